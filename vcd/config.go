@@ -1,12 +1,37 @@
 package vcd
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/hashicorp/terraform/helper/schema"
 	govcd "github.com/ukcloud/govcloudair" // Forked from vmware/govcloudair
 )
 
+// wrapTransport, when set, wraps the HTTP transport of every client created by
+// Config.Client. The acceptance tests use it to record and replay vCD API
+// interactions.
+var wrapTransport func(http.RoundTripper) http.RoundTripper
+
+// clientCache holds the authenticated clients of the provider process by
+// Config.cacheKey, so that configuring a provider again with the same
+// settings reuses the vCD session instead of logging in.
+var (
+	clientCacheMu sync.Mutex
+	clientCache   = make(map[string]*govcd.VCDClient)
+)
+
 type Config struct {
 	User            string
 	Password        string
@@ -15,28 +40,436 @@ type Config struct {
 	VDC             string
 	MaxRetryTimeout int
 	InsecureFlag    bool
+	DefaultTimeouts map[string]time.Duration
+
+	// SkipTLSVerifyHosts lists the host names for which certificate
+	// verification is disabled, while it stays enforced for any other host.
+	SkipTLSVerifyHosts []string
+
+	// CACertFile is a PEM file of CA certificates trusted in addition to
+	// the system ones.
+	CACertFile string
+
+	// HTTPTimeout is the number of seconds to wait for a connection and for
+	// the response headers of each request. It does not limit how long the
+	// body takes to transfer, as catalog uploads can take hours.
+	HTTPTimeout int
 }
 
 type VCDClient struct {
 	*govcd.VCDClient
 	MaxRetryTimeout int
 	InsecureFlag    bool
+	DefaultTimeouts map[string]time.Duration
 }
 
 func (c *Config) Client() (*VCDClient, error) {
+	clientCacheMu.Lock()
+	defer clientCacheMu.Unlock()
+
+	key := c.cacheKey()
+	client, ok := clientCache[key]
+	if !ok {
+		var err error
+		client, err = c.newClient()
+		if err != nil {
+			return nil, err
+		}
+		clientCache[key] = client
+	}
+
+	return &VCDClient{client, c.MaxRetryTimeout, c.InsecureFlag, c.DefaultTimeouts}, nil
+}
+
+// cacheKey identifies the session and the HTTP settings of the client. The
+// password is hashed so that a changed password does not reuse the session
+// opened with the old one.
+func (c *Config) cacheKey() string {
+	return strings.Join([]string{
+		c.Href, c.User, c.Org, c.VDC,
+		fmt.Sprintf("%x", sha256.Sum256([]byte(c.Password))),
+		fmt.Sprintf("%t", c.InsecureFlag),
+		fmt.Sprintf("%d", c.MaxRetryTimeout),
+		strings.Join(c.SkipTLSVerifyHosts, ","),
+		c.CACertFile,
+		fmt.Sprintf("%d", c.HTTPTimeout),
+	}, "\x00")
+}
+
+func (c *Config) newClient() (*govcd.VCDClient, error) {
 	u, err := url.ParseRequestURI(c.Href)
 	if err != nil {
 		return nil, fmt.Errorf("Something went wrong: %s", err)
 	}
 
-	vcdclient := &VCDClient{
-		govcd.NewVCDClient(*u, c.InsecureFlag),
-		c.MaxRetryTimeout, c.InsecureFlag}
+	vcdclient := &VCDClient{VCDClient: govcd.NewVCDClient(*u, c.InsecureFlag)}
+	transport := vcdclient.Client.Http.Transport.(*http.Transport)
+
+	if c.CACertFile != "" && c.InsecureFlag {
+		log.Printf("[WARN] Ignoring ca_cert_file %s, allow_unverified_ssl disables certificate verification", c.CACertFile)
+	} else if c.CACertFile != "" {
+		roots, err := loadCACertFile(c.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig.RootCAs = roots
+	}
+
+	dialer := &net.Dialer{}
+	if c.HTTPTimeout > 0 {
+		timeout := time.Duration(c.HTTPTimeout) * time.Second
+		dialer.Timeout = timeout
+		transport.DialContext = dialer.DialContext
+		transport.TLSHandshakeTimeout = timeout
+		transport.ResponseHeaderTimeout = timeout
+	}
+
+	if len(c.SkipTLSVerifyHosts) > 0 && !c.InsecureFlag {
+		transport.DialTLS = skipTLSVerifyDialer(dialer, transport.TLSClientConfig, transport.TLSHandshakeTimeout, c.SkipTLSVerifyHosts)
+	}
+
+	if c.MaxRetryTimeout > 0 {
+		vcdclient.Client.Http.Transport = &retryTransport{
+			transport: vcdclient.Client.Http.Transport,
+			timeout:   time.Duration(c.MaxRetryTimeout) * time.Second,
+			backoff:   time.Second,
+		}
+	}
+
+	if wrapTransport != nil {
+		vcdclient.Client.Http.Transport = wrapTransport(vcdclient.Client.Http.Transport)
+	}
+
+	// The login goes straight to the inner transport, so it never carries
+	// the token of the expired session
+	inner := vcdclient.Client.Http.Transport
+	vcdclient.Client.Http.Transport = &sessionTransport{
+		transport: inner,
+		login: func() (string, error) {
+			return login(inner, *u, c.User, c.Password, c.Org)
+		},
+	}
+
 	org, vcd, err := vcdclient.Authenticate(c.User, c.Password, c.Org, c.VDC)
 	if err != nil {
 		return nil, fmt.Errorf("Something went wrong: %s", err)
 	}
 	vcdclient.Org = org
 	vcdclient.OrgVdc = vcd
-	return vcdclient, nil
+	return vcdclient.VCDClient, nil
+}
+
+// resourceTimeouts lets a resource accept a timeouts block. The durations
+// are zero unless set in the block, so that retryTimeout can tell them apart
+// and fall back to the provider defaults.
+func resourceTimeouts() *schema.ResourceTimeout {
+	return &schema.ResourceTimeout{
+		Create:  schema.DefaultTimeout(time.Duration(0)),
+		Read:    schema.DefaultTimeout(time.Duration(0)),
+		Update:  schema.DefaultTimeout(time.Duration(0)),
+		Delete:  schema.DefaultTimeout(time.Duration(0)),
+		Default: schema.DefaultTimeout(time.Duration(0)),
+	}
+}
+
+// retryTimeout returns the number of seconds an operation of the given kind
+// (schema.TimeoutCreate, schema.TimeoutUpdate, ...) on the resource of d may
+// take. The timeouts block of the resource wins, then the provider-level
+// default_*_timeout, otherwise max_retry_timeout is used. d must belong to a
+// resource declaring resourceTimeouts.
+func (c *VCDClient) retryTimeout(d *schema.ResourceData, key string) int {
+	if timeout := d.Timeout(key); timeout > 0 {
+		return int(timeout.Seconds())
+	}
+	if timeout := d.Timeout(schema.TimeoutDefault); timeout > 0 {
+		return int(timeout.Seconds())
+	}
+	if timeout, ok := c.DefaultTimeouts[key]; ok {
+		return int(timeout.Seconds())
+	}
+	return c.MaxRetryTimeout
+}
+
+// findVdc returns the named VDC of the Org, or the provider VDC when name is
+// empty.
+func (c *VCDClient) findVdc(name string) (govcd.Vdc, error) {
+	if name == "" || name == c.OrgVdc.Vdc.Name {
+		return c.OrgVdc, nil
+	}
+
+	for _, l := range c.Org.Org.Link {
+		if l.Rel == "down" && l.Type == "application/vnd.vmware.vcloud.vdc+xml" && l.Name == name {
+			vdc := govcd.NewVdc(&c.Client)
+			vdc.Vdc.HREF = l.HREF
+
+			if err := vdc.Refresh(); err != nil {
+				return govcd.Vdc{}, err
+			}
+
+			return *vdc, nil
+		}
+	}
+
+	return govcd.Vdc{}, fmt.Errorf("can't find vdc: %s", name)
+}
+
+// supportedVersions is the answer of vCD to GET <href>/versions, which tells
+// where to log in.
+type supportedVersions struct {
+	VersionInfo struct {
+		Version  string `xml:"Version"`
+		LoginUrl string `xml:"LoginUrl"`
+	} `xml:"VersionInfo"`
+}
+
+// login opens a new vCD session with the login URL vCD publishes at href and
+// returns its token. It builds its own requests rather than using the shared
+// govcd.Client, whose token other requests keep reading.
+func login(transport http.RoundTripper, href url.URL, user, password, org string) (string, error) {
+	client := &http.Client{Transport: transport}
+
+	versionsHREF := href
+	versionsHREF.Path += "/versions"
+
+	resp, err := checkResp(client.Get(versionsHREF.String()))
+	if err != nil {
+		return "", fmt.Errorf("error finding LoginUrl: %s", err)
+	}
+
+	versions := new(supportedVersions)
+	if err = decodeBody(resp, versions); err != nil {
+		return "", fmt.Errorf("error decoding versions response: %s", err)
+	}
+
+	loginHREF, err := url.Parse(versions.VersionInfo.LoginUrl)
+	if err != nil || versions.VersionInfo.LoginUrl == "" {
+		return "", fmt.Errorf("couldn't find a LoginUrl in versions")
+	}
+
+	req, err := http.NewRequest("POST", loginHREF.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("error authorizing: %s", err)
+	}
+	req.SetBasicAuth(user+"@"+org, password)
+	req.Header.Add("Accept", "application/*+xml;version=5.5")
+
+	resp, err = checkResp(client.Do(req))
+	if err != nil {
+		return "", fmt.Errorf("error authorizing: %s", err)
+	}
+	drainBody(resp)
+
+	token := resp.Header.Get(vcdAuthorizationHeader)
+	if token == "" {
+		return "", fmt.Errorf("error authorizing: vCD returned no session token")
+	}
+	return token, nil
+}
+
+// loadCACertFile returns the system certificate pool with the certificates
+// of the PEM file added.
+func loadCACertFile(path string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read ca_cert_file: %s", err)
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("ca_cert_file %s does not contain any PEM encoded certificate", path)
+	}
+
+	return roots, nil
+}
+
+// skipTLSVerifyDialer returns a DialTLS function for http.Transport which
+// disables certificate verification only when connecting to one of the given
+// hosts. Connections to any other host are verified using tlsConfig. As
+// http.Transport no longer runs the handshake itself, it is bounded by
+// handshakeTimeout here. The transport does not use DialTLS for requests
+// sent through a proxy, whose connections are always verified.
+func skipTLSVerifyDialer(dialer *net.Dialer, tlsConfig *tls.Config, handshakeTimeout time.Duration, hosts []string) func(network, addr string) (net.Conn, error) {
+	skip := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		skip[host] = true
+	}
+
+	return func(network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		config := &tls.Config{}
+		if tlsConfig != nil {
+			config = tlsConfig.Clone()
+		}
+		config.ServerName = host
+		config.InsecureSkipVerify = skip[host]
+
+		rawConn, err := dialer.Dial(network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		conn := tls.Client(rawConn, config)
+		if handshakeTimeout > 0 {
+			conn.SetDeadline(time.Now().Add(handshakeTimeout))
+		}
+		if err := conn.Handshake(); err != nil {
+			rawConn.Close()
+			return nil, err
+		}
+		conn.SetDeadline(time.Time{})
+
+		return conn, nil
+	}
+}
+
+// retryTransport retries idempotent GET requests, which includes task
+// polling, when vCD answers with a transient server error or the connection
+// fails. Other methods are sent once, as a create that failed to respond may
+// still have succeeded. Retries back off exponentially until timeout, or the
+// deadline of the request context when that is sooner, is exhausted, after
+// which the last response or error is returned unchanged.
+type retryTransport struct {
+	transport http.RoundTripper
+	timeout   time.Duration
+	backoff   time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		return t.transport.RoundTrip(req)
+	}
+
+	deadline := time.Now().Add(t.timeout)
+	if d, ok := req.Context().Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	backoff := t.backoff
+	for {
+		resp, err := t.transport.RoundTrip(req)
+		if !isRetryableResponse(resp, err) || time.Now().Add(backoff).After(deadline) {
+			return resp, err
+		}
+
+		if err == nil {
+			log.Printf("[DEBUG] GET %s returned %s, retrying in %s", req.URL, resp.Status, backoff)
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			log.Printf("[DEBUG] GET %s failed: %s, retrying in %s", req.URL, err, backoff)
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func isRetryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// vcdAuthorizationHeader carries the session token of vCD requests.
+const vcdAuthorizationHeader = "X-Vcloud-Authorization"
+
+// sessionTransport logs in again when vCD rejects a request because its
+// session expired, and sends the request once more with the new token.
+// Concurrent requests failing on the same expired token share one login.
+// Requests without a token, like the login itself, and requests whose body
+// cannot be sent again are returned unchanged.
+//
+// The token of the govcd.Client the requests are built with is never
+// changed, as requests read it without locking. Once a new session is open
+// the transport puts its token on every request instead.
+type sessionTransport struct {
+	transport http.RoundTripper
+	login     func() (string, error)
+
+	mu    sync.RWMutex
+	token string
+}
+
+func (t *sessionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	used := req.Header.Get(vcdAuthorizationHeader)
+	if used == "" {
+		return t.transport.RoundTrip(req)
+	}
+
+	if token := t.currentToken(); token != "" && token != used {
+		req = withToken(req, token)
+		used = token
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+
+	token, err := t.renew(used)
+	if err != nil {
+		log.Printf("[DEBUG] Unable to renew the vCD session: %s", err)
+		return resp, nil
+	}
+
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	retry := withToken(req, token)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.transport.RoundTrip(retry)
+}
+
+func (t *sessionTransport) currentToken() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.token
+}
+
+// renew returns the token to use instead of the rejected one, logging in
+// again unless another request already did.
+func (t *sessionTransport) renew(rejected string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && t.token != rejected {
+		return t.token, nil
+	}
+
+	log.Printf("[DEBUG] vCD session expired, logging in again")
+	token, err := t.login()
+	if err != nil {
+		return "", err
+	}
+	t.token = token
+	return token, nil
+}
+
+// withToken returns a copy of req carrying token, leaving req untouched as
+// http.RoundTripper requires.
+func withToken(req *http.Request, token string) *http.Request {
+	clone := req.Clone(req.Context())
+	clone.Header.Set(vcdAuthorizationHeader, token)
+	return clone
 }
@@ -0,0 +1,423 @@
+package vcd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	govcd "github.com/ukcloud/govcloudair"
+)
+
+func TestSkipTLSVerifyDialer(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	trusted := httptest.NewTLSServer(handler)
+	defer trusted.Close()
+	// httptest servers share a built-in certificate, so give the second
+	// server a certificate of its own which is not part of the root CAs
+	selfSigned := httptest.NewUnstartedServer(handler)
+	selfSigned.TLS = &tls.Config{Certificates: []tls.Certificate{generateSelfSignedCertificate(t)}}
+	selfSigned.StartTLS()
+	defer selfSigned.Close()
+
+	// Only the certificate of the trusted server is part of the root CAs
+	roots := x509.NewCertPool()
+	roots.AddCert(trusted.Certificate())
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialTLS: skipTLSVerifyDialer(&net.Dialer{}, &tls.Config{RootCAs: roots}, 0, []string{"localhost"}),
+		},
+	}
+
+	get := func(serverURL, host string) error {
+		u, err := url.Parse(serverURL)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		u.Host = strings.Replace(u.Host, "127.0.0.1", host, 1)
+		resp, err := client.Get(u.String())
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(trusted.URL, "127.0.0.1"); err != nil {
+		t.Fatalf("trusted host should be verified successfully: %s", err)
+	}
+	if err := get(selfSigned.URL, "localhost"); err != nil {
+		t.Fatalf("verification should be skipped for listed host: %s", err)
+	}
+	if err := get(selfSigned.URL, "127.0.0.1"); err == nil {
+		t.Fatalf("self-signed certificate should be rejected for a host which is not listed")
+	}
+}
+
+func TestSkipTLSVerifyDialerHandshakeTimeout(t *testing.T) {
+	// The listener accepts connections but never answers the handshake
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	dial := skipTLSVerifyDialer(&net.Dialer{}, nil, 100*time.Millisecond, []string{"127.0.0.1"})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := dial("tcp", l.Addr().String())
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("expected the handshake to time out")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("handshake was not bounded by the timeout")
+	}
+}
+
+func generateSelfSignedCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestRetryTransport(t *testing.T) {
+	failures := 0
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &retryTransport{
+			transport: http.DefaultTransport,
+			timeout:   time.Second,
+			backoff:   10 * time.Millisecond,
+		},
+	}
+
+	cases := []struct {
+		method   string
+		failures int
+		status   int
+		requests int
+	}{
+		// Transient errors on GET are retried until they succeed
+		{"GET", 2, http.StatusOK, 3},
+		// Non-idempotent requests are only sent once
+		{"POST", 2, http.StatusServiceUnavailable, 1},
+		// The last response is returned once the timeout is exhausted
+		{"GET", 100, http.StatusServiceUnavailable, 7},
+	}
+
+	for _, tc := range cases {
+		failures, requests = tc.failures, 0
+
+		req, err := http.NewRequest(tc.method, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s with %d failures: %s", tc.method, tc.failures, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s with %d failures: expected status %d, got %d", tc.method, tc.failures, tc.status, resp.StatusCode)
+		}
+		if requests != tc.requests {
+			t.Errorf("%s with %d failures: expected %d requests, got %d", tc.method, tc.failures, tc.requests, requests)
+		}
+	}
+}
+
+func TestSessionTransport(t *testing.T) {
+	valid := "new"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(vcdAuthorizationHeader) != valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	logins := 0
+	transport := &sessionTransport{
+		transport: http.DefaultTransport,
+		login: func() (string, error) {
+			logins++
+			return valid, nil
+		},
+	}
+	client := &http.Client{Transport: transport}
+
+	send := func(sent string) *http.Response {
+		req, err := http.NewRequest("POST", server.URL, strings.NewReader("payload"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sent != "" {
+			req.Header.Set(vcdAuthorizationHeader, sent)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// An expired token is renewed and the request sent again with its body
+	resp := send("old")
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "payload" {
+		t.Errorf("expected the request to be resent, got status %d and body %q", resp.StatusCode, body)
+	}
+
+	// A request which was in flight with the old token reuses the new one
+	resp = send("old")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || logins != 1 {
+		t.Errorf("expected status 200 after 1 login, got %d after %d logins", resp.StatusCode, logins)
+	}
+
+	// Requests without a token are not retried
+	resp = send("")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || logins != 1 {
+		t.Errorf("expected status 401 after 1 login, got %d after %d logins", resp.StatusCode, logins)
+	}
+}
+
+// TestSessionTransportConcurrentRenewal sends requests built by a shared
+// govcd.Client while the session is renewed. Run it with -race, the token of
+// the client must not be written while requests read it.
+func TestSessionTransportConcurrentRenewal(t *testing.T) {
+	var valid atomic.Value
+	valid.Store("old")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(vcdAuthorizationHeader) != valid.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logins int32
+	client := &govcd.Client{
+		VCDToken:      "old",
+		VCDAuthHeader: "x-vcloud-authorization",
+		APIVersion:    "5.5",
+	}
+	client.Http.Transport = &sessionTransport{
+		transport: http.DefaultTransport,
+		login: func() (string, error) {
+			atomic.AddInt32(&logins, 1)
+			return "new", nil
+		},
+	}
+
+	// The session expires while the requests are in flight
+	valid.Store("new")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Http.Do(client.NewRequest(map[string]string{}, "GET", *u, nil))
+			if err != nil {
+				errs <- err
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				errs <- fmt.Errorf("expected status 200, got %d", resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if n := atomic.LoadInt32(&logins); n != 1 {
+		t.Errorf("expected the requests to share 1 login, got %d", n)
+	}
+	if client.VCDToken != "old" {
+		t.Errorf("expected the token of the client to be left alone, got %q", client.VCDToken)
+	}
+}
+
+func TestConfigCACertFile(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	bogus, err := ioutil.TempFile("", "vcd-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(bogus.Name())
+	bogus.WriteString("not a certificate")
+	bogus.Close()
+
+	cases := []struct {
+		file     string
+		insecure bool
+		message  string
+	}{
+		{bogus.Name(), false, "does not contain any PEM encoded certificate"},
+		{bogus.Name() + ".missing", false, "Unable to read ca_cert_file"},
+	}
+
+	for _, c := range cases {
+		config := Config{
+			User:         "user",
+			Password:     "password",
+			Org:          "org",
+			Href:         server.URL + "/api",
+			InsecureFlag: c.insecure,
+			CACertFile:   c.file,
+		}
+
+		_, err := config.Client()
+		if err == nil || !strings.Contains(err.Error(), c.message) {
+			t.Errorf("%s: expected an error containing %q, got %v", c.file, c.message, err)
+		}
+	}
+
+	if requests != 0 {
+		t.Errorf("expected no request to vcd, got %d", requests)
+	}
+
+	// allow_unverified_ssl wins, so the file is not read at all
+	config := Config{
+		User:         "user",
+		Password:     "password",
+		Org:          "org",
+		Href:         server.URL + "/api",
+		InsecureFlag: true,
+		CACertFile:   bogus.Name(),
+	}
+
+	_, err = config.Client()
+	if err == nil || strings.Contains(err.Error(), "ca_cert_file") {
+		t.Errorf("expected ca_cert_file to be ignored with allow_unverified_ssl, got %v", err)
+	}
+	if requests == 0 {
+		t.Errorf("expected the client to log in to vcd")
+	}
+}
+
+func TestRetryTransportContextDeadline(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &retryTransport{
+			transport: http.DefaultTransport,
+			timeout:   time.Minute,
+			backoff:   10 * time.Millisecond,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The deadline of the request is sooner than the timeout of the
+	// transport, so retries stop at the deadline
+	start := time.Now()
+	resp, err := client.Do(req.WithContext(ctx))
+	if err == nil {
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the retries to stop at the request deadline, took %s", elapsed)
+	}
+	if requests < 2 {
+		t.Errorf("expected the request to be retried until its deadline, got %d requests", requests)
+	}
+}
@@ -0,0 +1,93 @@
+package vcd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceVcdCatalogItem() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdCatalogItemRead,
+
+		Schema: map[string]*schema.Schema{
+			"catalog": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"created": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"metadata": &schema.Schema{
+				Type:     schema.TypeMap,
+				Computed: true,
+			},
+
+			"href": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"template_href": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The href of the vApp template or media the item refers to",
+			},
+		},
+	}
+}
+
+func dataSourceVcdCatalogItemRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	catalogName := d.Get("catalog").(string)
+	name := d.Get("name").(string)
+
+	catalog, err := vcdClient.findCatalog(catalogName)
+	if err != nil {
+		return fmt.Errorf("Catalog %s not found in org %s: %#v", catalogName, vcdClient.Org.Org.Name, err)
+	}
+
+	item, err := catalog.FindCatalogItem(name)
+	if err != nil {
+		return fmt.Errorf("Catalog item %s not found in catalog %s: %#v", name, catalogName, err)
+	}
+
+	metadata, err := getMetadata(&vcdClient.Client, item.CatalogItem.HREF)
+	if err != nil {
+		return fmt.Errorf("Error reading metadata of catalog item %s: %#v", name, err)
+	}
+
+	entries := make(map[string]interface{})
+	for _, entry := range metadata.MetadataEntry {
+		if entry.TypedValue != nil {
+			entries[entry.Key] = entry.TypedValue.Value
+		}
+	}
+
+	d.SetId(item.CatalogItem.HREF)
+	d.Set("description", item.CatalogItem.Description)
+	d.Set("created", item.CatalogItem.DateCreated)
+	d.Set("href", item.CatalogItem.HREF)
+	if item.CatalogItem.Entity != nil {
+		d.Set("template_href", item.CatalogItem.Entity.HREF)
+	}
+	if err := d.Set("metadata", entries); err != nil {
+		return fmt.Errorf("Error setting metadata: %#v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package vcd
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccVcdCatalogItemDataSource_Basic(t *testing.T) {
+	generatedHrefRegexp := regexp.MustCompile("^https://")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccCheckVcdCatalogItemDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr(
+						"data.vcd_catalog_item.centos", "template_href", generatedHrefRegexp),
+					resource.TestCheckResourceAttrSet(
+						"data.vcd_catalog_item.centos", "created"),
+				),
+			},
+			resource.TestStep{
+				Config:      testAccCheckVcdCatalogItemDataSource_missing,
+				ExpectError: regexp.MustCompile("Catalog item does-not-exist not found in catalog Skyscape Catalogue"),
+			},
+		},
+	})
+}
+
+const testAccCheckVcdCatalogItemDataSource_basic = `
+data "vcd_catalog_item" "centos" {
+  catalog = "Skyscape Catalogue"
+  name    = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+}
+`
+
+const testAccCheckVcdCatalogItemDataSource_missing = `
+data "vcd_catalog_item" "missing" {
+  catalog = "Skyscape Catalogue"
+  name    = "does-not-exist"
+}
+`
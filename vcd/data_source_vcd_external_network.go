@@ -0,0 +1,160 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceVcdExternalNetwork() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdExternalNetworkRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"href": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"gateway": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"netmask": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"dns1": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"dns2": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"dns_suffix": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"ip_scope": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"gateway": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"netmask": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"dns1": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"dns2": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"dns_suffix": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"enabled": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+
+						"static_ip_pool": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"start_address": &schema.Schema{
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+
+									"end_address": &schema.Schema{
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVcdExternalNetworkRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	name := d.Get("name").(string)
+
+	network, err := vcdClient.findExternalNetwork(name)
+	if err != nil {
+		return fmt.Errorf("Error finding external network %s: %#v", name, err)
+	}
+
+	log.Printf("[DEBUG] External network: %#v", network)
+
+	scopes := []map[string]interface{}{}
+	if network.Configuration != nil && network.Configuration.IPScopes != nil {
+		for _, scope := range network.Configuration.IPScopes.IPScope {
+			pools := []map[string]interface{}{}
+			if scope.IPRanges != nil {
+				pools = flattenIPRange(scope.IPRanges)
+			}
+
+			scopes = append(scopes, map[string]interface{}{
+				"gateway":        scope.Gateway,
+				"netmask":        scope.Netmask,
+				"dns1":           scope.DNS1,
+				"dns2":           scope.DNS2,
+				"dns_suffix":     scope.DNSSuffix,
+				"enabled":        scope.IsEnabled,
+				"static_ip_pool": pools,
+			})
+		}
+	}
+
+	d.SetId(network.HREF)
+	d.Set("href", network.HREF)
+	d.Set("description", network.Description)
+
+	// The first scope is the primary subnet of the network
+	if len(scopes) > 0 {
+		d.Set("gateway", scopes[0]["gateway"])
+		d.Set("netmask", scopes[0]["netmask"])
+		d.Set("dns1", scopes[0]["dns1"])
+		d.Set("dns2", scopes[0]["dns2"])
+		d.Set("dns_suffix", scopes[0]["dns_suffix"])
+	}
+
+	return d.Set("ip_scope", scopes)
+}
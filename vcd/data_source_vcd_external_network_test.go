@@ -0,0 +1,51 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccVcdExternalNetworkDataSource_Basic(t *testing.T) {
+	if v := os.Getenv("VCD_SYSTEM_ADMIN"); v == "" {
+		t.Skip("Environment variable VCD_SYSTEM_ADMIN must be set to run external network tests")
+		return
+	}
+	if v := os.Getenv("VCD_EXTERNAL_NETWORK"); v == "" {
+		t.Skip("Environment variable VCD_EXTERNAL_NETWORK must be set to run external network tests")
+		return
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdExternalNetworkDataSource_basic, os.Getenv("VCD_EXTERNAL_NETWORK")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.vcd_external_network.ext", "name", os.Getenv("VCD_EXTERNAL_NETWORK")),
+					resource.TestMatchResourceAttr(
+						"data.vcd_external_network.ext", "href", regexp.MustCompile("^https://")),
+					resource.TestMatchResourceAttr(
+						"data.vcd_external_network.ext", "gateway", regexp.MustCompile(`^\d+\.\d+\.\d+\.\d+$`)),
+					resource.TestCheckResourceAttrSet(
+						"data.vcd_external_network.ext", "ip_scope.0.netmask"),
+				),
+			},
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckVcdExternalNetworkDataSource_basic, "no-such-network"),
+				ExpectError: regexp.MustCompile("can't find external network: no-such-network"),
+			},
+		},
+	})
+}
+
+const testAccCheckVcdExternalNetworkDataSource_basic = `
+data "vcd_external_network" "ext" {
+  name = "%s"
+}
+`
@@ -0,0 +1,94 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceVcdOrgCatalogs() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdOrgCatalogsRead,
+
+		Schema: map[string]*schema.Schema{
+			"catalogs": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"href": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"published": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+
+						"item_count": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVcdOrgCatalogsRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	// Catalogs may have been created or deleted since the provider logged in
+	org, err := vcdClient.getOrg()
+	if err != nil {
+		return fmt.Errorf("Error refreshing org: %#v", err)
+	}
+
+	// The Org only links the catalogs the caller is allowed to see
+	catalogs := make([]map[string]interface{}, 0)
+	for _, link := range org.Org.Link {
+		if link.Rel != "down" || link.Type != "application/vnd.vmware.vcloud.catalog+xml" {
+			continue
+		}
+
+		catalog, err := org.FindCatalog(link.Name)
+		if err != nil {
+			return fmt.Errorf("Error reading catalog %s: %#v", link.Name, err)
+		}
+
+		itemCount := 0
+		for _, items := range catalog.Catalog.CatalogItems {
+			itemCount += len(items.CatalogItem)
+		}
+
+		catalogs = append(catalogs, map[string]interface{}{
+			"name":       catalog.Catalog.Name,
+			"id":         catalog.Catalog.ID,
+			"href":       catalog.Catalog.HREF,
+			"published":  catalog.Catalog.IsPublished,
+			"item_count": itemCount,
+		})
+	}
+
+	log.Printf("[DEBUG] Org %s catalogs: %#v", org.Org.Name, catalogs)
+
+	d.SetId(org.Org.HREF)
+	if err := d.Set("catalogs", catalogs); err != nil {
+		return fmt.Errorf("Error setting catalogs: %#v", err)
+	}
+
+	return nil
+}
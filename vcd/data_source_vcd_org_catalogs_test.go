@@ -0,0 +1,32 @@
+package vcd
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccVcdOrgCatalogsDataSource_Basic(t *testing.T) {
+	generatedHrefRegexp := regexp.MustCompile("^https://")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: testAccCheckVcdOrgCatalogsDataSource_basic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr(
+						"data.vcd_org_catalogs.all", "id", generatedHrefRegexp),
+					resource.TestCheckResourceAttrSet(
+						"data.vcd_org_catalogs.all", "catalogs.#"),
+				),
+			},
+		},
+	})
+}
+
+const testAccCheckVcdOrgCatalogsDataSource_basic = `
+data "vcd_org_catalogs" "all" {}
+`
@@ -0,0 +1,75 @@
+package vcd
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceVcdStorageProfile() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdStorageProfileRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"vdc": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The VDC the storage profile belongs to. Defaults to the provider VDC",
+			},
+
+			"href": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"limit": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"used": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"default": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceVcdStorageProfileRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	name := d.Get("name").(string)
+	vdc, err := vcdClient.findVdc(d.Get("vdc").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding VDC: %#v", err)
+	}
+	vdcName := vdc.Vdc.Name
+
+	record, err := vcdClient.findStorageProfileRecord(vdc, name)
+	if err != nil {
+		return fmt.Errorf("Error finding storage profile: %#v", err)
+	}
+
+	if !record.IsEnabled {
+		return fmt.Errorf("Storage profile %s is not enabled in VDC %s", name, vdcName)
+	}
+
+	d.SetId(record.HREF)
+	d.Set("vdc", record.VdcName)
+	d.Set("href", record.HREF)
+	d.Set("limit", record.StorageLimitMB)
+	d.Set("used", record.StorageUsedMB)
+	d.Set("default", record.IsDefaultStorageProfile)
+
+	return nil
+}
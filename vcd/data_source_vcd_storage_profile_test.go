@@ -0,0 +1,43 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccVcdStorageProfileDataSource_Basic(t *testing.T) {
+	if v := os.Getenv("VCD_STORAGE_PROFILE"); v == "" {
+		t.Skip("Environment variable VCD_STORAGE_PROFILE must be set to run storage profile tests")
+		return
+	}
+
+	generatedHrefRegexp := regexp.MustCompile("^https://")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdStorageProfileDataSource_basic, os.Getenv("VCD_STORAGE_PROFILE")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"data.vcd_storage_profile.sp", "name", os.Getenv("VCD_STORAGE_PROFILE")),
+					resource.TestCheckResourceAttr(
+						"data.vcd_storage_profile.sp", "vdc", os.Getenv("VCD_VDC")),
+					resource.TestMatchResourceAttr(
+						"data.vcd_storage_profile.sp", "href", generatedHrefRegexp),
+				),
+			},
+		},
+	})
+}
+
+const testAccCheckVcdStorageProfileDataSource_basic = `
+data "vcd_storage_profile" "sp" {
+  name = "%s"
+}
+`
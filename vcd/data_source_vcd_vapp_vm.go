@@ -0,0 +1,142 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+func dataSourceVcdVAppVm() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVcdVAppVmRead,
+
+		Schema: map[string]*schema.Schema{
+			"vapp_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"href": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"ip": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"cpus": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"cpu_cores": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"memory": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"power_state": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"storage_profile": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"network": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"ip_allocation_mode": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"ip": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"is_primary": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+
+						"adapter_type": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+
+						"connected": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVcdVAppVmRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vappName := d.Get("vapp_name").(string)
+	name := d.Get("name").(string)
+
+	vapp, err := vcdClient.findVAppByName(vappName)
+	if err != nil {
+		return fmt.Errorf("Error finding vApp %s: %s", vappName, err)
+	}
+
+	// FindVMByName expects the vApp to have VMs
+	if vapp.VApp.Children == nil {
+		return fmt.Errorf("Error finding VM %s: vApp %s has no VMs", name, vappName)
+	}
+
+	vm, err := vcdClient.findVMByName(vapp, name)
+	if err != nil {
+		return fmt.Errorf("Error finding VM %s in vApp %s: %s", name, vappName, err)
+	}
+
+	log.Printf("[DEBUG] VM: %#v", vm.VM)
+
+	d.SetId(vm.VM.HREF)
+	d.Set("href", vm.VM.HREF)
+	d.Set("power_state", types.VAppStatuses[vm.VM.Status])
+	d.Set("memory", vm.Memory())
+
+	cpus, cores := vm.CPUs()
+	d.Set("cpus", cpus)
+	d.Set("cpu_cores", cores)
+
+	if primary := vm.PrimaryNetworkConnection(); primary != nil {
+		d.Set("ip", primary.IPAddress)
+	}
+	if vm.VM.StorageProfile != nil {
+		d.Set("storage_profile", vm.VM.StorageProfile.Name)
+	}
+
+	return d.Set("network", flattenVmNetworks(vm.NetworkConnections()))
+}
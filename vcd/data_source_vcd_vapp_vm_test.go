@@ -0,0 +1,81 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccVcdVAppVmDataSource_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdVAppVmDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVmDataSource_basic, os.Getenv("VCD_EDGE_GATEWAY"), "moo"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair(
+						"data.vcd_vapp_vm.moo", "href", "vcd_vapp_vm.moo", "href"),
+					resource.TestCheckResourceAttr(
+						"data.vcd_vapp_vm.moo", "ip", "10.10.102.161"),
+					resource.TestCheckResourceAttr(
+						"data.vcd_vapp_vm.moo", "cpus", "1"),
+					resource.TestCheckResourceAttr(
+						"data.vcd_vapp_vm.moo", "memory", "1024"),
+					resource.TestCheckResourceAttr(
+						"data.vcd_vapp_vm.moo", "power_state", "POWERED_ON"),
+					resource.TestCheckResourceAttr(
+						"data.vcd_vapp_vm.moo", "network.#", "1"),
+					resource.TestCheckResourceAttr(
+						"data.vcd_vapp_vm.moo", "network.0.name", "foonet"),
+				),
+			},
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckVcdVAppVmDataSource_basic, os.Getenv("VCD_EDGE_GATEWAY"), "no-such-vm"),
+				ExpectError: regexp.MustCompile("Error finding VM no-such-vm in vApp foobar"),
+			},
+		},
+	})
+}
+
+const testAccCheckVcdVAppVmDataSource_basic = `
+resource "vcd_network" "foonet" {
+	name = "foonet"
+	edge_gateway = "%s"
+	gateway = "10.10.102.1"
+	static_ip_pool {
+		start_address = "10.10.102.2"
+		end_address = "10.10.102.254"
+	}
+}
+
+resource "vcd_vapp" "foobar" {
+  name          = "foobar"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  catalog_name  = "Skyscape Catalogue"
+  network_name  = "${vcd_network.foonet.name}"
+  memory        = 1024
+  cpus          = 1
+  ip            = "10.10.102.160"
+}
+
+resource "vcd_vapp_vm" "moo" {
+  vapp_name     = "${vcd_vapp.foobar.name}"
+  name          = "moo"
+  catalog_name  = "Skyscape Catalogue"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  memory        = 1024
+  cpus          = 1
+  ip            = "10.10.102.161"
+}
+
+data "vcd_vapp_vm" "moo" {
+  vapp_name  = "${vcd_vapp.foobar.name}"
+  name       = "%s"
+  depends_on = ["vcd_vapp_vm.moo"]
+}
+`
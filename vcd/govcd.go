@@ -0,0 +1,231 @@
+package vcd
+
+// The govcd_*.go files hold the vCD API calls the provider needs and the
+// vendored govcloudair lacks. They extend the govcloudair objects, with the
+// same conventions, rather than patching the vendored copy.
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	govcd "github.com/ukcloud/govcloudair"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+// apiRequest sends a request to the vCD API at href and checks its response.
+// body, when not nil, is marshaled to XML and sent as contentType.
+func apiRequest(c *govcd.Client, method, href, contentType string, params map[string]string, body interface{}) (*http.Response, error) {
+	return apiRequestContext(context.Background(), c, method, href, contentType, params, body)
+}
+
+// apiRequestContext is apiRequest with a context, whose deadline also stops
+// retryTransport from retrying the request past it.
+func apiRequestContext(ctx context.Context, c *govcd.Client, method, href, contentType string, params map[string]string, body interface{}) (*http.Response, error) {
+	u, err := url.ParseRequestURI(href)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding href: %s", err)
+	}
+
+	var b io.Reader
+	if body != nil {
+		output, err := xml.MarshalIndent(body, "  ", "    ")
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling request: %s", err)
+		}
+		b = bytes.NewBufferString(xml.Header + string(output))
+	}
+
+	if params == nil {
+		params = map[string]string{}
+	}
+
+	req := c.NewRequest(params, method, *u, b).WithContext(ctx)
+	if contentType != "" {
+		req.Header.Add("Content-Type", contentType)
+	}
+
+	return checkResp(c.Http.Do(req))
+}
+
+// apiTask sends a request to the vCD API at href, like apiRequest, and
+// returns the task vCD answers with.
+func apiTask(c *govcd.Client, method, href, contentType string, body interface{}) (govcd.Task, error) {
+	resp, err := apiRequest(c, method, href, contentType, nil, body)
+	if err != nil {
+		return govcd.Task{}, err
+	}
+
+	task := govcd.NewTask(c)
+
+	if err = decodeBody(resp, task.Task); err != nil {
+		return govcd.Task{}, fmt.Errorf("error decoding Task response: %s", err)
+	}
+
+	// The request was successful
+	return *task, nil
+}
+
+// taskPollInterval is how long waitTask waits between polls of a task, the
+// same as Task.WaitTaskCompletion.
+var taskPollInterval = 3 * time.Second
+
+// waitTask waits for the task to complete, like Task.WaitTaskCompletion, but
+// gives up after timeout seconds.
+func waitTask(c *govcd.Client, task govcd.Task, timeout int) error {
+
+	if task.Task == nil {
+		return fmt.Errorf("cannot refresh, Object is empty")
+	}
+
+	href := task.Task.HREF
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	for {
+		resp, err := apiRequestContext(ctx, c, "GET", href, "", nil, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("timed out after %d seconds waiting for task %s", timeout, task.Task.Operation)
+			}
+			return fmt.Errorf("error retrieving task: %s", err)
+		}
+
+		task.Task = new(types.Task)
+		if err = decodeBody(resp, task.Task); err != nil {
+			return fmt.Errorf("error decoding task response: %s", err)
+		}
+
+		switch task.Task.Status {
+		case "queued", "preRunning", "running":
+		case "error":
+			return fmt.Errorf("task did not complete successfully: %s", task.Task.Description)
+		default:
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %d seconds waiting for task %s", timeout, task.Task.Operation)
+		case <-time.After(taskPollInterval):
+		}
+	}
+}
+
+// waitResponseTask waits up to timeout seconds for the task returned by
+// requests which vCD may either complete right away or run in the
+// background.
+func waitResponseTask(c *govcd.Client, resp *http.Response, timeout int) error {
+	if resp.StatusCode != http.StatusAccepted {
+		drainBody(resp)
+		return nil
+	}
+
+	task := govcd.NewTask(c)
+
+	if err := decodeBody(resp, task.Task); err != nil {
+		return fmt.Errorf("error decoding task response: %s", err)
+	}
+
+	return waitTask(c, *task, timeout)
+}
+
+// waitTasks waits up to timeout seconds for each of the tasks vCD lists in a
+// created object.
+func waitTasks(c *govcd.Client, tasks *types.TasksInProgress, timeout int) error {
+	if tasks == nil {
+		return nil
+	}
+
+	task := govcd.NewTask(c)
+	for _, t := range tasks.Task {
+		task.Task = t
+		if err := waitTask(c, *task, timeout); err != nil {
+			return fmt.Errorf("error performing task: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// queryFilterEscaper percent-encodes the characters the query service
+// separates filter conditions and their values with, and the percent sign
+// itself.
+var queryFilterEscaper = strings.NewReplacer("%", "%25", ";", "%3B", ",", "%2C", "=", "%3D")
+
+// queryFilterValue escapes a value compared in a query filter, e.g. a name,
+// so that it can not end the condition it appears in.
+func queryFilterValue(value string) string {
+	return queryFilterEscaper.Replace(value)
+}
+
+// drainBody reads the rest of the body, so that the connection can be
+// reused, and closes it.
+func drainBody(resp *http.Response) {
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// The helpers below are copies of the unexported ones of govcloudair, so that
+// the responses are checked and decoded the same way.
+
+// parseErr takes an error XML resp and returns a single string for use in error messages.
+func parseErr(resp *http.Response) error {
+
+	errBody := new(types.Error)
+
+	// if there was an error decoding the body, just return that
+	if err := decodeBody(resp, errBody); err != nil {
+		return fmt.Errorf("error parsing error body for non-200 request: %s", err)
+	}
+
+	return fmt.Errorf("API Error: %d: %s", errBody.MajorErrorCode, errBody.Message)
+}
+
+// decodeBody is used to XML decode a response body
+func decodeBody(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// Unmarshal the XML.
+	if err = xml.Unmarshal(body, &out); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkResp wraps http.Client.Do() and verifies the request, if status code
+// is 2XX it passes back the response, if it's a known invalid status code it
+// parses the resultant XML error and returns a descriptive error, if the
+// status code is not handled it returns a generic error with the status code.
+func checkResp(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil {
+		return resp, err
+	}
+
+	switch i := resp.StatusCode; {
+	// Valid request, return the response.
+	case i == 200 || i == 201 || i == 202 || i == 204:
+		return resp, nil
+	// Invalid request, parse the XML error returned and return it.
+	case i == 400 || i == 401 || i == 403 || i == 404 || i == 405 || i == 406 || i == 409 || i == 415 || i == 500 || i == 503 || i == 504:
+		return nil, parseErr(resp)
+	// Unhandled response.
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("unhandled API response, please report this issue, status code: %s", resp.Status)
+	}
+}
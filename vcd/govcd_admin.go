@@ -0,0 +1,418 @@
+package vcd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	govcd "github.com/ukcloud/govcloudair"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+// adminOrg is the admin view of an organization, which system
+// administrators use to manage it.
+type adminOrg struct {
+	AdminOrg *adminOrgType
+	c        *govcd.Client
+}
+
+// adminVdc is the admin view of an organization VDC.
+type adminVdc struct {
+	AdminVdc *adminVdcType
+	c        *govcd.Client
+}
+
+// providerVdc is a provider VDC, which backs the organization VDCs with
+// compute, storage and network pools.
+type providerVdc struct {
+	ProviderVdc *providerVdcType
+	c           *govcd.Client
+}
+
+// adminHREF returns the href of the admin API at path.
+func (c *VCDClient) adminHREF(path string) string {
+	u := c.OrgHREF
+	u.Path = path
+	return u.String()
+}
+
+// createOrg creates an organization and waits up to timeout seconds for the
+// creation to complete. It requires system administrator rights.
+func (c *VCDClient) createOrg(org *adminOrgType, timeout int) (adminOrg, error) {
+
+	org.Xmlns = "http://www.vmware.com/vcloud/v1.5"
+
+	resp, err := apiRequest(&c.Client, "POST", c.adminHREF("/api/admin/orgs"), "application/vnd.vmware.admin.organization+xml", nil, org)
+	if err != nil {
+		return adminOrg{}, fmt.Errorf("error creating org: %s", err)
+	}
+
+	created := adminOrg{AdminOrg: new(adminOrgType), c: &c.Client}
+
+	if err = decodeBody(resp, created.AdminOrg); err != nil {
+		return adminOrg{}, fmt.Errorf("error decoding org response: %s", err)
+	}
+
+	if err = waitTasks(&c.Client, created.AdminOrg.Tasks, timeout); err != nil {
+		return adminOrg{}, err
+	}
+
+	// The request was successful
+	return created, nil
+}
+
+func (c *VCDClient) getAdminOrg(href string) (adminOrg, error) {
+
+	resp, err := apiRequest(&c.Client, "GET", href, "", nil, nil)
+	if err != nil {
+		return adminOrg{}, fmt.Errorf("error retrieving org: %s", err)
+	}
+
+	org := adminOrg{AdminOrg: new(adminOrgType), c: &c.Client}
+
+	if err = decodeBody(resp, org.AdminOrg); err != nil {
+		return adminOrg{}, fmt.Errorf("error decoding org response: %s", err)
+	}
+
+	// The request was successful
+	return org, nil
+}
+
+// UpdateGeneralSettings replaces the general settings, such as the VM
+// quotas, of the organization, waiting up to timeout seconds for vCD to apply
+// them.
+func (o *adminOrg) UpdateGeneralSettings(settings *orgGeneralSettingsType, timeout int) error {
+
+	settings.Xmlns = "http://www.vmware.com/vcloud/v1.5"
+	settings.HREF = ""
+	settings.Type = ""
+	settings.Link = nil
+
+	resp, err := apiRequest(o.c, "PUT", o.AdminOrg.HREF+"/settings/general", "application/vnd.vmware.admin.organizationGeneralSettings+xml", nil, settings)
+	if err != nil {
+		return fmt.Errorf("error updating org settings: %s", err)
+	}
+
+	return waitResponseTask(o.c, resp, timeout)
+}
+
+func (o *adminOrg) Enable() error {
+	return o.action("enable")
+}
+
+func (o *adminOrg) Disable() error {
+	return o.action("disable")
+}
+
+// Delete removes the organization, waiting up to timeout seconds for vCD to
+// complete the removal.
+func (o *adminOrg) Delete(timeout int) error {
+
+	resp, err := apiRequest(o.c, "DELETE", o.AdminOrg.HREF, "", nil, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting org: %s", err)
+	}
+
+	return waitResponseTask(o.c, resp, timeout)
+}
+
+func (o *adminOrg) action(action string) error {
+
+	resp, err := apiRequest(o.c, "POST", o.AdminOrg.HREF+"/action/"+action, "", nil, nil)
+	if err != nil {
+		return fmt.Errorf("error performing %s on org: %s", action, err)
+	}
+	drainBody(resp)
+
+	// The request was successful
+	return nil
+}
+
+// getVCloud returns the admin view of the cloud, which references the
+// organizations and provider VDCs. It requires system administrator rights.
+func (c *VCDClient) getVCloud() (*vCloudType, error) {
+
+	resp, err := apiRequest(&c.Client, "GET", c.adminHREF("/api/admin"), "", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving admin view: %s", err)
+	}
+
+	vcloud := &vCloudType{}
+
+	if err = decodeBody(resp, vcloud); err != nil {
+		return nil, fmt.Errorf("error decoding admin view response: %s", err)
+	}
+
+	return vcloud, nil
+}
+
+// findAdminOrg returns the admin view of the named organization.
+func (c *VCDClient) findAdminOrg(name string) (adminOrg, error) {
+
+	vcloud, err := c.getVCloud()
+	if err != nil {
+		return adminOrg{}, err
+	}
+
+	if vcloud.OrganizationReferences != nil {
+		for _, ref := range vcloud.OrganizationReferences.Reference {
+			if ref.Name == name {
+				return c.getAdminOrg(ref.HREF)
+			}
+		}
+	}
+
+	return adminOrg{}, fmt.Errorf("can't find org: %s", name)
+}
+
+// findProviderVdc returns the named provider VDC.
+func (c *VCDClient) findProviderVdc(name string) (providerVdc, error) {
+
+	vcloud, err := c.getVCloud()
+	if err != nil {
+		return providerVdc{}, err
+	}
+
+	if vcloud.ProviderVdcReferences != nil {
+		for _, ref := range vcloud.ProviderVdcReferences.Reference {
+			if ref.Name != name {
+				continue
+			}
+
+			resp, err := apiRequest(&c.Client, "GET", ref.HREF, "", nil, nil)
+			if err != nil {
+				return providerVdc{}, fmt.Errorf("error retrieving provider vdc: %s", err)
+			}
+
+			pvdc := providerVdc{ProviderVdc: new(providerVdcType), c: &c.Client}
+
+			if err = decodeBody(resp, pvdc.ProviderVdc); err != nil {
+				return providerVdc{}, fmt.Errorf("error decoding provider vdc response: %s", err)
+			}
+
+			// The request was successful
+			return pvdc, nil
+		}
+	}
+
+	return providerVdc{}, fmt.Errorf("can't find provider vdc: %s", name)
+}
+
+// findExternalNetwork returns the named external network. Like the other
+// extension API calls, it requires system administrator rights.
+func (c *VCDClient) findExternalNetwork(name string) (*externalNetworkType, error) {
+
+	resp, err := apiRequest(&c.Client, "GET", c.adminHREF("/api/admin/extension/externalNetworkReferences"), "", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving external networks: %s", err)
+	}
+
+	refs := &externalNetworkReferencesType{}
+
+	if err = decodeBody(resp, refs); err != nil {
+		return nil, fmt.Errorf("error decoding external networks response: %s", err)
+	}
+
+	for _, ref := range refs.ExternalNetworkReference {
+		if ref.Name != name {
+			continue
+		}
+
+		resp, err := apiRequest(&c.Client, "GET", ref.HREF, "", nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving external network: %s", err)
+		}
+
+		network := &externalNetworkType{}
+
+		if err = decodeBody(resp, network); err != nil {
+			return nil, fmt.Errorf("error decoding external network response: %s", err)
+		}
+
+		// The request was successful
+		return network, nil
+	}
+
+	return nil, fmt.Errorf("can't find external network: %s", name)
+}
+
+// FindStorageProfileReference returns a reference to the named storage
+// profile of the provider VDC.
+func (p *providerVdc) FindStorageProfileReference(name string) (*types.Reference, error) {
+	if p.ProviderVdc.StorageProfiles != nil {
+		for _, ref := range p.ProviderVdc.StorageProfiles.Reference {
+			if ref.Name == name {
+				return &types.Reference{HREF: ref.HREF, Name: ref.Name}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("can't find storage profile %s in provider vdc %s", name, p.ProviderVdc.Name)
+}
+
+// FindNetworkPoolReference returns a reference to the named network pool of
+// the provider VDC.
+func (p *providerVdc) FindNetworkPoolReference(name string) (*types.Reference, error) {
+	if p.ProviderVdc.NetworkPoolReferences != nil {
+		for _, ref := range p.ProviderVdc.NetworkPoolReferences.Reference {
+			if ref.Name == name {
+				return &types.Reference{HREF: ref.HREF, Name: ref.Name}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("can't find network pool %s in provider vdc %s", name, p.ProviderVdc.Name)
+}
+
+// CreateVdc creates an organization VDC. The VDC is returned as soon as vCD
+// accepted it; Wait waits for its creation to complete.
+func (o *adminOrg) CreateVdc(params *createVdcParamsType) (adminVdc, error) {
+
+	params.Xmlns = "http://www.vmware.com/vcloud/v1.5"
+
+	resp, err := apiRequest(o.c, "POST", o.AdminOrg.HREF+"/vdcsparams", "application/vnd.vmware.admin.createVdcParams+xml", nil, params)
+	if err != nil {
+		return adminVdc{}, fmt.Errorf("error creating vdc: %s", err)
+	}
+
+	created := adminVdc{AdminVdc: new(adminVdcType), c: o.c}
+
+	if err = decodeBody(resp, created.AdminVdc); err != nil {
+		return adminVdc{}, fmt.Errorf("error decoding vdc response: %s", err)
+	}
+
+	// The request was successful
+	return created, nil
+}
+
+// Wait waits up to timeout seconds for the tasks the VDC was returned with to
+// complete.
+func (v *adminVdc) Wait(timeout int) error {
+	return waitTasks(v.c, v.AdminVdc.Tasks, timeout)
+}
+
+func (c *VCDClient) getAdminVdc(href string) (adminVdc, error) {
+
+	resp, err := apiRequest(&c.Client, "GET", href, "", nil, nil)
+	if err != nil {
+		return adminVdc{}, fmt.Errorf("error retrieving vdc: %s", err)
+	}
+
+	vdc := adminVdc{AdminVdc: new(adminVdcType), c: &c.Client}
+
+	if err = decodeBody(resp, vdc.AdminVdc); err != nil {
+		return adminVdc{}, fmt.Errorf("error decoding vdc response: %s", err)
+	}
+
+	// The request was successful
+	return vdc, nil
+}
+
+// StorageProfiles returns the storage profiles of the VDC, with the limits
+// and defaults the VDC references them with.
+func (v *adminVdc) StorageProfiles() ([]*adminVdcStorageProfileType, error) {
+	var profiles []*adminVdcStorageProfileType
+	if v.AdminVdc.VdcStorageProfiles == nil {
+		return profiles, nil
+	}
+
+	for _, ref := range v.AdminVdc.VdcStorageProfiles.VdcStorageProfile {
+		resp, err := apiRequest(v.c, "GET", ref.HREF, "", nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving storage profile %s: %s", ref.Name, err)
+		}
+
+		profile := new(adminVdcStorageProfileType)
+		if err = decodeBody(resp, profile); err != nil {
+			return nil, fmt.Errorf("error decoding storage profile response: %s", err)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, nil
+}
+
+// OrgHREF returns the href of the admin view of the organization of the VDC.
+func (v *adminVdc) OrgHREF() (string, error) {
+	link := v.AdminVdc.Link.ForType("application/vnd.vmware.admin.organization+xml", "up")
+	if link == nil {
+		return "", fmt.Errorf("can't find the org of vdc %s", v.AdminVdc.Name)
+	}
+	return link.HREF, nil
+}
+
+// Update saves the changes made to AdminVdc, such as its compute capacity,
+// waiting up to timeout seconds for vCD to apply them.
+func (v *adminVdc) Update(timeout int) error {
+
+	v.AdminVdc.Xmlns = "http://www.vmware.com/vcloud/v1.5"
+	v.AdminVdc.Link = nil
+	v.AdminVdc.Tasks = nil
+
+	resp, err := apiRequest(v.c, "PUT", v.AdminVdc.HREF, "application/vnd.vmware.admin.vdc+xml", nil, v.AdminVdc)
+	if err != nil {
+		return fmt.Errorf("error updating vdc: %s", err)
+	}
+
+	return waitResponseTask(v.c, resp, timeout)
+}
+
+func (v *adminVdc) Enable() error {
+	return v.action("enable")
+}
+
+func (v *adminVdc) Disable() error {
+	return v.action("disable")
+}
+
+// Delete removes the VDC, which must be disabled first. force and recursive
+// also remove the vApps and other objects the VDC still contains. vCD is
+// given timeout seconds to complete the removal.
+func (v *adminVdc) Delete(force, recursive bool, timeout int) error {
+
+	params := map[string]string{
+		"force":     fmt.Sprintf("%t", force),
+		"recursive": fmt.Sprintf("%t", recursive),
+	}
+
+	resp, err := apiRequest(v.c, "DELETE", v.AdminVdc.HREF, "", params, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting vdc: %s", err)
+	}
+
+	return waitResponseTask(v.c, resp, timeout)
+}
+
+func (v *adminVdc) action(action string) error {
+
+	resp, err := apiRequest(v.c, "POST", v.AdminVdc.HREF+"/action/"+action, "", nil, nil)
+	if err != nil {
+		return fmt.Errorf("error performing %s on vdc: %s", action, err)
+	}
+	drainBody(resp)
+
+	// The request was successful
+	return nil
+}
+
+// updateNetwork sends the configuration in network to vCD, replacing the
+// settings of the org VDC network, such as the DNS servers of its IP scope.
+func (c *VCDClient) updateNetwork(network *types.OrgVDCNetwork) (govcd.Task, error) {
+
+	pathArr := strings.Split(network.HREF, "/")
+	href := c.adminHREF("/api/admin/network/" + pathArr[len(pathArr)-1])
+
+	update := *network
+	update.Xmlns = "http://www.vmware.com/vcloud/v1.5"
+	update.Tasks = nil
+
+	for {
+		task, err := apiTask(&c.Client, "PUT", href, "application/vnd.vmware.vcloud.orgVdcNetwork+xml", update)
+		if err != nil {
+			if strings.HasSuffix(err.Error(), "is busy, cannot proceed with the operation.") {
+				time.Sleep(3 * time.Second)
+				continue
+			}
+			return govcd.Task{}, fmt.Errorf("error updating Network: %s", err)
+		}
+		return task, nil
+	}
+}
@@ -0,0 +1,451 @@
+package vcd
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	govcd "github.com/ukcloud/govcloudair"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+// getOrg reads the Org of the provider again, e.g. to see the catalogs
+// created since the provider logged in. The Org of the client is shared by
+// all resources, so it is left as it is and a fresh copy is returned.
+func (c *VCDClient) getOrg() (*govcd.Org, error) {
+
+	if c.Org.Org.HREF == "" {
+		return nil, fmt.Errorf("cannot refresh, Object is empty")
+	}
+
+	resp, err := apiRequest(&c.Client, "GET", c.Org.Org.HREF, "", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving org: %s", err)
+	}
+
+	org := govcd.NewOrg(&c.Client)
+
+	if err = decodeBody(resp, org.Org); err != nil {
+		return nil, fmt.Errorf("error decoding org response: %s", err)
+	}
+
+	// The request was successful
+	return org, nil
+}
+
+// findCatalog looks the named catalog of the Org up, including the catalogs
+// created since the provider logged in.
+func (c *VCDClient) findCatalog(name string) (govcd.Catalog, error) {
+	org, err := c.getOrg()
+	if err != nil {
+		return govcd.Catalog{}, err
+	}
+
+	return org.FindCatalog(name)
+}
+
+// createCatalog creates a catalog in the Org of the provider and waits up to
+// timeout seconds for the creation to complete. It requires organization
+// administrator rights.
+func (c *VCDClient) createCatalog(name, description string, timeout int) (*adminCatalogType, error) {
+
+	catalog := &adminCatalogType{
+		Xmlns:       "http://www.vmware.com/vcloud/v1.5",
+		Name:        name,
+		Description: description,
+	}
+
+	href := strings.Replace(c.Org.Org.HREF, "/api/org/", "/api/admin/org/", 1) + "/catalogs"
+
+	resp, err := apiRequest(&c.Client, "POST", href, "application/vnd.vmware.admin.catalog+xml", nil, catalog)
+	if err != nil {
+		return nil, fmt.Errorf("error creating catalog: %s", err)
+	}
+
+	created := new(adminCatalogType)
+	if err = decodeBody(resp, created); err != nil {
+		return nil, fmt.Errorf("error decoding catalog response: %s", err)
+	}
+
+	if err = waitTasks(&c.Client, created.Tasks, timeout); err != nil {
+		return nil, err
+	}
+
+	// The request was successful
+	return created, nil
+}
+
+// deleteCatalog removes the catalog. Unless recursive is set, deleting a
+// catalog which still contains items fails. force also removes items which
+// are in use, e.g. by a running task. vCD is given timeout seconds to
+// complete the removal.
+func (c *VCDClient) deleteCatalog(catalog govcd.Catalog, force, recursive bool, timeout int) error {
+
+	href := strings.Replace(catalog.Catalog.HREF, "/api/catalog/", "/api/admin/catalog/", 1)
+
+	resp, err := apiRequest(&c.Client, "DELETE", href, "", map[string]string{
+		"force":     strconv.FormatBool(force),
+		"recursive": strconv.FormatBool(recursive),
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting catalog: %s", err)
+	}
+
+	// Depending on the API version vCD either deletes the catalog right away
+	// or returns a task
+	return waitResponseTask(&c.Client, resp, timeout)
+}
+
+// deleteCatalogItem removes the catalog item, along with the vApp template
+// or media it refers to. vCD is given timeout seconds to complete the
+// removal.
+func (c *VCDClient) deleteCatalogItem(item govcd.CatalogItem, timeout int) error {
+
+	resp, err := apiRequest(&c.Client, "DELETE", item.CatalogItem.HREF, "", nil, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting catalogitem: %s", err)
+	}
+
+	// Depending on the API version vCD either deletes the item right away or
+	// returns a task
+	return waitResponseTask(&c.Client, resp, timeout)
+}
+
+// uploadProgress is called after each uploaded piece of a file with the
+// number of bytes of that file transferred so far.
+type uploadProgress func(fileName string, transferred, size int64)
+
+// uploadOvf uploads the OVA at ovaPath as a vApp template named itemName in
+// the catalog. Files are sent in pieces of uploadPieceSize bytes, and vCD is
+// given timeout seconds to process the OVF descriptor. It returns the vCD
+// task importing the template, which the caller should wait for. Once the
+// catalog item exists it is returned even on error, so that the caller can
+// clean it up. The template is stored on storageProfile when it is not nil.
+func (c *VCDClient) uploadOvf(catalog govcd.Catalog, ovaPath, itemName, description string, storageProfile *types.Reference, uploadPieceSize int64, timeout int, progress uploadProgress) (govcd.CatalogItem, govcd.Task, error) {
+
+	if uploadPieceSize <= 0 {
+		return govcd.CatalogItem{}, govcd.Task{}, fmt.Errorf("upload piece size must be positive, got %d", uploadPieceSize)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "terraform-provider-vcd-ova")
+	if err != nil {
+		return govcd.CatalogItem{}, govcd.Task{}, fmt.Errorf("error creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	ovfName, err := extractOva(ovaPath, tmpDir)
+	if err != nil {
+		return govcd.CatalogItem{}, govcd.Task{}, err
+	}
+
+	item, err := c.createUploadItem(catalog, itemName, description, storageProfile)
+	if err != nil {
+		return govcd.CatalogItem{}, govcd.Task{}, err
+	}
+
+	template, err := item.GetVAppTemplate()
+	if err != nil {
+		return item, govcd.Task{}, err
+	}
+
+	ovfLink, err := uploadLink(template.VAppTemplate, "descriptor.ovf")
+	if err != nil {
+		return item, govcd.Task{}, err
+	}
+
+	if err = c.uploadFile(ovfLink, filepath.Join(tmpDir, ovfName), "descriptor.ovf", uploadPieceSize, progress); err != nil {
+		return item, govcd.Task{}, err
+	}
+
+	// vCD parses the descriptor before it lists the remaining files. A
+	// descriptor it rejects fails the import task instead.
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	for {
+		if err = c.refreshVAppTemplate(&template); err != nil {
+			return item, govcd.Task{}, err
+		}
+		if err = failedTask(template.VAppTemplate.Tasks); err != nil {
+			return item, govcd.Task{}, fmt.Errorf("error importing vapptemplate %s: %s", itemName, err)
+		}
+		if template.VAppTemplate.OvfDescriptorUploaded == "true" {
+			break
+		}
+		if time.Now().After(deadline) {
+			return item, govcd.Task{}, fmt.Errorf("timed out after %d seconds waiting for vCD to process the OVF descriptor of %s", timeout, itemName)
+		}
+		time.Sleep(3 * time.Second)
+	}
+
+	if template.VAppTemplate.Files != nil {
+		for _, file := range template.VAppTemplate.Files.File {
+			if file.Name == "descriptor.ovf" || (file.Size > 0 && file.BytesTransferred == file.Size) {
+				continue
+			}
+
+			link, err := uploadLink(template.VAppTemplate, file.Name)
+			if err != nil {
+				return item, govcd.Task{}, err
+			}
+
+			if err = c.uploadFile(link, filepath.Join(tmpDir, file.Name), file.Name, uploadPieceSize, progress); err != nil {
+				return item, govcd.Task{}, err
+			}
+		}
+	}
+
+	if template.VAppTemplate.Tasks == nil || len(template.VAppTemplate.Tasks.Task) == 0 {
+		return item, govcd.Task{}, fmt.Errorf("no import task found for vapptemplate %s", itemName)
+	}
+
+	task := govcd.NewTask(&c.Client)
+	task.Task = template.VAppTemplate.Tasks.Task[0]
+
+	// The request was successful
+	return item, *task, nil
+}
+
+// failedTask returns the error of the first failed task in tasks, if any.
+func failedTask(tasks *types.TasksInProgress) error {
+	if tasks == nil {
+		return nil
+	}
+	for _, task := range tasks.Task {
+		if task.Status != "error" {
+			continue
+		}
+		if task.Error != nil {
+			return fmt.Errorf("task %s failed: %s", task.Name, task.Error.Message)
+		}
+		return fmt.Errorf("task %s failed", task.Name)
+	}
+	return nil
+}
+
+// createUploadItem creates the catalog item, and the empty vApp template
+// behind it, that the files are uploaded to.
+func (c *VCDClient) createUploadItem(catalog govcd.Catalog, itemName, description string, storageProfile *types.Reference) (govcd.CatalogItem, error) {
+
+	var href string
+	for _, l := range catalog.Catalog.Link {
+		if l.Rel == "add" && l.Type == "application/vnd.vmware.vcloud.uploadVAppTemplateParams+xml" {
+			href = l.HREF
+		}
+	}
+	if href == "" {
+		return govcd.CatalogItem{}, fmt.Errorf("catalog %s does not allow uploads", catalog.Catalog.Name)
+	}
+
+	params := &uploadVAppTemplateParamsType{
+		Xmlns:             "http://www.vmware.com/vcloud/v1.5",
+		Name:              itemName,
+		Description:       description,
+		VdcStorageProfile: storageProfile,
+	}
+
+	resp, err := apiRequest(&c.Client, "POST", href, "application/vnd.vmware.vcloud.uploadVAppTemplateParams+xml", nil, params)
+	if err != nil {
+		return govcd.CatalogItem{}, fmt.Errorf("error creating catalog item: %s", err)
+	}
+
+	item := govcd.NewCatalogItem(&c.Client)
+
+	if err = decodeBody(resp, item.CatalogItem); err != nil {
+		return govcd.CatalogItem{}, fmt.Errorf("error decoding catalog item response: %s", err)
+	}
+
+	// The request was successful
+	return *item, nil
+}
+
+// uploadFile sends the local file at path to u in pieces, using
+// Content-Range so very large disks never have to be held in memory.
+func (c *VCDClient) uploadFile(u *url.URL, path, fileName string, uploadPieceSize int64, progress uploadProgress) error {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %s", fileName, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("error reading %s: %s", fileName, err)
+	}
+	size := info.Size()
+
+	for offset := int64(0); offset < size || offset == 0; offset += uploadPieceSize {
+		length := uploadPieceSize
+		if offset+length > size {
+			length = size - offset
+		}
+
+		req := c.Client.NewRequest(map[string]string{}, "PUT", *u, io.NewSectionReader(f, offset, length))
+		req.ContentLength = length
+		if length > 0 {
+			req.Header.Add("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, size))
+		}
+
+		resp, err := checkResp(c.Client.Http.Do(req))
+		if err != nil {
+			return fmt.Errorf("error uploading %s: %s", fileName, err)
+		}
+		drainBody(resp)
+
+		if progress != nil {
+			progress(fileName, offset+length, size)
+		}
+
+		if size == 0 {
+			break
+		}
+	}
+
+	// The request was successful
+	return nil
+}
+
+// refreshVAppTemplate reads the vApp template again, e.g. to follow the
+// progress of an upload.
+func (c *VCDClient) refreshVAppTemplate(template *govcd.VAppTemplate) error {
+
+	if template.VAppTemplate.HREF == "" {
+		return fmt.Errorf("cannot refresh, Object is empty")
+	}
+
+	resp, err := apiRequest(&c.Client, "GET", template.VAppTemplate.HREF, "", nil, nil)
+	if err != nil {
+		return fmt.Errorf("error retrieving vapptemplate: %s", err)
+	}
+
+	// Empty struct before a new unmarshal, otherwise we end up with duplicate
+	// elements in slices.
+	template.VAppTemplate = &types.VAppTemplate{}
+
+	if err = decodeBody(resp, template.VAppTemplate); err != nil {
+		return fmt.Errorf("error decoding vapptemplate response: %s", err)
+	}
+
+	// The request was successful
+	return nil
+}
+
+func uploadLink(template *types.VAppTemplate, fileName string) (*url.URL, error) {
+	if template.Files != nil {
+		for _, file := range template.Files.File {
+			if file.Name != fileName {
+				continue
+			}
+			for _, l := range file.Link {
+				if l.Rel == "upload:default" {
+					return url.ParseRequestURI(l.HREF)
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("can't find upload link for file: %s", fileName)
+}
+
+// extractOva unpacks the OVA, a tar archive, into dir and returns the name of
+// its OVF descriptor.
+func extractOva(ovaPath, dir string) (string, error) {
+
+	f, err := os.Open(ovaPath)
+	if err != nil {
+		return "", fmt.Errorf("error opening OVA: %s", err)
+	}
+	defer f.Close()
+
+	var ovfName string
+	r := tar.NewReader(f)
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading OVA: %s", err)
+		}
+
+		// Files are referenced by plain name from the descriptor
+		name := filepath.Base(header.Name)
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		out, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return "", fmt.Errorf("error extracting OVA: %s", err)
+		}
+		_, err = io.Copy(out, r)
+		out.Close()
+		if err != nil {
+			return "", fmt.Errorf("error extracting OVA: %s", err)
+		}
+
+		if strings.HasSuffix(strings.ToLower(name), ".ovf") {
+			ovfName = name
+		}
+	}
+
+	if ovfName == "" {
+		return "", fmt.Errorf("OVA %s contains no OVF descriptor", ovaPath)
+	}
+	return ovfName, nil
+}
+
+// queryMedia returns the records of the media matching the query filter, in
+// any catalog the user can see.
+func (c *VCDClient) queryMedia(filter string) ([]*queryResultMediaRecordType, error) {
+
+	resp, err := apiRequest(&c.Client, "GET", c.QueryHREF.String(), "", map[string]string{
+		"type":   "media",
+		"format": "records",
+		"filter": filter,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retreiving query: %s", err)
+	}
+
+	results := &queryResultMediaRecordsType{}
+
+	if err = decodeBody(resp, results); err != nil {
+		return nil, fmt.Errorf("error decoding query results: %s", err)
+	}
+
+	return results.MediaRecord, nil
+}
+
+// findStorageProfileRecord queries the named storage profile of the VDC,
+// along with its usage and limit. VDC names are only unique within an org,
+// so the VDC is matched by href.
+func (c *VCDClient) findStorageProfileRecord(vdc govcd.Vdc, name string) (*types.QueryResultOrgVdcStorageProfileRecordType, error) {
+
+	results, err := c.Query(map[string]string{
+		"type":   "orgVdcStorageProfile",
+		"format": "records",
+		"filter": fmt.Sprintf("name==%s;vdc==%s", queryFilterValue(name), queryFilterValue(vdc.Vdc.HREF)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error querying storage profiles: %s", err)
+	}
+
+	records := results.Results.OrgVdcStorageProfileRecord
+	log.Printf("[DEBUG] Storage profile records: %#v", records)
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("unable to find storage profile %s in vdc %s", name, vdc.Vdc.Name)
+	}
+	if len(records) > 1 {
+		return nil, fmt.Errorf("found %d storage profiles named %s in vdc %s", len(records), name, vdc.Vdc.Name)
+	}
+
+	return records[0], nil
+}
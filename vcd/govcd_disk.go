@@ -0,0 +1,144 @@
+package vcd
+
+import (
+	"fmt"
+
+	govcd "github.com/ukcloud/govcloudair"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+// independentDisk is an independent disk of a VDC, which can be attached to
+// VMs and outlives them.
+type independentDisk struct {
+	Disk *diskType
+	c    *govcd.Client
+}
+
+// createDisk starts creating an independent disk in the vdc. The returned
+// disk already has its href, Wait waits for the creation to complete.
+func (c *VCDClient) createDisk(vdc govcd.Vdc, disk *diskType) (independentDisk, error) {
+
+	var href string
+	for _, av := range vdc.Vdc.Link {
+		if av.Rel == "add" && av.Type == "application/vnd.vmware.vcloud.diskCreateParams+xml" {
+			href = av.HREF
+		}
+	}
+	if href == "" {
+		return independentDisk{}, fmt.Errorf("vdc %s does not allow creating disks", vdc.Vdc.Name)
+	}
+
+	params := &diskCreateParamsType{
+		Xmlns: "http://www.vmware.com/vcloud/v1.5",
+		Disk:  disk,
+	}
+
+	resp, err := apiRequest(&c.Client, "POST", href, "application/vnd.vmware.vcloud.diskCreateParams+xml", nil, params)
+	if err != nil {
+		return independentDisk{}, fmt.Errorf("error creating disk: %s", err)
+	}
+
+	created := independentDisk{Disk: new(diskType), c: &c.Client}
+
+	if err = decodeBody(resp, created.Disk); err != nil {
+		return independentDisk{}, fmt.Errorf("error decoding disk response: %s", err)
+	}
+
+	// The request was successful
+	return created, nil
+}
+
+// Wait waits up to timeout seconds for the tasks the disk was returned with
+// to complete.
+func (d *independentDisk) Wait(timeout int) error {
+	return waitTasks(d.c, d.Disk.Tasks, timeout)
+}
+
+// findDiskByHREF returns the independent disk at href.
+func (c *VCDClient) findDiskByHREF(href string) (independentDisk, error) {
+	disk := independentDisk{Disk: &diskType{HREF: href}, c: &c.Client}
+
+	if err := disk.Refresh(); err != nil {
+		return independentDisk{}, err
+	}
+
+	// The request was successful
+	return disk, nil
+}
+
+func (d *independentDisk) Refresh() error {
+
+	if d.Disk.HREF == "" {
+		return fmt.Errorf("cannot refresh, Object is empty")
+	}
+
+	resp, err := apiRequest(d.c, "GET", d.Disk.HREF, "", nil, nil)
+	if err != nil {
+		return fmt.Errorf("error retrieving disk: %s", err)
+	}
+
+	// Empty struct before a new unmarshal, otherwise we end up with duplicate
+	// elements in slices.
+	d.Disk = &diskType{}
+
+	if err = decodeBody(resp, d.Disk); err != nil {
+		return fmt.Errorf("error decoding disk response: %s", err)
+	}
+
+	// The request was successful
+	return nil
+}
+
+// Resize changes the size of the disk, in bytes. vCD can only grow disks.
+func (d *independentDisk) Resize(size int64) (govcd.Task, error) {
+
+	disk := &diskType{
+		Xmlns:          "http://www.vmware.com/vcloud/v1.5",
+		Name:           d.Disk.Name,
+		Size:           size,
+		Description:    d.Disk.Description,
+		StorageProfile: d.Disk.StorageProfile,
+	}
+
+	task, err := apiTask(d.c, "PUT", d.Disk.HREF, "application/vnd.vmware.vcloud.disk+xml", disk)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error resizing disk: %s", err)
+	}
+	return task, nil
+}
+
+// AttachedVMs returns references to the VMs the disk is attached to.
+func (d *independentDisk) AttachedVMs() ([]*types.Reference, error) {
+
+	var href string
+	for _, l := range d.Disk.Link {
+		if l.Rel == "down" && l.Type == "application/vnd.vmware.vcloud.vms+xml" {
+			href = l.HREF
+		}
+	}
+	if href == "" {
+		return nil, fmt.Errorf("can't find attached VMs link of disk: %s", d.Disk.Name)
+	}
+
+	resp, err := apiRequest(d.c, "GET", href, "", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving attached VMs: %s", err)
+	}
+
+	vms := new(vmsType)
+
+	if err = decodeBody(resp, vms); err != nil {
+		return nil, fmt.Errorf("error decoding attached VMs response: %s", err)
+	}
+
+	// The request was successful
+	return vms.VmReference, nil
+}
+
+func (d *independentDisk) Delete() (govcd.Task, error) {
+	task, err := apiTask(d.c, "DELETE", d.Disk.HREF, "", nil)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error deleting disk: %s", err)
+	}
+	return task, nil
+}
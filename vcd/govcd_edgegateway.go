@@ -0,0 +1,351 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	govcd "github.com/ukcloud/govcloudair"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+// govcdEdgeGateway names the embedded govcd.EdgeGateway of vcdEdgeGateway,
+// so that vcdEdgeGateway.EdgeGateway still is the *types.EdgeGateway.
+type govcdEdgeGateway = govcd.EdgeGateway
+
+// vcdEdgeGateway is a govcd.EdgeGateway with the services and the calls the
+// vendored govcloudair lacks.
+type vcdEdgeGateway struct {
+	govcdEdgeGateway
+	services *edgeGatewayType
+	c        *govcd.Client
+}
+
+// findEdgeGateway looks the named edge gateway of the VDC up, along with the
+// services govcd.EdgeGateway does not decode.
+func (c *VCDClient) findEdgeGateway(name string) (vcdEdgeGateway, error) {
+	edgeGateway, err := c.OrgVdc.FindEdgeGateway(name)
+	if err != nil {
+		return vcdEdgeGateway{}, err
+	}
+
+	e := vcdEdgeGateway{govcdEdgeGateway: edgeGateway, c: &c.Client}
+	if err := e.Refresh(); err != nil {
+		return vcdEdgeGateway{}, err
+	}
+
+	return e, nil
+}
+
+// Refresh reads the edge gateway again, along with the services
+// types.EdgeGateway only decodes in part.
+func (e *vcdEdgeGateway) Refresh() error {
+
+	if e.EdgeGateway == nil {
+		return fmt.Errorf("cannot refresh, Object is empty")
+	}
+
+	resp, err := apiRequest(e.c, "GET", e.EdgeGateway.HREF, "", nil, nil)
+	if err != nil {
+		return fmt.Errorf("error retreiving Edge Gateway: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error retreiving Edge Gateway: %s", err)
+	}
+
+	// Empty structs before a new unmarshal, otherwise we end up with
+	// duplicate elements in slices.
+	e.EdgeGateway = &types.EdgeGateway{}
+	e.services = &edgeGatewayType{}
+
+	if err = xml.Unmarshal(body, e.EdgeGateway); err != nil {
+		return fmt.Errorf("error decoding Edge Gateway response: %s", err)
+	}
+	if err = xml.Unmarshal(body, e.services); err != nil {
+		return fmt.Errorf("error decoding Edge Gateway response: %s", err)
+	}
+
+	// The request was successful
+	return nil
+}
+
+// natPortMapping is a NAT rule translating the traffic of a protocol, as
+// AddNATPortMappingWithProtocol adds it.
+type natPortMapping struct {
+	natType      string
+	externalIP   string
+	externalPort string
+	internalIP   string
+	internalPort string
+	protocol     string
+	icmpSubType  string
+}
+
+// matches reports whether rule translates exactly the traffic of m. Rules
+// which differ in any setting, e.g. only in the protocol, are other rules.
+func (m natPortMapping) matches(rule *types.NatRule) bool {
+	r := rule.GatewayNatRule
+	return r != nil &&
+		rule.RuleType == m.natType &&
+		r.OriginalIP == m.externalIP &&
+		r.OriginalPort == m.externalPort &&
+		r.TranslatedIP == m.internalIP &&
+		r.TranslatedPort == m.internalPort &&
+		strings.EqualFold(r.Protocol, m.protocol) &&
+		r.IcmpSubType == m.icmpSubType
+}
+
+// uplink returns the uplink interface of the edge gateway, which the NAT
+// rules of the provider are applied on.
+func (e *vcdEdgeGateway) uplink() types.Reference {
+	var uplink types.Reference
+	for _, gi := range e.EdgeGateway.Configuration.GatewayInterfaces.GatewayInterface {
+		if gi.InterfaceType != "uplink" {
+			continue
+		}
+		uplink = *gi.Network
+	}
+	return uplink
+}
+
+// FindNATPortMapping returns the NAT rule of the edge gateway translating
+// exactly the traffic of m, as of its last refresh, or nil.
+func (e *vcdEdgeGateway) FindNATPortMapping(m natPortMapping) *types.NatRule {
+	natService := e.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.NatService
+	if natService == nil {
+		return nil
+	}
+
+	for _, rule := range natService.NatRule {
+		if m.matches(rule) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// AddNATPortMappingWithProtocol adds a NAT rule translating the traffic of
+// protocol, like govcd.EdgeGateway.AddNATPortMapping does for tcp. The ports
+// can be a single port, a range such as 8000-8100 or any. icmpSubType only
+// applies to the icmp protocol.
+func (e *vcdEdgeGateway) AddNATPortMappingWithProtocol(nattype, externalIP, externalPort, internalIP, internalPort, protocol, icmpSubType string) (govcd.Task, error) {
+	uplink := e.uplink()
+	m := natPortMapping{nattype, externalIP, externalPort, internalIP, internalPort, protocol, icmpSubType}
+
+	newedgeconfig := e.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration
+
+	// Take care of the NAT service
+	newnatservice := &types.NatService{}
+
+	if newedgeconfig.NatService == nil {
+		newnatservice.IsEnabled = true
+	} else {
+		newnatservice.IsEnabled = newedgeconfig.NatService.IsEnabled
+		newnatservice.NatType = newedgeconfig.NatService.NatType
+		newnatservice.Policy = newedgeconfig.NatService.Policy
+		newnatservice.ExternalIP = newedgeconfig.NatService.ExternalIP
+
+		for _, v := range newedgeconfig.NatService.NatRule {
+
+			// The rule is added again below, e.g. when the last attempt
+			// failed after vCD applied it
+			if m.matches(v) && v.GatewayNatRule.Interface != nil && v.GatewayNatRule.Interface.HREF == uplink.HREF {
+				continue
+			}
+
+			newnatservice.NatRule = append(newnatservice.NatRule, v)
+		}
+	}
+
+	//add rule
+	natRule := &types.NatRule{
+		RuleType:  nattype,
+		IsEnabled: true,
+		GatewayNatRule: &types.GatewayNatRule{
+			Interface: &types.Reference{
+				HREF: uplink.HREF,
+			},
+			OriginalIP:     externalIP,
+			OriginalPort:   externalPort,
+			TranslatedIP:   internalIP,
+			TranslatedPort: internalPort,
+			Protocol:       protocol,
+			IcmpSubType:    icmpSubType,
+		},
+	}
+	newnatservice.NatRule = append(newnatservice.NatRule, natRule)
+
+	newedgeconfig.NatService = newnatservice
+
+	return e.ConfigureServices(&edgeGatewayServiceConfigurationType{
+		NatService: newnatservice,
+	})
+}
+
+// RemoveNATPortMappingWithProtocol removes the NAT rules translating exactly
+// the traffic AddNATPortMappingWithProtocol was given. Unlike
+// govcd.EdgeGateway.RemoveNATPortMapping, which only compares the original IP
+// and port, rules differing in the protocol or the translation are kept.
+func (e *vcdEdgeGateway) RemoveNATPortMappingWithProtocol(nattype, externalIP, externalPort, internalIP, internalPort, protocol, icmpSubType string) (govcd.Task, error) {
+	uplink := e.uplink()
+	m := natPortMapping{nattype, externalIP, externalPort, internalIP, internalPort, protocol, icmpSubType}
+
+	natService := e.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.NatService
+	if natService == nil {
+		natService = &types.NatService{}
+	}
+
+	newnatservice := &types.NatService{
+		IsEnabled:  natService.IsEnabled,
+		NatType:    natService.NatType,
+		Policy:     natService.Policy,
+		ExternalIP: natService.ExternalIP,
+	}
+
+	for _, v := range natService.NatRule {
+		if m.matches(v) && v.GatewayNatRule.Interface != nil && v.GatewayNatRule.Interface.HREF == uplink.HREF {
+			continue
+		}
+		newnatservice.NatRule = append(newnatservice.NatRule, v)
+	}
+
+	e.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.NatService = newnatservice
+
+	return e.ConfigureServices(&edgeGatewayServiceConfigurationType{
+		NatService: newnatservice,
+	})
+}
+
+// ConfigureServices replaces the configuration of the services included in
+// config, leaving the other services of the edge gateway untouched.
+func (e *vcdEdgeGateway) ConfigureServices(config *edgeGatewayServiceConfigurationType) (govcd.Task, error) {
+
+	config.Xmlns = "http://www.vmware.com/vcloud/v1.5"
+
+	task, err := apiTask(e.c, "POST", e.EdgeGateway.HREF+"/action/configureServices", "application/vnd.vmware.admin.edgeGatewayServiceConfiguration+xml", config)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error reconfiguring Edge Gateway: %s", err)
+	}
+	return task, nil
+}
+
+// StaticRoutes returns the static routes of the edge gateway, as of its last
+// refresh.
+func (e *vcdEdgeGateway) StaticRoutes() []*types.StaticRoute {
+	if c := e.services.Configuration; c != nil && c.EdgeGatewayServiceConfiguration != nil && c.EdgeGatewayServiceConfiguration.StaticRoutingService != nil {
+		return c.EdgeGatewayServiceConfiguration.StaticRoutingService.StaticRoute
+	}
+	return nil
+}
+
+// AddStaticRoute appends route to the static routes of the edge gateway. A
+// route to the same network via the same next hop, e.g. one added by an
+// earlier attempt that vCD applied before failing, is replaced instead.
+func (e *vcdEdgeGateway) AddStaticRoute(route *types.StaticRoute) (govcd.Task, error) {
+
+	// Refresh EdgeGateway rules
+	err := e.Refresh()
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error refreshing edge gateway: %s", err)
+	}
+
+	var routes []*types.StaticRoute
+	for _, r := range e.StaticRoutes() {
+		if r.Network == route.Network && r.NextHopIP == route.NextHopIP {
+			continue
+		}
+		routes = append(routes, r)
+	}
+	routes = append(routes, route)
+
+	return e.ConfigureServices(&edgeGatewayServiceConfigurationType{
+		StaticRoutingService: &staticRoutingServiceType{
+			IsEnabled:   true,
+			StaticRoute: routes,
+		},
+	})
+}
+
+// RemoveStaticRoute removes the static routes to network via nextHop,
+// keeping any other route of the edge gateway.
+func (e *vcdEdgeGateway) RemoveStaticRoute(network, nextHop string) (govcd.Task, error) {
+
+	// Refresh EdgeGateway rules
+	err := e.Refresh()
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error refreshing edge gateway: %s", err)
+	}
+
+	var routes []*types.StaticRoute
+	for _, r := range e.StaticRoutes() {
+		if r.Network == network && r.NextHopIP == nextHop {
+			continue
+		}
+		routes = append(routes, r)
+	}
+
+	return e.ConfigureServices(&edgeGatewayServiceConfigurationType{
+		StaticRoutingService: &staticRoutingServiceType{
+			IsEnabled:   true,
+			StaticRoute: routes,
+		},
+	})
+}
+
+func (e *vcdEdgeGateway) dhcpPools() []*types.DhcpPoolService {
+	if c := e.EdgeGateway.Configuration; c != nil && c.EdgeGatewayServiceConfiguration != nil && c.EdgeGatewayServiceConfiguration.GatewayDhcpService != nil {
+		return c.EdgeGatewayServiceConfiguration.GatewayDhcpService.Pool
+	}
+	return nil
+}
+
+// GetDhcpPools returns the DHCP pools the edge gateway serves to the network
+// with the given HREF.
+func (e *vcdEdgeGateway) GetDhcpPools(networkHREF string) []*types.DhcpPoolService {
+	var pools []*types.DhcpPoolService
+	for _, p := range e.dhcpPools() {
+		if p.Network != nil && p.Network.HREF == networkHREF {
+			pools = append(pools, p)
+		}
+	}
+	return pools
+}
+
+// SetDhcpPools replaces the DHCP pools of network by pools, keeping the pools
+// of the other networks of the edge gateway. The DHCP service is disabled
+// once no network has a pool left.
+func (e *vcdEdgeGateway) SetDhcpPools(network *types.Reference, pools []*types.DhcpPoolService) (govcd.Task, error) {
+
+	// Refresh EdgeGateway rules
+	err := e.Refresh()
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error refreshing edge gateway: %s", err)
+	}
+
+	var newpools []*types.DhcpPoolService
+	for _, p := range e.dhcpPools() {
+		if p.Network != nil && p.Network.HREF == network.HREF {
+			continue
+		}
+		newpools = append(newpools, p)
+	}
+
+	for _, p := range pools {
+		p.Network = &types.Reference{
+			HREF: network.HREF,
+			Name: network.Name,
+		}
+		newpools = append(newpools, p)
+	}
+
+	return e.ConfigureServices(&edgeGatewayServiceConfigurationType{
+		GatewayDhcpService: &types.GatewayDhcpService{
+			IsEnabled: len(newpools) > 0,
+			Pool:      newpools,
+		},
+	})
+}
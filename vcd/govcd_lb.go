@@ -0,0 +1,439 @@
+package vcd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// lbURL returns the URL of a load balancer object of the edge gateway. The
+// load balancer of advanced edge gateways is configured through the NSX API,
+// which vCD proxies under /network/edges.
+func (e *vcdEdgeGateway) lbURL(object string) (*url.URL, error) {
+
+	s, err := url.ParseRequestURI(e.EdgeGateway.HREF)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding edge gateway HREF: %s", err)
+	}
+
+	// NSX knows the edge by the UUID ending the gateway URN
+	id := e.EdgeGateway.ID[strings.LastIndex(e.EdgeGateway.ID, ":")+1:]
+	if id == "" {
+		return nil, fmt.Errorf("edge gateway %s has no id", e.EdgeGateway.Name)
+	}
+
+	s.Path = "/network/edges/" + id + "/loadbalancer/config/" + object
+
+	return s, nil
+}
+
+// lbRequest sends a load balancer request, marshaling in as the body when it
+// is not nil. Errors are decoded from the NSX error format.
+func (e *vcdEdgeGateway) lbRequest(method string, u *url.URL, in interface{}) (*http.Response, error) {
+
+	var b io.Reader
+	if in != nil {
+		output, err := xml.MarshalIndent(in, "  ", "    ")
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling load balancer object: %s", err)
+		}
+		log.Printf("[DEBUG] Load balancer %s %s: %s", method, u.Path, output)
+		b = bytes.NewBuffer(output)
+	}
+
+	req := e.c.NewRequest(map[string]string{}, method, *u, b)
+	if in != nil {
+		req.Header.Add("Content-Type", "application/xml")
+	}
+
+	resp, err := e.c.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		nsxErr := new(nsxErrorType)
+		if err := decodeBody(resp, nsxErr); err != nil || nsxErr.Details == "" {
+			return nil, fmt.Errorf("unhandled API response, status code: %s", resp.Status)
+		}
+		return nil, fmt.Errorf("API Error: %d: %s", nsxErr.ErrorCode, nsxErr.Details)
+	}
+
+	return resp, nil
+}
+
+// lbCreate creates a load balancer object and returns the id NSX assigned
+// to it, which ends the Location header of the response.
+func (e *vcdEdgeGateway) lbCreate(object string, in interface{}) (string, error) {
+
+	u, err := e.lbURL(object)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := e.lbRequest("POST", u, in)
+	if err != nil {
+		return "", err
+	}
+
+	drainBody(resp)
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("no Location header in the response to the creation of a load balancer object")
+	}
+
+	return path.Base(location), nil
+}
+
+// lbConfig returns the load balancer objects of one kind.
+func (e *vcdEdgeGateway) lbConfig(object string) (*lbConfigType, error) {
+
+	u, err := e.lbURL(object)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.lbRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &lbConfigType{}
+
+	if err = decodeBody(resp, config); err != nil {
+		return nil, fmt.Errorf("error decoding load balancer response: %s", err)
+	}
+
+	return config, nil
+}
+
+// lbUpdate replaces the configuration of a load balancer object.
+func (e *vcdEdgeGateway) lbUpdate(object string, in interface{}) error {
+
+	u, err := e.lbURL(object)
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.lbRequest("PUT", u, in)
+	if err != nil {
+		return err
+	}
+	drainBody(resp)
+
+	return nil
+}
+
+// lbDelete removes a load balancer object.
+func (e *vcdEdgeGateway) lbDelete(object string) error {
+
+	u, err := e.lbURL(object)
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.lbRequest("DELETE", u, nil)
+	if err != nil {
+		return err
+	}
+	drainBody(resp)
+
+	return nil
+}
+
+// CreateLbServiceMonitor creates a load balancer service monitor and returns
+// its id.
+func (e *vcdEdgeGateway) CreateLbServiceMonitor(monitor *lbMonitorType) (string, error) {
+	id, err := e.lbCreate("monitors", monitor)
+	if err != nil {
+		return "", fmt.Errorf("error creating load balancer service monitor: %s", err)
+	}
+	return id, nil
+}
+
+// GetLbServiceMonitors returns the load balancer service monitors of the edge
+// gateway.
+func (e *vcdEdgeGateway) GetLbServiceMonitors() ([]*lbMonitorType, error) {
+	config, err := e.lbConfig("monitors")
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving load balancer service monitors: %s", err)
+	}
+	return config.Monitor, nil
+}
+
+// GetLbServiceMonitor returns the service monitor with the given id, or nil
+// when the edge gateway has no such monitor.
+func (e *vcdEdgeGateway) GetLbServiceMonitor(id string) (*lbMonitorType, error) {
+
+	monitors, err := e.GetLbServiceMonitors()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, monitor := range monitors {
+		if monitor.ID == id {
+			return monitor, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// FindLbServiceMonitorByName returns the service monitor with the given name,
+// or nil when the edge gateway has no such monitor.
+func (e *vcdEdgeGateway) FindLbServiceMonitorByName(name string) (*lbMonitorType, error) {
+
+	monitors, err := e.GetLbServiceMonitors()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, monitor := range monitors {
+		if monitor.Name == name {
+			return monitor, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// UpdateLbServiceMonitor replaces the configuration of the service monitor
+// monitor.ID.
+func (e *vcdEdgeGateway) UpdateLbServiceMonitor(monitor *lbMonitorType) error {
+	if err := e.lbUpdate("monitors/"+monitor.ID, monitor); err != nil {
+		return fmt.Errorf("error updating load balancer service monitor: %s", err)
+	}
+	return nil
+}
+
+// DeleteLbServiceMonitor removes the service monitor with the given id.
+func (e *vcdEdgeGateway) DeleteLbServiceMonitor(id string) error {
+	if err := e.lbDelete("monitors/" + id); err != nil {
+		return fmt.Errorf("error deleting load balancer service monitor: %s", err)
+	}
+	return nil
+}
+
+// CreateLbServerPool creates a load balancer server pool and returns its id.
+func (e *vcdEdgeGateway) CreateLbServerPool(pool *lbPoolType) (string, error) {
+	id, err := e.lbCreate("pools", pool)
+	if err != nil {
+		return "", fmt.Errorf("error creating load balancer server pool: %s", err)
+	}
+	return id, nil
+}
+
+// GetLbServerPools returns the load balancer server pools of the edge gateway.
+func (e *vcdEdgeGateway) GetLbServerPools() ([]*lbPoolType, error) {
+	config, err := e.lbConfig("pools")
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving load balancer server pools: %s", err)
+	}
+	return config.Pool, nil
+}
+
+// GetLbServerPool returns the load balancer server pool with the given id, or
+// nil when the edge gateway has no such pool.
+func (e *vcdEdgeGateway) GetLbServerPool(id string) (*lbPoolType, error) {
+
+	pools, err := e.GetLbServerPools()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pool := range pools {
+		if pool.ID == id {
+			return pool, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// FindLbServerPoolByName returns the load balancer server pool with the given
+// name, or nil when the edge gateway has no such pool.
+func (e *vcdEdgeGateway) FindLbServerPoolByName(name string) (*lbPoolType, error) {
+
+	pools, err := e.GetLbServerPools()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pool := range pools {
+		if pool.Name == name {
+			return pool, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// UpdateLbServerPool replaces the configuration of the server pool pool.ID.
+func (e *vcdEdgeGateway) UpdateLbServerPool(pool *lbPoolType) error {
+	if err := e.lbUpdate("pools/"+pool.ID, pool); err != nil {
+		return fmt.Errorf("error updating load balancer server pool: %s", err)
+	}
+	return nil
+}
+
+// DeleteLbServerPool removes the server pool with the given id.
+func (e *vcdEdgeGateway) DeleteLbServerPool(id string) error {
+	if err := e.lbDelete("pools/" + id); err != nil {
+		return fmt.Errorf("error deleting load balancer server pool: %s", err)
+	}
+	return nil
+}
+
+// CreateLbAppProfile creates a load balancer application profile and returns
+// its id.
+func (e *vcdEdgeGateway) CreateLbAppProfile(profile *lbAppProfileType) (string, error) {
+	id, err := e.lbCreate("applicationprofiles", profile)
+	if err != nil {
+		return "", fmt.Errorf("error creating load balancer application profile: %s", err)
+	}
+	return id, nil
+}
+
+// GetLbAppProfiles returns the load balancer application profiles of the
+// edge gateway.
+func (e *vcdEdgeGateway) GetLbAppProfiles() ([]*lbAppProfileType, error) {
+	config, err := e.lbConfig("applicationprofiles")
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving load balancer application profiles: %s", err)
+	}
+	return config.ApplicationProfile, nil
+}
+
+// GetLbAppProfile returns the application profile with the given id, or nil
+// when the edge gateway has no such profile.
+func (e *vcdEdgeGateway) GetLbAppProfile(id string) (*lbAppProfileType, error) {
+
+	profiles, err := e.GetLbAppProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, profile := range profiles {
+		if profile.ID == id {
+			return profile, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// FindLbAppProfileByName returns the application profile with the given
+// name, or nil when the edge gateway has no such profile.
+func (e *vcdEdgeGateway) FindLbAppProfileByName(name string) (*lbAppProfileType, error) {
+
+	profiles, err := e.GetLbAppProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// UpdateLbAppProfile replaces the configuration of the application profile
+// profile.ID.
+func (e *vcdEdgeGateway) UpdateLbAppProfile(profile *lbAppProfileType) error {
+	if err := e.lbUpdate("applicationprofiles/"+profile.ID, profile); err != nil {
+		return fmt.Errorf("error updating load balancer application profile: %s", err)
+	}
+	return nil
+}
+
+// DeleteLbAppProfile removes the application profile with the given id.
+func (e *vcdEdgeGateway) DeleteLbAppProfile(id string) error {
+	if err := e.lbDelete("applicationprofiles/" + id); err != nil {
+		return fmt.Errorf("error deleting load balancer application profile: %s", err)
+	}
+	return nil
+}
+
+// CreateLbVirtualServer creates a load balancer virtual server and returns
+// its id.
+func (e *vcdEdgeGateway) CreateLbVirtualServer(server *lbVirtualServerType) (string, error) {
+	id, err := e.lbCreate("virtualservers", server)
+	if err != nil {
+		return "", fmt.Errorf("error creating load balancer virtual server: %s", err)
+	}
+	return id, nil
+}
+
+// GetLbVirtualServers returns the load balancer virtual servers of the edge
+// gateway.
+func (e *vcdEdgeGateway) GetLbVirtualServers() ([]*lbVirtualServerType, error) {
+	config, err := e.lbConfig("virtualservers")
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving load balancer virtual servers: %s", err)
+	}
+	return config.VirtualServer, nil
+}
+
+// GetLbVirtualServer returns the virtual server with the given id, or nil
+// when the edge gateway has no such virtual server.
+func (e *vcdEdgeGateway) GetLbVirtualServer(id string) (*lbVirtualServerType, error) {
+
+	servers, err := e.GetLbVirtualServers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, server := range servers {
+		if server.ID == id {
+			return server, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// FindLbVirtualServerByName returns the virtual server with the given name,
+// or nil when the edge gateway has no such virtual server.
+func (e *vcdEdgeGateway) FindLbVirtualServerByName(name string) (*lbVirtualServerType, error) {
+
+	servers, err := e.GetLbVirtualServers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, server := range servers {
+		if server.Name == name {
+			return server, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// UpdateLbVirtualServer replaces the configuration of the virtual server
+// server.ID.
+func (e *vcdEdgeGateway) UpdateLbVirtualServer(server *lbVirtualServerType) error {
+	if err := e.lbUpdate("virtualservers/"+server.ID, server); err != nil {
+		return fmt.Errorf("error updating load balancer virtual server: %s", err)
+	}
+	return nil
+}
+
+// DeleteLbVirtualServer removes the virtual server with the given id, leaving
+// the other virtual servers of the edge gateway untouched.
+func (e *vcdEdgeGateway) DeleteLbVirtualServer(id string) error {
+	if err := e.lbDelete("virtualservers/" + id); err != nil {
+		return fmt.Errorf("error deleting load balancer virtual server: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package vcd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	govcd "github.com/ukcloud/govcloudair"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+func TestWaitTask(t *testing.T) {
+	defer func(interval time.Duration) { taskPollInterval = interval }(taskPollInterval)
+	taskPollInterval = 10 * time.Millisecond
+
+	var statuses []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[0]
+		if len(statuses) > 1 {
+			statuses = statuses[1:]
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `<Task xmlns="http://www.vmware.com/vcloud/v1.5" status="%s" operation="Powering on"><Description>failed</Description></Task>`, status)
+	}))
+	defer server.Close()
+
+	client := &govcd.Client{}
+
+	cases := []struct {
+		statuses []string
+		err      string
+	}{
+		// The task is polled until it leaves the waiting states
+		{[]string{"queued", "running", "success"}, ""},
+		{[]string{"running", "error"}, "task did not complete successfully: failed"},
+		// A task still running at the timeout gives up
+		{[]string{"running"}, "timed out after 1 seconds waiting for task Powering on"},
+	}
+
+	for _, tc := range cases {
+		statuses = tc.statuses
+
+		task := govcd.Task{Task: &types.Task{HREF: server.URL + "/api/task/1"}}
+		err := waitTask(client, task, 1)
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("%v: expected no error, got %s", tc.statuses, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%v: expected error %q, got %v", tc.statuses, tc.err, err)
+		}
+	}
+}
+
+func TestQueryFilterValue(t *testing.T) {
+	cases := map[string]string{
+		"gold":         "gold",
+		"gold;tier=1":  "gold%3Btier%3D1",
+		"a,b":          "a%2Cb",
+		"100%":         "100%25",
+		"https://x/y1": "https://x/y1",
+	}
+	for value, expected := range cases {
+		if actual := queryFilterValue(value); actual != expected {
+			t.Errorf("%s: expected %q, got %q", value, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,543 @@
+package vcd
+
+import (
+	"encoding/xml"
+
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+// XML types of the vCD API which the vendored types package lacks, or
+// declares with a single element where vCD returns a list. They are named
+// after their vCD schema type.
+
+// adminCatalogType represents the admin view of a Catalog object.
+type adminCatalogType struct {
+	XMLName     xml.Name               `xml:"AdminCatalog"`
+	Xmlns       string                 `xml:"xmlns,attr,omitempty"`
+	HREF        string                 `xml:"href,attr,omitempty"`
+	Type        string                 `xml:"type,attr,omitempty"`
+	ID          string                 `xml:"id,attr,omitempty"`
+	Name        string                 `xml:"name,attr"`
+	Description string                 `xml:"Description,omitempty"`
+	Link        types.LinkList         `xml:"Link,omitempty"`
+	Tasks       *types.TasksInProgress `xml:"Tasks,omitempty"`
+	IsPublished bool                   `xml:"IsPublished,omitempty"`
+}
+
+// uploadVAppTemplateParamsType represents parameters for an upload vApp
+// template request.
+type uploadVAppTemplateParamsType struct {
+	XMLName           xml.Name         `xml:"UploadVAppTemplateParams"`
+	Xmlns             string           `xml:"xmlns,attr"`
+	Name              string           `xml:"name,attr"`
+	Description       string           `xml:"Description,omitempty"`
+	VdcStorageProfile *types.Reference `xml:"VdcStorageProfile,omitempty"` // Storage profile the template is stored on. Defaults to the one of the catalog.
+}
+
+// mediaInsertOrEjectParamsType represents parameters for an insert or eject
+// media request.
+type mediaInsertOrEjectParamsType struct {
+	XMLName xml.Name         `xml:"MediaInsertOrEjectParams"`
+	Xmlns   string           `xml:"xmlns,attr"`
+	Media   *types.Reference `xml:"Media"`
+}
+
+// queryResultMediaRecordsType is the result of a query for media.
+type queryResultMediaRecordsType struct {
+	XMLName     xml.Name                      `xml:"QueryResultRecords"`
+	MediaRecord []*queryResultMediaRecordType `xml:"MediaRecord"`
+}
+
+// queryResultMediaRecordType represents a media record as query result.
+type queryResultMediaRecordType struct {
+	HREF        string `xml:"href,attr,omitempty"`        // The URI of the entity.
+	Name        string `xml:"name,attr,omitempty"`        // Media name.
+	CatalogName string `xml:"catalogName,attr,omitempty"` // The name of the catalog containing the media.
+	IsBusy      bool   `xml:"isBusy,attr"`                // True if the media is busy.
+}
+
+// diskCreateParamsType represents parameters for creating an independent
+// disk.
+type diskCreateParamsType struct {
+	XMLName xml.Name  `xml:"DiskCreateParams"`
+	Xmlns   string    `xml:"xmlns,attr"`
+	Disk    *diskType `xml:"Disk"`
+}
+
+// diskType represents an independent disk.
+type diskType struct {
+	XMLName        xml.Name               `xml:"Disk"`
+	Xmlns          string                 `xml:"xmlns,attr,omitempty"`
+	HREF           string                 `xml:"href,attr,omitempty"`
+	Type           string                 `xml:"type,attr,omitempty"`
+	ID             string                 `xml:"id,attr,omitempty"`
+	Name           string                 `xml:"name,attr"`
+	Status         int                    `xml:"status,attr,omitempty"`
+	Size           int64                  `xml:"size,attr"`
+	Iops           int                    `xml:"iops,attr,omitempty"`
+	BusType        string                 `xml:"busType,attr,omitempty"`
+	BusSubType     string                 `xml:"busSubType,attr,omitempty"`
+	Link           types.LinkList         `xml:"Link,omitempty"`
+	Description    string                 `xml:"Description,omitempty"`
+	Tasks          *types.TasksInProgress `xml:"Tasks,omitempty"`
+	StorageProfile *types.Reference       `xml:"StorageProfile,omitempty"`
+	Owner          *types.Owner           `xml:"Owner,omitempty"`
+}
+
+// vmsType represents a list of VMs, such as the VMs a disk is attached to.
+type vmsType struct {
+	VmReference []*types.Reference `xml:"VmReference,omitempty"`
+}
+
+// adminOrgType represents the admin view of a vCloud Director organization.
+type adminOrgType struct {
+	XMLName     xml.Name               `xml:"AdminOrg"`
+	Xmlns       string                 `xml:"xmlns,attr,omitempty"`
+	HREF        string                 `xml:"href,attr,omitempty"`
+	Type        string                 `xml:"type,attr,omitempty"`
+	ID          string                 `xml:"id,attr,omitempty"`
+	Name        string                 `xml:"name,attr"`
+	Link        types.LinkList         `xml:"Link,omitempty"`
+	Description string                 `xml:"Description,omitempty"`
+	Tasks       *types.TasksInProgress `xml:"Tasks,omitempty"`
+	FullName    string                 `xml:"FullName"`
+	IsEnabled   bool                   `xml:"IsEnabled"`
+	Settings    *orgSettingsType       `xml:"Settings"`
+}
+
+// orgSettingsType represents the settings of a vCloud Director organization.
+type orgSettingsType struct {
+	HREF               string                  `xml:"href,attr,omitempty"`
+	Type               string                  `xml:"type,attr,omitempty"`
+	Link               types.LinkList          `xml:"Link,omitempty"`
+	OrgGeneralSettings *orgGeneralSettingsType `xml:"OrgGeneralSettings,omitempty"`
+}
+
+// orgGeneralSettingsType represents the general settings, such as the VM
+// quotas, of a vCloud Director organization.
+type orgGeneralSettingsType struct {
+	XMLName            xml.Name       `xml:"OrgGeneralSettings"`
+	Xmlns              string         `xml:"xmlns,attr,omitempty"`
+	HREF               string         `xml:"href,attr,omitempty"`
+	Type               string         `xml:"type,attr,omitempty"`
+	Link               types.LinkList `xml:"Link,omitempty"`
+	CanPublishCatalogs bool           `xml:"CanPublishCatalogs,omitempty"`
+	DeployedVMQuota    int            `xml:"DeployedVMQuota"`
+	StoredVMQuota      int            `xml:"StoredVmQuota"`
+}
+
+// vCloudType represents the admin view of a vCloud Director installation,
+// which references its organizations and provider vDCs.
+type vCloudType struct {
+	XMLName                xml.Name        `xml:"VCloud"`
+	HREF                   string          `xml:"href,attr,omitempty"`
+	Name                   string          `xml:"name,attr"`
+	OrganizationReferences *referencesType `xml:"OrganizationReferences,omitempty"`
+	ProviderVdcReferences  *referencesType `xml:"ProviderVdcReferences,omitempty"`
+}
+
+// referencesType is a list of references, whatever the name of its
+// elements, such as OrganizationReference or NetworkPoolReference.
+type referencesType struct {
+	Reference []*types.Reference `xml:",any"`
+}
+
+// externalNetworkReferencesType lists the external networks of the cloud.
+type externalNetworkReferencesType struct {
+	XMLName                  xml.Name           `xml:"VMWExternalNetworkReferences"`
+	ExternalNetworkReference []*types.Reference `xml:"ExternalNetworkReference,omitempty"`
+}
+
+// externalNetworkType represents the extension view of an external network.
+type externalNetworkType struct {
+	XMLName       xml.Name                          `xml:"VMWExternalNetwork"`
+	HREF          string                            `xml:"href,attr,omitempty"`
+	Type          string                            `xml:"type,attr,omitempty"`
+	ID            string                            `xml:"id,attr,omitempty"`
+	Name          string                            `xml:"name,attr"`
+	Description   string                            `xml:"Description,omitempty"`
+	Configuration *externalNetworkConfigurationType `xml:"Configuration,omitempty"`
+}
+
+// externalNetworkConfigurationType is the configuration of an external
+// network. Unlike other networks, external networks can have several IP
+// scopes.
+type externalNetworkConfigurationType struct {
+	IPScopes  *externalNetworkIPScopesType `xml:"IpScopes,omitempty"`
+	FenceMode string                       `xml:"FenceMode"`
+}
+
+// externalNetworkIPScopesType holds the subnets of an external network.
+type externalNetworkIPScopesType struct {
+	IPScope []*types.IPScope `xml:"IpScope"`
+}
+
+// providerVdcType represents the admin view of a provider vDC.
+type providerVdcType struct {
+	XMLName               xml.Name        `xml:"ProviderVdc"`
+	HREF                  string          `xml:"href,attr,omitempty"`
+	Type                  string          `xml:"type,attr,omitempty"`
+	ID                    string          `xml:"id,attr,omitempty"`
+	Name                  string          `xml:"name,attr"`
+	Description           string          `xml:"Description,omitempty"`
+	IsEnabled             bool            `xml:"IsEnabled,omitempty"`
+	NetworkPoolReferences *referencesType `xml:"NetworkPoolReferences,omitempty"`
+	StorageProfiles       *referencesType `xml:"StorageProfiles,omitempty"`
+}
+
+// createVdcParamsType are the parameters used to create an organization
+// vDC.
+type createVdcParamsType struct {
+	XMLName                  xml.Name                       `xml:"CreateVdcParams"`
+	Xmlns                    string                         `xml:"xmlns,attr"`
+	Name                     string                         `xml:"name,attr"`
+	Description              string                         `xml:"Description,omitempty"`
+	AllocationModel          string                         `xml:"AllocationModel"`
+	ComputeCapacity          *types.ComputeCapacity         `xml:"ComputeCapacity"`
+	NicQuota                 int                            `xml:"NicQuota"`
+	NetworkQuota             int                            `xml:"NetworkQuota"`
+	VMQuota                  int                            `xml:"VmQuota"`
+	IsEnabled                bool                           `xml:"IsEnabled"`
+	VdcStorageProfile        []*vdcStorageProfileParamsType `xml:"VdcStorageProfile"`
+	ResourceGuaranteedMemory float64                        `xml:"ResourceGuaranteedMemory,omitempty"`
+	ResourceGuaranteedCpu    float64                        `xml:"ResourceGuaranteedCpu,omitempty"`
+	VCpuInMhz                int64                          `xml:"VCpuInMhz,omitempty"`
+	IsThinProvision          bool                           `xml:"IsThinProvision"`
+	NetworkPoolReference     *types.Reference               `xml:"NetworkPoolReference,omitempty"`
+	ProviderVdcReference     *types.Reference               `xml:"ProviderVdcReference"`
+}
+
+// vdcStorageProfileParamsType are the parameters of a storage profile of a
+// vDC being created.
+type vdcStorageProfileParamsType struct {
+	Enabled                   bool             `xml:"Enabled"`
+	Units                     string           `xml:"Units"`
+	Limit                     int64            `xml:"Limit"`
+	Default                   bool             `xml:"Default"`
+	ProviderVdcStorageProfile *types.Reference `xml:"ProviderVdcStorageProfile"`
+}
+
+// adminVdcType represents the admin view of an organization vDC.
+type adminVdcType struct {
+	XMLName                  xml.Name                  `xml:"AdminVdc"`
+	Xmlns                    string                    `xml:"xmlns,attr,omitempty"`
+	HREF                     string                    `xml:"href,attr,omitempty"`
+	Type                     string                    `xml:"type,attr,omitempty"`
+	ID                       string                    `xml:"id,attr,omitempty"`
+	Name                     string                    `xml:"name,attr"`
+	Status                   int                       `xml:"status,attr,omitempty"`
+	Link                     types.LinkList            `xml:"Link,omitempty"`
+	Description              string                    `xml:"Description,omitempty"`
+	Tasks                    *types.TasksInProgress    `xml:"Tasks,omitempty"`
+	AllocationModel          string                    `xml:"AllocationModel"`
+	ComputeCapacity          *types.ComputeCapacity    `xml:"ComputeCapacity"`
+	NicQuota                 int                       `xml:"NicQuota"`
+	NetworkQuota             int                       `xml:"NetworkQuota"`
+	VMQuota                  int                       `xml:"VmQuota"`
+	IsEnabled                bool                      `xml:"IsEnabled"`
+	VdcStorageProfiles       *types.VdcStorageProfiles `xml:"VdcStorageProfiles,omitempty"`
+	ResourceGuaranteedMemory float64                   `xml:"ResourceGuaranteedMemory,omitempty"`
+	ResourceGuaranteedCpu    float64                   `xml:"ResourceGuaranteedCpu,omitempty"`
+	VCpuInMhz                int64                     `xml:"VCpuInMhz,omitempty"`
+	IsThinProvision          bool                      `xml:"IsThinProvision"`
+	NetworkPoolReference     *types.Reference          `xml:"NetworkPoolReference,omitempty"`
+	ProviderVdcReference     *types.Reference          `xml:"ProviderVdcReference,omitempty"`
+}
+
+// adminVdcStorageProfileType represents the admin view of a storage profile
+// of an organization vDC.
+type adminVdcStorageProfileType struct {
+	HREF                      string           `xml:"href,attr,omitempty"`
+	Name                      string           `xml:"name,attr"`
+	Enabled                   bool             `xml:"Enabled"`
+	Units                     string           `xml:"Units"`
+	Limit                     int64            `xml:"Limit"`
+	Default                   bool             `xml:"Default"`
+	ProviderVdcStorageProfile *types.Reference `xml:"ProviderVdcStorageProfile"`
+}
+
+// Metadata value types, set as the xsi:type of a TypedValue
+const (
+	metadataStringValue = "MetadataStringValue"
+)
+
+// metadataType is the list of metadata entries of an entity.
+type metadataType struct {
+	XMLName       xml.Name             `xml:"Metadata"`
+	HREF          string               `xml:"href,attr,omitempty"`
+	Link          types.LinkList       `xml:"Link,omitempty"`
+	MetadataEntry []*metadataEntryType `xml:"MetadataEntry,omitempty"`
+}
+
+type metadataEntryType struct {
+	Key        string            `xml:"Key"`
+	TypedValue *types.TypedValue `xml:"TypedValue"`
+}
+
+// vmType decodes the sections of a VM which the vendored types.VM lacks or
+// only decodes in part. It is read from the same document as types.VM.
+type vmType struct {
+	NetworkConnectionSection  *networkConnectionSectionType    `xml:"NetworkConnectionSection,omitempty"`
+	GuestCustomizationSection *types.GuestCustomizationSection `xml:"GuestCustomizationSection,omitempty"`
+	VirtualHardwareSection    *virtualHardwareSectionType      `xml:"VirtualHardwareSection,omitempty"`
+	RuntimeInfoSection        *runtimeInfoSectionType          `xml:"RuntimeInfoSection,omitempty"`
+}
+
+// networkConnectionSectionType is the container for the network connections
+// of a VM. Unlike types.NetworkConnectionSection it holds all the NICs.
+type networkConnectionSectionType struct {
+	XMLName xml.Name `xml:"NetworkConnectionSection"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	Ovf     string   `xml:"xmlns:ovf,attr,omitempty"`
+
+	Info                          string                   `xml:"ovf:Info"`
+	HREF                          string                   `xml:"href,attr,omitempty"`
+	Type                          string                   `xml:"type,attr,omitempty"`
+	PrimaryNetworkConnectionIndex int                      `xml:"PrimaryNetworkConnectionIndex"`
+	NetworkConnection             []*networkConnectionType `xml:"NetworkConnection,omitempty"`
+}
+
+// networkConnectionType represents a NIC of a VM. Unlike
+// types.NetworkConnection its elements are in schema order, as vCD requires
+// when updating, and it includes the adapter type.
+type networkConnectionType struct {
+	Network                 string `xml:"network,attr"`                      // Name of the network to which this NIC is connected.
+	NeedsCustomization      bool   `xml:"needsCustomization,attr,omitempty"` // True if this NIC needs customization.
+	NetworkConnectionIndex  int    `xml:"NetworkConnectionIndex"`            // Virtual slot number associated with this NIC. First slot number is 0.
+	IPAddress               string `xml:"IpAddress,omitempty"`               // IP address assigned to this NIC.
+	ExternalIPAddress       string `xml:"ExternalIpAddress,omitempty"`       // If the network to which this NIC connects provides NAT services, the external address assigned to this NIC appears here.
+	IsConnected             bool   `xml:"IsConnected"`                       // Whether the NIC is connected.
+	MACAddress              string `xml:"MACAddress,omitempty"`              // MAC address associated with the NIC.
+	IPAddressAllocationMode string `xml:"IpAddressAllocationMode"`           // One of: POOL, DHCP, MANUAL, NONE.
+	NetworkAdapterType      string `xml:"NetworkAdapterType,omitempty"`      // The type of the NIC, e.g. VMXNET3 or E1000. vCD picks one based on the guest OS when empty.
+}
+
+// virtualHardwareSectionType is the virtual hardware of a VM, with the
+// elements of its items that types.VirtualHardwareItem does not decode.
+type virtualHardwareSectionType struct {
+	Item []*virtualHardwareItemType `xml:"Item,omitempty"`
+}
+
+type virtualHardwareItemType struct {
+	ResourceType    int                             `xml:"ResourceType,omitempty"`
+	ResourceSubType string                          `xml:"ResourceSubType,omitempty"`
+	ElementName     string                          `xml:"ElementName,omitempty"`
+	Description     string                          `xml:"Description,omitempty"`
+	InstanceID      int                             `xml:"InstanceID,omitempty"`
+	Address         string                          `xml:"Address,omitempty"`
+	AddressOnParent int                             `xml:"AddressOnParent,omitempty"`
+	Parent          int                             `xml:"Parent,omitempty"` // For disks, the InstanceID of their controller
+	HostResource    []*virtualHardwareHostResources `xml:"HostResource,omitempty"`
+}
+
+type virtualHardwareHostResources struct {
+	Capacity          int    `xml:"capacity,attr,omitempty"`
+	StorageProfile    string `xml:"storageProfileHref,attr,omitempty"`
+	OverrideVmDefault bool   `xml:"storageProfileOverrideVmDefault,attr,omitempty"`
+	Value             string `xml:",chardata"` // For ResourceType=15 (CD Drive) the media inserted, if any
+}
+
+// runtimeInfoSectionType holds the runtime information of a VM.
+type runtimeInfoSectionType struct {
+	VMWareTools *vmwareToolsType `xml:"VMWareTools,omitempty"` // Set when VMware Tools are installed in the guest.
+}
+
+type vmwareToolsType struct {
+	Version string `xml:"version,attr,omitempty"` // The version of the VMware Tools installed in the guest.
+}
+
+// ovfCPUItemType is the CPU item of a VM, as updated through its
+// virtualHardwareSection/cpu link. Like types.OVFItem, it spells out the
+// namespace prefixes because it is only ever marshaled.
+type ovfCPUItemType struct {
+	XMLName         xml.Name    `xml:"vcloud:Item"`
+	XmlnsRasd       string      `xml:"xmlns:rasd,attr"`
+	XmlnsVCloud     string      `xml:"xmlns:vcloud,attr"`
+	XmlnsXsi        string      `xml:"xmlns:xsi,attr"`
+	XmlnsVmw        string      `xml:"xmlns:vmw,attr,omitempty"`
+	VCloudHREF      string      `xml:"vcloud:href,attr"`
+	VCloudType      string      `xml:"vcloud:type,attr"`
+	AllocationUnits string      `xml:"rasd:AllocationUnits"`
+	Description     string      `xml:"rasd:Description"`
+	ElementName     string      `xml:"rasd:ElementName"`
+	InstanceID      int         `xml:"rasd:InstanceID"`
+	Reservation     int         `xml:"rasd:Reservation"`
+	ResourceType    int         `xml:"rasd:ResourceType"`
+	VirtualQuantity int         `xml:"rasd:VirtualQuantity"`
+	Weight          int         `xml:"rasd:Weight"`
+	CoresPerSocket  int         `xml:"vmw:CoresPerSocket,omitempty"` // Omitted to keep the current value
+	Link            *types.Link `xml:"vcloud:Link"`
+}
+
+// rasdItemsListType is the list of disks and disk controllers of a VM, as
+// replaced through its virtualHardwareSection/disks link. Like types.OVFItem,
+// it spells out the namespace prefixes because it is only ever marshaled.
+type rasdItemsListType struct {
+	XMLName     xml.Name           `xml:"RasdItemsList"`
+	Xmlns       string             `xml:"xmlns,attr"`
+	XmlnsRasd   string             `xml:"xmlns:rasd,attr"`
+	XmlnsVCloud string             `xml:"xmlns:vcloud,attr"`
+	HREF        string             `xml:"href,attr,omitempty"`
+	Type        string             `xml:"type,attr,omitempty"`
+	Item        []*ovfDiskItemType `xml:"Item"`
+}
+
+// ovfDiskItemType is a disk (ResourceType 17) or a disk controller of a
+// rasdItemsListType. Controllers carry their bus number in Address, disks
+// their unit number in AddressOnParent.
+type ovfDiskItemType struct {
+	Address         string                   `xml:"rasd:Address,omitempty"`
+	AddressOnParent string                   `xml:"rasd:AddressOnParent,omitempty"`
+	Description     string                   `xml:"rasd:Description"`
+	ElementName     string                   `xml:"rasd:ElementName"`
+	HostResource    *ovfDiskHostResourceType `xml:"rasd:HostResource,omitempty"`
+	InstanceID      int                      `xml:"rasd:InstanceID"`
+	Parent          string                   `xml:"rasd:Parent,omitempty"`
+	ResourceSubType string                   `xml:"rasd:ResourceSubType,omitempty"`
+	ResourceType    int                      `xml:"rasd:ResourceType"`
+}
+
+// ovfDiskHostResourceType holds the capacity, in MB, and the bus of a disk.
+type ovfDiskHostResourceType struct {
+	BusSubType        string `xml:"vcloud:busSubType,attr"`
+	BusType           int    `xml:"vcloud:busType,attr"`
+	Capacity          int    `xml:"vcloud:capacity,attr"`
+	StorageProfile    string `xml:"vcloud:storageProfileHref,attr,omitempty"`
+	OverrideVmDefault bool   `xml:"vcloud:storageProfileOverrideVmDefault,attr,omitempty"`
+}
+
+// networkConfigSectionType is the container for the networks of a vApp.
+// Unlike types.NetworkConfigSection it holds all the networks.
+type networkConfigSectionType struct {
+	XMLName xml.Name `xml:"NetworkConfigSection"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	Ovf     string   `xml:"xmlns:ovf,attr,omitempty"`
+
+	Info          string                            `xml:"ovf:Info"`
+	HREF          string                            `xml:"href,attr,omitempty"`
+	Type          string                            `xml:"type,attr,omitempty"`
+	NetworkConfig []*types.VAppNetworkConfiguration `xml:"NetworkConfig,omitempty"`
+}
+
+// edgeGatewayType decodes the services of an edge gateway which the vendored
+// types.EdgeGateway only decodes in part.
+type edgeGatewayType struct {
+	Configuration *struct {
+		EdgeGatewayServiceConfiguration *struct {
+			StaticRoutingService *staticRoutingServiceType `xml:"StaticRoutingService,omitempty"`
+		} `xml:"EdgeGatewayServiceConfiguration,omitempty"`
+	} `xml:"Configuration"`
+}
+
+// edgeGatewayServiceConfigurationType is the configuration of edge gateway
+// services, as sent to the configureServices action.
+type edgeGatewayServiceConfigurationType struct {
+	XMLName              xml.Name                  `xml:"EdgeGatewayServiceConfiguration"`
+	Xmlns                string                    `xml:"xmlns,attr,omitempty"`
+	GatewayDhcpService   *types.GatewayDhcpService `xml:"GatewayDhcpService,omitempty"`
+	NatService           *types.NatService         `xml:"NatService,omitempty"`
+	StaticRoutingService *staticRoutingServiceType `xml:"StaticRoutingService,omitempty"`
+}
+
+// staticRoutingServiceType represents the static routing service of an edge
+// gateway. Unlike types.StaticRoutingService it holds all the routes.
+type staticRoutingServiceType struct {
+	IsEnabled   bool                 `xml:"IsEnabled"`             // Enable or disable the service using this flag
+	StaticRoute []*types.StaticRoute `xml:"StaticRoute,omitempty"` // Details of each Static Route.
+}
+
+// nsxErrorType is the error returned by the NSX API that vCD proxies for
+// advanced edge gateways.
+type nsxErrorType struct {
+	XMLName    xml.Name `xml:"error"`
+	ErrorCode  int      `xml:"errorCode"`
+	Details    string   `xml:"details"`
+	ModuleName string   `xml:"moduleName,omitempty"`
+}
+
+// lbConfigType represents the load balancer configuration of an advanced
+// edge gateway, as returned by the NSX API when listing load balancer
+// objects.
+type lbConfigType struct {
+	XMLName            xml.Name               `xml:"loadBalancer"`
+	Monitor            []*lbMonitorType       `xml:"monitor,omitempty"`
+	Pool               []*lbPoolType          `xml:"pool,omitempty"`
+	ApplicationProfile []*lbAppProfileType    `xml:"applicationProfile,omitempty"`
+	VirtualServer      []*lbVirtualServerType `xml:"virtualServer,omitempty"`
+}
+
+// lbMonitorType represents a load balancer service monitor of an advanced
+// edge gateway, which checks the health of the members of server pools.
+type lbMonitorType struct {
+	XMLName    xml.Name `xml:"monitor"`
+	ID         string   `xml:"monitorId,omitempty"`
+	Name       string   `xml:"name"`
+	Type       string   `xml:"type"`               // One of: http, https, tcp, icmp.
+	Interval   int      `xml:"interval"`           // Seconds between checks.
+	Timeout    int      `xml:"timeout"`            // Seconds to wait for a response.
+	MaxRetries int      `xml:"maxRetries"`         // Failed checks before a member is down.
+	Method     string   `xml:"method,omitempty"`   // HTTP method of http and https checks.
+	URL        string   `xml:"url,omitempty"`      // URL of http and https checks.
+	Expected   string   `xml:"expected,omitempty"` // Status line expected by http and https checks.
+	Send       string   `xml:"send,omitempty"`     // Data sent by tcp, http and https checks.
+	Receive    string   `xml:"receive,omitempty"`  // Data expected in the response.
+}
+
+// lbPoolType represents a load balancer server pool of an advanced edge
+// gateway.
+type lbPoolType struct {
+	XMLName     xml.Name            `xml:"pool"`
+	ID          string              `xml:"poolId,omitempty"`
+	Name        string              `xml:"name"`
+	Description string              `xml:"description,omitempty"`
+	Algorithm   string              `xml:"algorithm"`           // One of: round-robin, ip-hash, leastconn, uri.
+	Transparent bool                `xml:"transparent"`         // True to let the members see the client IP.
+	MonitorID   string              `xml:"monitorId,omitempty"` // Service monitor checking the members.
+	Member      []*lbPoolMemberType `xml:"member,omitempty"`    // Members of the pool.
+}
+
+// lbPoolMemberType represents a member of a load balancer server pool.
+type lbPoolMemberType struct {
+	ID          string `xml:"memberId,omitempty"`
+	Name        string `xml:"name"`
+	IPAddress   string `xml:"ipAddress"`
+	Port        int    `xml:"port"`
+	MonitorPort int    `xml:"monitorPort,omitempty"` // Port the monitor checks. Defaults to Port.
+	Weight      int    `xml:"weight,omitempty"`
+	Condition   string `xml:"condition,omitempty"` // One of: enabled, disabled, drain.
+}
+
+// lbAppProfileType represents a load balancer application profile of an
+// advanced edge gateway.
+type lbAppProfileType struct {
+	XMLName             xml.Name                     `xml:"applicationProfile"`
+	ID                  string                       `xml:"applicationProfileId,omitempty"`
+	Name                string                       `xml:"name"`
+	Template            string                       `xml:"template"` // One of: HTTP, HTTPS, TCP.
+	SslPassthrough      bool                         `xml:"sslPassthrough"`
+	InsertXForwardedFor bool                         `xml:"insertXForwardedFor"`
+	Persistence         *lbAppProfilePersistenceType `xml:"persistence,omitempty"`
+}
+
+// lbAppProfilePersistenceType represents how an application profile keeps
+// the connections of a client on the same pool member.
+type lbAppProfilePersistenceType struct {
+	Method     string `xml:"method"`               // One of: cookie, ssl_sessionid, sourceip, msrdp.
+	CookieName string `xml:"cookieName,omitempty"` // Cookie name when method is cookie.
+	CookieMode string `xml:"cookieMode,omitempty"` // One of: insert, prefix, app.
+}
+
+// lbVirtualServerType represents a load balancer virtual server of an
+// advanced edge gateway.
+type lbVirtualServerType struct {
+	XMLName              xml.Name `xml:"virtualServer"`
+	ID                   string   `xml:"virtualServerId,omitempty"`
+	Name                 string   `xml:"name"`
+	Description          string   `xml:"description,omitempty"`
+	Enabled              bool     `xml:"enabled"`
+	IPAddress            string   `xml:"ipAddress"`
+	Protocol             string   `xml:"protocol"` // One of: http, https, tcp.
+	Port                 int      `xml:"port"`
+	ApplicationProfileID string   `xml:"applicationProfileId"`
+	DefaultPoolID        string   `xml:"defaultPoolId,omitempty"`
+}
@@ -0,0 +1,233 @@
+package vcd
+
+import (
+	"fmt"
+
+	govcd "github.com/ukcloud/govcloudair"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+// govcdVApp names the embedded govcd.VApp of vcdVApp, so that vcdVApp.VApp
+// still is the *types.VApp of the vApp.
+type govcdVApp = govcd.VApp
+
+// vcdVApp is a govcd.VApp with the calls the vendored govcloudair lacks.
+type vcdVApp struct {
+	govcdVApp
+	c *govcd.Client
+}
+
+// newVApp extends vapp with the calls of vcdVApp.
+func (c *VCDClient) newVApp(vapp govcd.VApp) vcdVApp {
+	return vcdVApp{govcdVApp: vapp, c: &c.Client}
+}
+
+// findVAppByName looks the named vApp of the VDC up.
+func (c *VCDClient) findVAppByName(name string) (vcdVApp, error) {
+	vapp, err := c.OrgVdc.FindVAppByName(name)
+	if err != nil {
+		return vcdVApp{}, err
+	}
+
+	return c.newVApp(vapp), nil
+}
+
+// AddVMWithStorageProfile adds a VM to the vApp like govcd.VApp.AddVM,
+// placing its disks on the given storage profile. A nil profile leaves the
+// choice to vCD, which uses the default storage profile of the VDC.
+func (v *vcdVApp) AddVMWithStorageProfile(orgvdcnetwork govcd.OrgVDCNetwork, vapptemplate govcd.VAppTemplate, name string, storageprofileref *types.Reference) (govcd.Task, error) {
+
+	vcomp := &types.ReComposeVAppParams{
+		Ovf:         "http://schemas.dmtf.org/ovf/envelope/1",
+		Xsi:         "http://www.w3.org/2001/XMLSchema-instance",
+		Xmlns:       "http://www.vmware.com/vcloud/v1.5",
+		Deploy:      false,
+		Name:        v.VApp.Name,
+		PowerOn:     false,
+		Description: v.VApp.Description,
+		SourcedItem: &types.SourcedCompositionItemParam{
+			Source: &types.Reference{
+				HREF: vapptemplate.VAppTemplate.Children.VM[0].HREF,
+				Name: name,
+			},
+			InstantiationParams: &types.InstantiationParams{
+				NetworkConnectionSection: &types.NetworkConnectionSection{
+					Type:                          vapptemplate.VAppTemplate.Children.VM[0].NetworkConnectionSection.Type,
+					HREF:                          vapptemplate.VAppTemplate.Children.VM[0].NetworkConnectionSection.HREF,
+					Info:                          "Network config for sourced item",
+					PrimaryNetworkConnectionIndex: vapptemplate.VAppTemplate.Children.VM[0].NetworkConnectionSection.PrimaryNetworkConnectionIndex,
+					NetworkConnection: &types.NetworkConnection{
+						Network:                 orgvdcnetwork.OrgVDCNetwork.Name,
+						NetworkConnectionIndex:  vapptemplate.VAppTemplate.Children.VM[0].NetworkConnectionSection.PrimaryNetworkConnectionIndex,
+						IsConnected:             true,
+						IPAddressAllocationMode: "POOL",
+					},
+				},
+			},
+			NetworkAssignment: &types.NetworkAssignment{
+				InnerNetwork:     orgvdcnetwork.OrgVDCNetwork.Name,
+				ContainerNetwork: orgvdcnetwork.OrgVDCNetwork.Name,
+			},
+			StorageProfile: storageprofileref,
+		},
+	}
+
+	if connection := vapptemplate.VAppTemplate.Children.VM[0].NetworkConnectionSection.NetworkConnection; connection != nil {
+		vcomp.SourcedItem.InstantiationParams.NetworkConnectionSection.NetworkConnection.NetworkConnectionIndex = connection.NetworkConnectionIndex
+	}
+
+	task, err := apiTask(v.c, "POST", v.VApp.HREF+"/action/recomposeVApp", "application/vnd.vmware.vcloud.recomposeVAppParams+xml", vcomp)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error instantiating a new VM: %s", err)
+	}
+	return task, nil
+}
+
+// HasVMwareTools reports whether the vApp has VMs and vCD reports VMware
+// Tools installed in the guest of all of them, so that the vApp can be shut
+// down.
+func (v *vcdVApp) HasVMwareTools() (bool, error) {
+
+	resp, err := apiRequest(v.c, "GET", v.VApp.HREF, "", nil, nil)
+	if err != nil {
+		return false, fmt.Errorf("error retrieving vApp: %s", err)
+	}
+
+	// govcd.VApp does not decode the runtime info of the VMs
+	vapp := &struct {
+		Children *struct {
+			VM []*vmType `xml:"Vm"`
+		} `xml:"Children"`
+	}{}
+
+	if err = decodeBody(resp, vapp); err != nil {
+		return false, fmt.Errorf("error decoding vApp response: %s", err)
+	}
+
+	if vapp.Children == nil || len(vapp.Children.VM) == 0 {
+		return false, nil
+	}
+
+	for _, sections := range vapp.Children.VM {
+		vm := vcdVM{sections: sections}
+		if !vm.HasVMwareTools() {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// GetMetadata returns the metadata of the vApp.
+func (v *vcdVApp) GetMetadata() (*metadataType, error) {
+	return getMetadata(v.c, v.VApp.HREF)
+}
+
+// AddMetadata sets the metadata key of the vApp to the string value. Unlike
+// govcd.VApp.AddMetadata it sets it on the vApp rather than on its first VM.
+func (v *vcdVApp) AddMetadata(key, value string) (govcd.Task, error) {
+	return addMetadata(v.c, v.VApp.HREF, key, metadataStringValue, value)
+}
+
+// DeleteMetadata removes the metadata key from the vApp.
+func (v *vcdVApp) DeleteMetadata(key string) (govcd.Task, error) {
+	return deleteMetadata(v.c, v.VApp.HREF, key)
+}
+
+// GetNetworkConfig returns the networks of the vApp. Unlike
+// govcd.VApp.GetNetworkConfig it returns all of them.
+func (v *vcdVApp) GetNetworkConfig() (*networkConfigSectionType, error) {
+
+	networkConfig := &networkConfigSectionType{}
+
+	if v.VApp.HREF == "" {
+		return networkConfig, fmt.Errorf("cannot refresh, Object is empty")
+	}
+
+	resp, err := apiRequest(v.c, "GET", v.VApp.HREF+"/networkConfigSection/", "", nil, nil)
+	if err != nil {
+		return networkConfig, fmt.Errorf("error retrieving task: %s", err)
+	}
+
+	if err = decodeBody(resp, networkConfig); err != nil {
+		return networkConfig, fmt.Errorf("error decoding task response: %s", err)
+	}
+
+	// The request was successful
+	return networkConfig, nil
+}
+
+// AddRAWNetworkConfig bridges the vApp to an org VDC network. Unlike
+// govcd.VApp.AddRAWNetworkConfig it keeps any networks the vApp already has.
+func (v *vcdVApp) AddRAWNetworkConfig(networkName string, networkHref string) (govcd.Task, error) {
+	return v.AddNetworkConfig(&types.VAppNetworkConfiguration{
+		NetworkName: networkName,
+		Configuration: &types.NetworkConfiguration{
+			ParentNetwork: &types.Reference{
+				HREF: networkHref,
+			},
+			FenceMode: "bridged",
+		},
+	})
+}
+
+// AddNetworkConfig adds a network to the vApp. Isolated and NAT routed vApp
+// networks only exist within the vApp.
+func (v *vcdVApp) AddNetworkConfig(config *types.VAppNetworkConfiguration) (govcd.Task, error) {
+
+	networkConfig, err := v.GetNetworkConfig()
+	if err != nil {
+		return govcd.Task{}, err
+	}
+
+	for _, existing := range networkConfig.NetworkConfig {
+		if existing.NetworkName == config.NetworkName {
+			return govcd.Task{}, fmt.Errorf("vApp %s already has a network named %s", v.VApp.Name, config.NetworkName)
+		}
+	}
+
+	networkConfig.NetworkConfig = append(networkConfig.NetworkConfig, config)
+
+	return v.updateNetworkConfig(networkConfig)
+}
+
+// RemoveNetworkConfig removes the named network from the vApp.
+func (v *vcdVApp) RemoveNetworkConfig(networkName string) (govcd.Task, error) {
+
+	networkConfig, err := v.GetNetworkConfig()
+	if err != nil {
+		return govcd.Task{}, err
+	}
+
+	configs := make([]*types.VAppNetworkConfiguration, 0, len(networkConfig.NetworkConfig))
+	for _, existing := range networkConfig.NetworkConfig {
+		if existing.NetworkName != networkName {
+			configs = append(configs, existing)
+		}
+	}
+
+	if len(configs) == len(networkConfig.NetworkConfig) {
+		return govcd.Task{}, fmt.Errorf("vApp %s has no network named %s", v.VApp.Name, networkName)
+	}
+
+	networkConfig.NetworkConfig = configs
+
+	return v.updateNetworkConfig(networkConfig)
+}
+
+func (v *vcdVApp) updateNetworkConfig(networkConfig *networkConfigSectionType) (govcd.Task, error) {
+
+	networkConfig.Info = "Configuration parameters for logical networks"
+	networkConfig.Ovf = "http://schemas.dmtf.org/ovf/envelope/1"
+	networkConfig.Type = "application/vnd.vmware.vcloud.networkConfigSection+xml"
+	networkConfig.Xmlns = "http://www.vmware.com/vcloud/v1.5"
+	for _, config := range networkConfig.NetworkConfig {
+		config.Link = nil
+	}
+
+	task, err := apiTask(v.c, "PUT", v.VApp.HREF+"/networkConfigSection/", "application/vnd.vmware.vcloud.networkconfigsection+xml", networkConfig)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error updating vApp Networks: %s", err)
+	}
+	return task, nil
+}
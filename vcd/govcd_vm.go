@@ -0,0 +1,559 @@
+package vcd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	govcd "github.com/ukcloud/govcloudair"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+// govcdVM names the embedded govcd.VM of vcdVM, so that vcdVM.VM still is
+// the *types.VM of the VM.
+type govcdVM = govcd.VM
+
+// vcdVM is a govcd.VM with the sections and the calls the vendored
+// govcloudair lacks.
+type vcdVM struct {
+	govcdVM
+	sections *vmType
+	c        *govcd.Client
+}
+
+// findVMByName looks the named VM of the vApp up, along with the sections
+// govcd.VM does not decode.
+func (c *VCDClient) findVMByName(vapp vcdVApp, name string) (vcdVM, error) {
+	vm, err := c.OrgVdc.FindVMByName(vapp.govcdVApp, name)
+	if err != nil {
+		return vcdVM{}, err
+	}
+
+	v := vcdVM{govcdVM: vm, c: &c.Client}
+	if err := v.Refresh(); err != nil {
+		return vcdVM{}, err
+	}
+
+	return v, nil
+}
+
+// Refresh reads the VM again, along with the sections types.VM lacks.
+func (v *vcdVM) Refresh() error {
+
+	if v.VM.HREF == "" {
+		return fmt.Errorf("cannot refresh VM, Object is empty")
+	}
+
+	resp, err := apiRequest(v.c, "GET", v.VM.HREF, "", nil, nil)
+	if err != nil {
+		return fmt.Errorf("error retrieving VM: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error retrieving VM: %s", err)
+	}
+
+	// Empty structs before a new unmarshal, otherwise we end up with
+	// duplicate elements in slices.
+	v.VM = &types.VM{}
+	v.sections = &vmType{}
+
+	if err = xml.Unmarshal(body, v.VM); err != nil {
+		return fmt.Errorf("error decoding VM response: %s", err)
+	}
+	if err = xml.Unmarshal(body, v.sections); err != nil {
+		return fmt.Errorf("error decoding VM response: %s", err)
+	}
+
+	// The request was successful
+	return nil
+}
+
+// Shutdown asks the guest operating system to shut down, which needs VMware
+// Tools to be running in the guest.
+func (v *vcdVM) Shutdown() (govcd.Task, error) {
+	task, err := apiTask(v.c, "POST", v.VM.HREF+"/power/action/shutdown", "", nil)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error shutting down VM: %s", err)
+	}
+	return task, nil
+}
+
+// HasVMwareTools reports whether vCD reports VMware Tools installed in the
+// guest, so that the guest operating system can be shut down.
+func (v *vcdVM) HasVMwareTools() bool {
+	r := v.sections.RuntimeInfoSection
+	return r != nil && r.VMWareTools != nil && r.VMWareTools.Version != ""
+}
+
+// ChangeCPUCountWithCores sets the number of virtual CPUs of the VM, spread
+// over sockets of cores CPUs each. When cores is 0 the VM keeps its number
+// of cores per socket.
+func (v *vcdVM) ChangeCPUCountWithCores(size, cores int) (govcd.Task, error) {
+
+	newcpu := &ovfCPUItemType{
+		XmlnsRasd:       "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData",
+		XmlnsVCloud:     "http://www.vmware.com/vcloud/v1.5",
+		XmlnsXsi:        "http://www.w3.org/2001/XMLSchema-instance",
+		VCloudHREF:      v.VM.HREF + "/virtualHardwareSection/cpu",
+		VCloudType:      "application/vnd.vmware.vcloud.rasdItem+xml",
+		AllocationUnits: "hertz * 10^6",
+		Description:     "Number of Virtual CPUs",
+		ElementName:     strconv.Itoa(size) + " virtual CPU(s)",
+		InstanceID:      4,
+		Reservation:     0,
+		ResourceType:    3,
+		VirtualQuantity: size,
+		Weight:          0,
+		Link: &types.Link{
+			HREF: v.VM.HREF + "/virtualHardwareSection/cpu",
+			Rel:  "edit",
+			Type: "application/vnd.vmware.vcloud.rasdItem+xml",
+		},
+	}
+
+	if cores > 0 {
+		newcpu.XmlnsVmw = "http://www.vmware.com/schema/ovf"
+		newcpu.CoresPerSocket = cores
+	}
+
+	task, err := apiTask(v.c, "PUT", v.VM.HREF+"/virtualHardwareSection/cpu", "application/vnd.vmware.vcloud.rasdItem+xml", newcpu)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error customizing VM: %s", err)
+	}
+	return task, nil
+}
+
+// ChangeNetworkConnections replaces the NICs of the VM with the given
+// connections. The connection with the NetworkConnectionIndex primaryIndex
+// becomes the primary NIC of the VM.
+func (v *vcdVM) ChangeNetworkConnections(connections []*networkConnectionType, primaryIndex int) (govcd.Task, error) {
+
+	newnetwork := &networkConnectionSectionType{
+		Xmlns:                         "http://www.vmware.com/vcloud/v1.5",
+		Ovf:                           "http://schemas.dmtf.org/ovf/envelope/1",
+		Info:                          "Specifies the available VM network connections",
+		PrimaryNetworkConnectionIndex: primaryIndex,
+		NetworkConnection:             connections,
+	}
+
+	task, err := apiTask(v.c, "PUT", v.VM.HREF+"/networkConnectionSection/", "application/vnd.vmware.vcloud.networkConnectionSection+xml", newnetwork)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error customizing VM Network: %s", err)
+	}
+	return task, nil
+}
+
+// SetGuestCustomization replaces the guest customization settings of the VM.
+// The settings are applied the next time the VM is customized, which happens
+// on its first power on or when customization is forced.
+func (v *vcdVM) SetGuestCustomization(section *types.GuestCustomizationSection) (govcd.Task, error) {
+	section.Ovf = "http://schemas.dmtf.org/ovf/envelope/1"
+	section.Xsi = "http://www.w3.org/2001/XMLSchema-instance"
+	section.Xmlns = "http://www.vmware.com/vcloud/v1.5"
+	section.HREF = v.VM.HREF
+	section.Type = "application/vnd.vmware.vcloud.guestCustomizationSection+xml"
+	section.Info = "Specifies Guest OS Customization Settings"
+	section.Link = nil
+
+	task, err := apiTask(v.c, "PUT", v.VM.HREF+"/guestCustomizationSection/", "application/vnd.vmware.vcloud.guestCustomizationSection+xml", section)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error setting guest customization: %s", err)
+	}
+	return task, nil
+}
+
+// PowerOnAndForceCustomization deploys and powers on an undeployed VM,
+// running guest customization again even if it already ran.
+func (v *vcdVM) PowerOnAndForceCustomization() (govcd.Task, error) {
+
+	vu := &types.DeployVAppParams{
+		Xmlns:              "http://www.vmware.com/vcloud/v1.5",
+		PowerOn:            true,
+		ForceCustomization: true,
+	}
+
+	task, err := apiTask(v.c, "POST", v.VM.HREF+"/action/deploy", "application/vnd.vmware.vcloud.deployVAppParams+xml", vu)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error deploying VM: %s", err)
+	}
+	return task, nil
+}
+
+// InsertMedia inserts the media, e.g. an ISO from a catalog, into the CD
+// drive of the VM.
+func (v *vcdVM) InsertMedia(media *types.Reference) (govcd.Task, error) {
+	return v.mediaAction("insertMedia", media)
+}
+
+// EjectMedia ejects the media from the CD drive of the VM.
+func (v *vcdVM) EjectMedia(media *types.Reference) (govcd.Task, error) {
+	return v.mediaAction("ejectMedia", media)
+}
+
+func (v *vcdVM) mediaAction(action string, media *types.Reference) (govcd.Task, error) {
+
+	params := &mediaInsertOrEjectParamsType{
+		Xmlns: "http://www.vmware.com/vcloud/v1.5",
+		Media: media,
+	}
+
+	task, err := apiTask(v.c, "POST", v.VM.HREF+"/media/action/"+action, "application/vnd.vmware.vcloud.mediaInsertOrEjectParams+xml", params)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error performing %s on VM: %s", action, err)
+	}
+	return task, nil
+}
+
+// InsertedMedia returns the names of the media inserted in the CD drives of
+// the VM.
+func (v *vcdVM) InsertedMedia() []string {
+	var media []string
+
+	if v.sections.VirtualHardwareSection == nil {
+		return media
+	}
+
+	for _, item := range v.sections.VirtualHardwareSection.Item {
+		if item.ResourceType != 15 {
+			continue
+		}
+		for _, hr := range item.HostResource {
+			if hr.Value != "" {
+				media = append(media, hr.Value)
+			}
+		}
+	}
+
+	return media
+}
+
+// ChangeStorageProfile moves the disks of the VM to the given storage
+// profile. vCD migrates them in place, so the VM can keep running.
+func (v *vcdVM) ChangeStorageProfile(storageprofileref types.Reference) (govcd.Task, error) {
+
+	vm := &types.VM{
+		Xmlns:          "http://www.vmware.com/vcloud/v1.5",
+		Name:           v.VM.Name,
+		StorageProfile: &storageprofileref,
+	}
+
+	task, err := apiTask(v.c, "PUT", v.VM.HREF, "application/vnd.vmware.vcloud.vm+xml", vm)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error changing VM storage profile: %s", err)
+	}
+	return task, nil
+}
+
+// Bus types of VM disks, as used by OVF for the disk controllers.
+const (
+	diskBusIDE  = 5
+	diskBusSCSI = 6
+	diskBusSATA = 20
+)
+
+func isDiskController(item *virtualHardwareItemType) bool {
+	return item.ResourceType == diskBusIDE || item.ResourceType == diskBusSCSI || item.ResourceType == diskBusSATA
+}
+
+// vmDisk is an internal disk of a VM, addressed by the bus type and number
+// of its controller and its unit number on that controller. An empty
+// StorageProfileHREF means the disk follows the storage profile of the VM.
+// MoveFrom is the current placement of a disk moved to another bus or unit.
+type vmDisk struct {
+	BusType            int
+	BusNumber          int
+	UnitNumber         int
+	SizeMB             int
+	StorageProfileHREF string
+	MoveFrom           *vmDisk
+}
+
+// GetDisks returns the internal disks of the VM, as of its last refresh.
+func (v *vcdVM) GetDisks() []vmDisk {
+	var disks []vmDisk
+
+	if v.sections.VirtualHardwareSection == nil {
+		return disks
+	}
+
+	controllers := make(map[int]*virtualHardwareItemType)
+	for _, item := range v.sections.VirtualHardwareSection.Item {
+		if isDiskController(item) {
+			controllers[item.InstanceID] = item
+		}
+	}
+
+	for _, item := range v.sections.VirtualHardwareSection.Item {
+		if item.ResourceType != 17 || len(item.HostResource) == 0 {
+			continue
+		}
+		controller, ok := controllers[item.Parent]
+		if !ok {
+			continue
+		}
+		bus, _ := strconv.Atoi(controller.Address)
+
+		disk := vmDisk{
+			BusType:    controller.ResourceType,
+			BusNumber:  bus,
+			UnitNumber: item.AddressOnParent,
+			SizeMB:     item.HostResource[0].Capacity,
+		}
+		if item.HostResource[0].OverrideVmDefault {
+			disk.StorageProfileHREF = item.HostResource[0].StorageProfile
+		}
+		disks = append(disks, disk)
+	}
+
+	return disks
+}
+
+// ChangeDisks replaces the internal disks of the VM with the given ones.
+// Disks are matched on their bus and unit, or the ones they are moved from:
+// existing disks are resized, moved to another storage profile or placement,
+// new ones are added, and disks missing from the list are removed. vCD cannot shrink a disk, so sizes should only
+// grow. Controllers are added for buses the VM does not have yet.
+func (v *vcdVM) ChangeDisks(disks []vmDisk) (govcd.Task, error) {
+
+	err := v.Refresh()
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error refreshing VM before changing disks: %v", err)
+	}
+
+	if v.sections.VirtualHardwareSection == nil {
+		return govcd.Task{}, fmt.Errorf("VM %s has no virtual hardware section", v.VM.Name)
+	}
+
+	list := &rasdItemsListType{
+		Xmlns:       "http://www.vmware.com/vcloud/v1.5",
+		XmlnsRasd:   "http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData",
+		XmlnsVCloud: "http://www.vmware.com/vcloud/v1.5",
+		HREF:        v.VM.HREF + "/virtualHardwareSection/disks",
+		Type:        "application/vnd.vmware.vcloud.rasdItemsList+xml",
+	}
+
+	type address struct{ busType, bus, unit int }
+
+	// Existing items keep their instance ids, new ones get the next free id
+	nextID := 0
+	controllers := make(map[address]*ovfDiskItemType)
+	existing := make(map[address]*virtualHardwareItemType)
+	controllerSubTypes := make(map[int]string)
+	byInstance := make(map[int]*virtualHardwareItemType)
+
+	for _, item := range v.sections.VirtualHardwareSection.Item {
+		if item.InstanceID >= nextID {
+			nextID = item.InstanceID + 1
+		}
+		byInstance[item.InstanceID] = item
+
+		if !isDiskController(item) {
+			continue
+		}
+		bus, _ := strconv.Atoi(item.Address)
+		controller := &ovfDiskItemType{
+			Address:         item.Address,
+			Description:     item.Description,
+			ElementName:     item.ElementName,
+			InstanceID:      item.InstanceID,
+			ResourceSubType: item.ResourceSubType,
+			ResourceType:    item.ResourceType,
+		}
+		controllers[address{item.ResourceType, bus, 0}] = controller
+		list.Item = append(list.Item, controller)
+		controllerSubTypes[item.ResourceType] = item.ResourceSubType
+	}
+
+	for _, item := range v.sections.VirtualHardwareSection.Item {
+		parent, ok := byInstance[item.Parent]
+		if item.ResourceType != 17 || !ok || !isDiskController(parent) {
+			continue
+		}
+		bus, _ := strconv.Atoi(parent.Address)
+		existing[address{parent.ResourceType, bus, item.AddressOnParent}] = item
+	}
+
+	for _, disk := range disks {
+		controller, ok := controllers[address{disk.BusType, disk.BusNumber, 0}]
+		if !ok {
+			subType := controllerSubTypes[disk.BusType]
+			name := "SCSI Controller"
+			switch disk.BusType {
+			case diskBusSCSI:
+				if subType == "" {
+					subType = "lsilogic"
+				}
+			case diskBusIDE:
+				name = "IDE Controller"
+			case diskBusSATA:
+				name = "SATA Controller"
+				subType = "vmware.sata.ahci"
+			}
+			controller = &ovfDiskItemType{
+				Address:         strconv.Itoa(disk.BusNumber),
+				Description:     name,
+				ElementName:     fmt.Sprintf("%s %d", name, disk.BusNumber),
+				InstanceID:      nextID,
+				ResourceSubType: subType,
+				ResourceType:    disk.BusType,
+			}
+			nextID++
+			controllers[address{disk.BusType, disk.BusNumber, 0}] = controller
+			list.Item = append(list.Item, controller)
+		}
+
+		busSubType := controller.ResourceSubType
+		switch disk.BusType {
+		case diskBusIDE:
+			busSubType = "ide"
+		case diskBusSATA:
+			busSubType = "vmware.sata.ahci"
+		}
+
+		item := &ovfDiskItemType{
+			AddressOnParent: strconv.Itoa(disk.UnitNumber),
+			Description:     "Hard disk",
+			ElementName:     fmt.Sprintf("Hard disk %d:%d", disk.BusNumber, disk.UnitNumber),
+			HostResource: &ovfDiskHostResourceType{
+				BusSubType:        busSubType,
+				BusType:           disk.BusType,
+				Capacity:          disk.SizeMB,
+				StorageProfile:    disk.StorageProfileHREF,
+				OverrideVmDefault: disk.StorageProfileHREF != "",
+			},
+			Parent:       strconv.Itoa(controller.InstanceID),
+			ResourceType: 17,
+		}
+
+		placement := address{disk.BusType, disk.BusNumber, disk.UnitNumber}
+		if from := disk.MoveFrom; from != nil {
+			placement = address{from.BusType, from.BusNumber, from.UnitNumber}
+		}
+		if current, ok := existing[placement]; ok {
+			item.ElementName = current.ElementName
+			item.InstanceID = current.InstanceID
+		} else {
+			item.InstanceID = nextID
+			nextID++
+		}
+
+		list.Item = append(list.Item, item)
+	}
+
+	task, err := apiTask(v.c, "PUT", v.VM.HREF+"/virtualHardwareSection/disks", "application/vnd.vmware.vcloud.rasdItemsList+xml", list)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error changing VM disks: %s", err)
+	}
+	return task, nil
+}
+
+// NetworkConnections returns the NICs of the VM, as of its last refresh.
+func (v *vcdVM) NetworkConnections() *networkConnectionSectionType {
+	return v.sections.NetworkConnectionSection
+}
+
+// PrimaryNetworkConnection returns the primary NIC of the VM, or nil when the
+// VM has no NICs.
+func (v *vcdVM) PrimaryNetworkConnection() *networkConnectionType {
+	section := v.sections.NetworkConnectionSection
+	if section == nil {
+		return nil
+	}
+
+	for _, connection := range section.NetworkConnection {
+		if connection.NetworkConnectionIndex == section.PrimaryNetworkConnectionIndex {
+			return connection
+		}
+	}
+
+	return nil
+}
+
+// GuestCustomization returns the guest customization settings of the VM, as
+// of its last refresh.
+func (v *vcdVM) GuestCustomization() *types.GuestCustomizationSection {
+	return v.sections.GuestCustomizationSection
+}
+
+// CPUs returns the number of virtual CPUs of the VM and the number of cores
+// of each socket, which is 0 when vCD does not report it.
+func (v *vcdVM) CPUs() (count, coresPerSocket int) {
+	if v.VM.VirtualHardwareSection == nil {
+		return 0, 0
+	}
+
+	for _, item := range v.VM.VirtualHardwareSection.Item {
+		// ResourceType 3 is the processor
+		if item.ResourceType == 3 {
+			return item.VirtualQuantity, item.CoresPerSocket
+		}
+	}
+
+	return 0, 0
+}
+
+// Memory returns the memory size of the VM in MB, or 0 when vCD does not
+// report it.
+func (v *vcdVM) Memory() int {
+	if v.VM.VirtualHardwareSection == nil {
+		return 0
+	}
+
+	for _, item := range v.VM.VirtualHardwareSection.Item {
+		// ResourceType 4 is the memory, whose allocation unit is MB
+		if item.ResourceType == 4 {
+			return item.VirtualQuantity
+		}
+	}
+
+	return 0
+}
+
+// nestedHypervisorAction returns the name of the action which enables or
+// disables the nested hypervisor.
+func nestedHypervisorAction(enabled bool) string {
+	if enabled {
+		return "enableNestedHypervisor"
+	}
+	return "disableNestedHypervisor"
+}
+
+// CanChangeNestedHypervisor reports whether vCD currently offers to enable,
+// or disable, the exposure of hardware-assisted CPU virtualization to the
+// guest. vCD only links the action when the host supports it and the state
+// of the VM allows it.
+func (v *vcdVM) CanChangeNestedHypervisor(enabled bool) bool {
+	rel := nestedHypervisorAction(enabled)
+	return v.VM.Link.Find(func(l *types.Link) bool { return l != nil && l.Rel == rel }) != nil
+}
+
+// ChangeNestedHypervisor enables or disables the exposure of
+// hardware-assisted CPU virtualization to the guest operating system.
+func (v *vcdVM) ChangeNestedHypervisor(enabled bool) (govcd.Task, error) {
+	action := nestedHypervisorAction(enabled)
+
+	task, err := apiTask(v.c, "POST", v.VM.HREF+"/action/"+action, "", nil)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error performing %s on VM: %s", action, err)
+	}
+	return task, nil
+}
+
+// GetMetadata returns the metadata of the VM.
+func (v *vcdVM) GetMetadata() (*metadataType, error) {
+	return getMetadata(v.c, v.VM.HREF)
+}
+
+// AddMetadata sets the metadata key of the VM to the string value.
+func (v *vcdVM) AddMetadata(key, value string) (govcd.Task, error) {
+	return addMetadata(v.c, v.VM.HREF, key, metadataStringValue, value)
+}
+
+// DeleteMetadata removes the metadata key from the VM.
+func (v *vcdVM) DeleteMetadata(key string) (govcd.Task, error) {
+	return deleteMetadata(v.c, v.VM.HREF, key)
+}
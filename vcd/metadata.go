@@ -0,0 +1,136 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/ukcloud/govcloudair"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+// metadataEntity is implemented by the objects which carry metadata, such as
+// vApps and VMs.
+type metadataEntity interface {
+	GetMetadata() (*metadataType, error)
+	AddMetadata(key, value string) (govcd.Task, error)
+	DeleteMetadata(key string) (govcd.Task, error)
+}
+
+// updateMetadata brings the metadata of the entity in line with the metadata
+// argument, only touching the keys which differ from the live metadata. Each
+// change is given timeout seconds.
+func updateMetadata(c *govcd.Client, d *schema.ResourceData, entity metadataEntity, timeout int) error {
+	metadata, err := entity.GetMetadata()
+	if err != nil {
+		return fmt.Errorf("Error reading metadata: %#v", err)
+	}
+
+	live := flattenMetadata(metadata)
+	desired := d.Get("metadata").(map[string]interface{})
+
+	for k := range live {
+		if _, ok := desired[k]; ok {
+			continue
+		}
+
+		log.Printf("[TRACE] Removing metadata key %s", k)
+		err := retryCall(timeout, func() *resource.RetryError {
+			task, err := entity.DeleteMetadata(k)
+			if err != nil {
+				return resource.RetryableError(fmt.Errorf("Error deleting metadata: %#v", err))
+			}
+			return resource.RetryableError(waitTask(c, task, timeout))
+		})
+		if err != nil {
+			return fmt.Errorf("Error completing tasks: %#v", err)
+		}
+	}
+
+	for k, v := range desired {
+		if value, ok := live[k]; ok && value == v.(string) {
+			continue
+		}
+
+		log.Printf("[TRACE] Setting metadata key %s", k)
+		err := retryCall(timeout, func() *resource.RetryError {
+			task, err := entity.AddMetadata(k, v.(string))
+			if err != nil {
+				return resource.RetryableError(fmt.Errorf("Error adding metadata: %#v", err))
+			}
+			return resource.RetryableError(waitTask(c, task, timeout))
+		})
+		if err != nil {
+			return fmt.Errorf("Error completing tasks: %#v", err)
+		}
+	}
+
+	return nil
+}
+
+// readMetadata sets the metadata argument from the live metadata of the
+// entity, so keys added outside of Terraform show up as drift.
+func readMetadata(d *schema.ResourceData, entity metadataEntity) error {
+	metadata, err := entity.GetMetadata()
+	if err != nil {
+		return fmt.Errorf("Error reading metadata: %#v", err)
+	}
+
+	return d.Set("metadata", flattenMetadata(metadata))
+}
+
+func flattenMetadata(metadata *metadataType) map[string]string {
+	values := make(map[string]string)
+	for _, entry := range metadata.MetadataEntry {
+		if entry.TypedValue != nil {
+			values[entry.Key] = entry.TypedValue.Value
+		}
+	}
+	return values
+}
+
+// getMetadata retrieves the metadata of the entity at href.
+func getMetadata(c *govcd.Client, href string) (*metadataType, error) {
+	resp, err := apiRequest(c, "GET", href+"/metadata", "", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving metadata: %s", err)
+	}
+
+	metadata := &metadataType{}
+
+	if err = decodeBody(resp, metadata); err != nil {
+		return nil, fmt.Errorf("error decoding metadata response: %s", err)
+	}
+
+	// The request was successful
+	return metadata, nil
+}
+
+// addMetadata sets the metadata key of the entity at href. typedValue is one
+// of the metadata*Value types and value its string representation.
+func addMetadata(c *govcd.Client, href, key, typedValue, value string) (govcd.Task, error) {
+	newmetadata := &types.MetadataValue{
+		Xmlns: "http://www.vmware.com/vcloud/v1.5",
+		Xsi:   "http://www.w3.org/2001/XMLSchema-instance",
+		TypedValue: &types.TypedValue{
+			XsiType: typedValue,
+			Value:   value,
+		},
+	}
+
+	task, err := apiTask(c, "PUT", href+"/metadata/"+key, "application/vnd.vmware.vcloud.metadata.value+xml", newmetadata)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error adding metadata: %s", err)
+	}
+	return task, nil
+}
+
+// deleteMetadata removes the metadata key from the entity at href.
+func deleteMetadata(c *govcd.Client, href, key string) (govcd.Task, error) {
+	task, err := apiTask(c, "DELETE", href+"/metadata/"+key, "", nil)
+	if err != nil {
+		return govcd.Task{}, fmt.Errorf("error deleting metadata: %s", err)
+	}
+	return task, nil
+}
@@ -0,0 +1,53 @@
+package vcd
+
+import (
+	"log"
+	"sync"
+)
+
+// mutexKV is a simple key/value store of mutexes, used to serialize
+// operations on a single object, such as an edge gateway, while operations
+// on other objects proceed in parallel.
+type mutexKV struct {
+	lock  sync.Mutex
+	store map[string]*sync.Mutex
+}
+
+func newMutexKV() *mutexKV {
+	return &mutexKV{
+		store: make(map[string]*sync.Mutex),
+	}
+}
+
+// Lock locks the mutex for the given key, creating it if needed.
+func (m *mutexKV) Lock(key string) {
+	log.Printf("[DEBUG] Locking %q", key)
+	m.get(key).Lock()
+	log.Printf("[DEBUG] Locked %q", key)
+}
+
+// Unlock unlocks the mutex for the given key.
+func (m *mutexKV) Unlock(key string) {
+	log.Printf("[DEBUG] Unlocking %q", key)
+	m.get(key).Unlock()
+	log.Printf("[DEBUG] Unlocked %q", key)
+}
+
+func (m *mutexKV) get(key string) *sync.Mutex {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	mutex, ok := m.store[key]
+	if !ok {
+		mutex = &sync.Mutex{}
+		m.store[key] = mutex
+	}
+	return mutex
+}
+
+// edgeGatewayMutexKV serializes the configuration calls made on each edge
+// gateway.
+var edgeGatewayMutexKV = newMutexKV()
+
+// vAppMutexKV serializes the recompose and network configuration calls made
+// on each vApp.
+var vAppMutexKV = newMutexKV()
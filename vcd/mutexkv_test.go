@@ -0,0 +1,45 @@
+package vcd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMutexKV(t *testing.T) {
+	m := newMutexKV()
+
+	m.Lock("edge1")
+
+	// Other keys are not blocked
+	done := make(chan struct{})
+	go func() {
+		m.Lock("edge2")
+		m.Unlock("edge2")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Locking a different key blocked")
+	}
+
+	// The same key is blocked until it is unlocked
+	locked := make(chan struct{})
+	go func() {
+		m.Lock("edge1")
+		close(locked)
+		m.Unlock("edge1")
+	}()
+	select {
+	case <-locked:
+		t.Fatal("Locking the same key did not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	m.Unlock("edge1")
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("Unlocking did not release the key")
+	}
+}
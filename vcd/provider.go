@@ -1,6 +1,9 @@
 package vcd
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 )
@@ -63,16 +66,86 @@ func Provider() terraform.ResourceProvider {
 				DefaultFunc: schema.EnvDefaultFunc("VCD_ALLOW_UNVERIFIED_SSL", false),
 				Description: "If set, VCDClient will permit unverifiable SSL certificates.",
 			},
+
+			"skip_tls_verify_hosts": &schema.Schema{
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Host names for which SSL certificate verification is skipped, while it is enforced for any other host.",
+			},
+
+			"ca_cert_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("VCD_CA_CERT_FILE", ""),
+				Description: "Path to a PEM file of CA certificates to trust, in addition to the system ones, when verifying the vcd certificate.",
+			},
+
+			"http_timeout": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("VCD_HTTP_TIMEOUT", 0),
+				Description: "Max num seconds to wait for vcd to accept a connection and to start answering a request. 0 waits indefinitely",
+			},
+
+			"default_create_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateTimeout,
+				Description:  "Default duration to wait for resources to be created (e.g. \"10m\"), unless set in their timeouts block. Defaults to max_retry_timeout",
+			},
+
+			"default_read_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateTimeout,
+				Description:  "Default duration to wait for resources to be read (e.g. \"5m\"), unless set in their timeouts block. Defaults to max_retry_timeout",
+			},
+
+			"default_update_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateTimeout,
+				Description:  "Default duration to wait for resources to be updated (e.g. \"10m\"), unless set in their timeouts block. Defaults to max_retry_timeout",
+			},
+
+			"default_delete_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateTimeout,
+				Description:  "Default duration to wait for resources to be deleted (e.g. \"10m\"), unless set in their timeouts block. Defaults to max_retry_timeout",
+			},
+		},
+
+		DataSourcesMap: map[string]*schema.Resource{
+			"vcd_catalog_item":     dataSourceVcdCatalogItem(),
+			"vcd_external_network": dataSourceVcdExternalNetwork(),
+			"vcd_org_catalogs":     dataSourceVcdOrgCatalogs(),
+			"vcd_storage_profile":  dataSourceVcdStorageProfile(),
+			"vcd_vapp_vm":          dataSourceVcdVAppVm(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"vcd_network":         resourceVcdNetwork(),
-			"vcd_vapp":            resourceVcdVApp(),
-			"vcd_firewall_rules":  resourceVcdFirewallRules(),
-			"vcd_dnat":            resourceVcdDNAT(),
-			"vcd_snat":            resourceVcdSNAT(),
-			"vcd_edgegateway_vpn": resourceVcdEdgeGatewayVpn(),
-			"vcd_vapp_vm":         resourceVcdVAppVm(),
+			"vcd_network":                  resourceVcdNetwork(),
+			"vcd_vapp":                     resourceVcdVApp(),
+			"vcd_firewall_rules":           resourceVcdFirewallRules(),
+			"vcd_dnat":                     resourceVcdDNAT(),
+			"vcd_snat":                     resourceVcdSNAT(),
+			"vcd_edgegateway_vpn":          resourceVcdEdgeGatewayVpn(),
+			"vcd_vapp_vm":                  resourceVcdVAppVm(),
+			"vcd_catalog":                  resourceVcdCatalog(),
+			"vcd_catalog_item":             resourceVcdCatalogItem(),
+			"vcd_org":                      resourceVcdOrg(),
+			"vcd_org_vdc":                  resourceVcdOrgVdc(),
+			"vcd_edgegateway_static_route": resourceVcdEdgeGatewayStaticRoute(),
+			"vcd_edgegateway_dhcp":         resourceVcdEdgeGatewayDhcp(),
+			"vcd_independent_disk":         resourceVcdIndependentDisk(),
+			"vcd_inserted_media":           resourceVcdInsertedMedia(),
+			"vcd_vapp_network":             resourceVcdVAppNetwork(),
+			"vcd_lb_server_pool":           resourceVcdLbServerPool(),
+			"vcd_lb_virtual_server":        resourceVcdLbVirtualServer(),
+			"vcd_lb_service_monitor":       resourceVcdLbServiceMonitor(),
+			"vcd_lb_app_profile":           resourceVcdLbAppProfile(),
 		},
 
 		ConfigureFunc: providerConfigure,
@@ -87,6 +160,20 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		maxRetryTimeout = v.(int)
 	}
 
+	defaultTimeouts := make(map[string]time.Duration)
+	for _, key := range []string{schema.TimeoutCreate, schema.TimeoutRead, schema.TimeoutUpdate, schema.TimeoutDelete} {
+		if v, ok := d.GetOk("default_" + key + "_timeout"); ok {
+			// Already checked by validateTimeout
+			timeout, _ := time.ParseDuration(v.(string))
+			defaultTimeouts[key] = timeout
+		}
+	}
+
+	var skipTLSVerifyHosts []string
+	for _, host := range d.Get("skip_tls_verify_hosts").([]interface{}) {
+		skipTLSVerifyHosts = append(skipTLSVerifyHosts, host.(string))
+	}
+
 	config := Config{
 		User:            d.Get("user").(string),
 		Password:        d.Get("password").(string),
@@ -95,7 +182,25 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		VDC:             d.Get("vdc").(string),
 		MaxRetryTimeout: maxRetryTimeout,
 		InsecureFlag:    d.Get("allow_unverified_ssl").(bool),
+		CACertFile:      d.Get("ca_cert_file").(string),
+		HTTPTimeout:     d.Get("http_timeout").(int),
+		DefaultTimeouts: defaultTimeouts,
+
+		SkipTLSVerifyHosts: skipTLSVerifyHosts,
 	}
 
 	return config.Client()
 }
+
+func validateTimeout(v interface{}, k string) (ws []string, errors []error) {
+	timeout, err := time.ParseDuration(v.(string))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a duration such as \"30s\" or \"10m\": %s", k, err))
+		return
+	}
+	// Timeouts are counted in whole seconds
+	if timeout < time.Second {
+		errors = append(errors, fmt.Errorf("%q must be at least 1s, got %q", k, v.(string)))
+	}
+	return
+}
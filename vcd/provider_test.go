@@ -48,3 +48,19 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal("VCD_VDC must be set for acceptance tests")
 	}
 }
+
+func TestValidateTimeout(t *testing.T) {
+	validTimeouts := []string{"1s", "30s", "10m", "1h30m"}
+	for _, v := range validTimeouts {
+		if _, errors := validateTimeout(v, "default_create_timeout"); len(errors) != 0 {
+			t.Fatalf("%q should be a valid timeout: %q", v, errors)
+		}
+	}
+
+	invalidTimeouts := []string{"", "10", "ten minutes", "0s", "500ms", "-5m"}
+	for _, v := range invalidTimeouts {
+		if _, errors := validateTimeout(v, "default_create_timeout"); len(errors) == 0 {
+			t.Fatalf("%q should be an invalid timeout", v)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package vcd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Setting VCD_TEST_REPLAY=record runs the acceptance tests against a live vCD
+// and stores every API interaction in a cassette file, VCD_TEST_REPLAY=replay
+// serves the stored interactions back without any network access. The
+// cassette defaults to test-fixtures/vcd_cassette.json and can be changed
+// with VCD_TEST_CASSETTE.
+const (
+	testReplayRecord = "record"
+	testReplayReplay = "replay"
+)
+
+// Headers carrying credentials or session tokens are never written to a
+// cassette.
+var cassetteScrubbedHeaders = []string{"Authorization", "X-Vcloud-Authorization"}
+
+// Elements carrying passwords or personal data of users are blanked in the
+// request and response bodies, as is the user a session belongs to. Request
+// bodies are scrubbed the same way before replay compares them.
+var cassetteScrubbedBody = regexp.MustCompile(
+	`(<(?:\w+:)?(?:AdminPassword|Password|FullName|EmailAddress|Telephone)>)[^<]*(<)` +
+		`|(<(?:\w+:)?Session\b[^>]*\suser=")[^"]*(")`)
+
+// scrubCassetteBody blanks the secrets of a request or response body.
+func scrubCassetteBody(body string) string {
+	return cassetteScrubbedBody.ReplaceAllString(body, "${1}${3}REDACTED${2}${4}")
+}
+
+func init() {
+	mode := os.Getenv("VCD_TEST_REPLAY")
+	if mode == "" {
+		return
+	}
+
+	path := os.Getenv("VCD_TEST_CASSETTE")
+	if path == "" {
+		path = filepath.Join("test-fixtures", "vcd_cassette.json")
+	}
+
+	c, err := newCassette(path, mode)
+	if err != nil {
+		log.Fatalf("[ERROR] Unable to use cassette %s: %s", path, err)
+	}
+	wrapTransport = c.wrap
+}
+
+type cassetteInteraction struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	RequestBody string      `json:"request_body,omitempty"`
+	StatusCode  int         `json:"status_code"`
+	Header      http.Header `json:"header"`
+	Body        string      `json:"body"`
+}
+
+type cassette struct {
+	path         string
+	mode         string
+	mu           sync.Mutex
+	interactions []*cassetteInteraction
+	played       []bool
+}
+
+func newCassette(path, mode string) (*cassette, error) {
+	c := &cassette{path: path, mode: mode}
+
+	switch mode {
+	case testReplayRecord:
+		return c, nil
+	case testReplayReplay:
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &c.interactions); err != nil {
+			return nil, err
+		}
+		c.played = make([]bool, len(c.interactions))
+		return c, nil
+	}
+
+	return nil, fmt.Errorf("VCD_TEST_REPLAY must be %q or %q, got %q", testReplayRecord, testReplayReplay, mode)
+}
+
+func (c *cassette) wrap(transport http.RoundTripper) http.RoundTripper {
+	return &cassetteTransport{cassette: c, transport: transport}
+}
+
+type cassetteTransport struct {
+	cassette  *cassette
+	transport http.RoundTripper
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		requestBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	if t.cassette.mode == testReplayReplay {
+		return t.cassette.replay(req, string(requestBody))
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := http.Header{}
+	for k, v := range resp.Header {
+		header[k] = v
+	}
+	for _, k := range cassetteScrubbedHeaders {
+		if header.Get(k) != "" {
+			header.Set(k, "REDACTED")
+		}
+	}
+
+	err = t.cassette.record(&cassetteInteraction{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		RequestBody: scrubCassetteBody(string(requestBody)),
+		StatusCode:  resp.StatusCode,
+		Header:      header,
+		Body:        scrubCassetteBody(string(body)),
+	})
+
+	return resp, err
+}
+
+// record appends an interaction and rewrites the cassette, so that it stays
+// usable even if the test run is interrupted.
+func (c *cassette) record(i *cassetteInteraction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, i)
+
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, data, 0644)
+}
+
+// replay returns the first interaction not played yet which matches the
+// method, URL and scrubbed body of the request.
+func (c *cassette) replay(req *http.Request, requestBody string) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	requestBody = scrubCassetteBody(requestBody)
+
+	for idx, i := range c.interactions {
+		if c.played[idx] ||
+			i.Method != req.Method ||
+			i.URL != req.URL.String() ||
+			i.RequestBody != requestBody {
+			continue
+		}
+		c.played[idx] = true
+
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", i.StatusCode, http.StatusText(i.StatusCode)),
+			StatusCode: i.StatusCode,
+			Header:     i.Header,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(i.Body)),
+			Request:    req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("No recorded interaction left for %s %s in %s", req.Method, req.URL, c.path)
+}
+
+func TestScrubCassetteBody(t *testing.T) {
+	cases := map[string]string{
+		`<vcloud:AdminPassword>secret</vcloud:AdminPassword>`:    `<vcloud:AdminPassword>REDACTED</vcloud:AdminPassword>`,
+		`<User><FullName>Jane</FullName><Password/></User>`:      `<User><FullName>REDACTED</FullName><Password/></User>`,
+		`<Session xmlns="x" user="admin" org="System">`:          `<Session xmlns="x" user="REDACTED" org="System">`,
+		`<Org name="test"><Description>kept</Description></Org>`: `<Org name="test"><Description>kept</Description></Org>`,
+	}
+	for body, expected := range cases {
+		if actual := scrubCassetteBody(body); actual != expected {
+			t.Errorf("expected %s, got %s", expected, actual)
+		}
+	}
+}
+
+func TestCassetteRecordReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-vcloud-authorization", "secret-token")
+		w.Write([]byte("<Org name=\"test\"><AdminPassword>secret-password</AdminPassword></Org>"))
+	}))
+
+	dir, err := ioutil.TempDir("", "vcd-cassette")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	recorder, err := newCassette(path, testReplayRecord)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	client := &http.Client{Transport: recorder.wrap(http.DefaultTransport)}
+	resp, err := client.Post(server.URL+"/api/org", "application/xml", strings.NewReader("<User><Password>user-password</Password></User>"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.Header.Get("x-vcloud-authorization") != "secret-token" || !strings.Contains(string(body), "secret-password") {
+		t.Fatalf("recording must not alter the live response")
+	}
+	server.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, secret := range []string{"secret-token", "secret-password", "user-password"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("%s was not scrubbed from cassette: %s", secret, data)
+		}
+	}
+
+	player, err := newCassette(path, testReplayReplay)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	client = &http.Client{Transport: player.wrap(nil)}
+	// The request is matched whatever password it carries
+	resp, err = client.Post(server.URL+"/api/org", "application/xml", strings.NewReader("<User><Password>other-password</Password></User>"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "<Org name=\"test\"><AdminPassword>REDACTED</AdminPassword></Org>" {
+		t.Fatalf("unexpected replayed body: %s", body)
+	}
+
+	if _, err := client.Post(server.URL+"/api/org", "application/xml", strings.NewReader("<User><Password>user-password</Password></User>")); err == nil {
+		t.Fatalf("each interaction should only be replayed once")
+	}
+}
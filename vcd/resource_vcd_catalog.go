@@ -0,0 +1,192 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceVcdCatalog() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdCatalogCreate,
+		Read:   resourceVcdCatalogRead,
+		Update: resourceVcdCatalogUpdate,
+		Delete: resourceVcdCatalogDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdCatalogImport,
+		},
+
+		Timeouts: resourceTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"delete_recursive": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Remove the catalog items along with the catalog",
+			},
+
+			"delete_force": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Remove the catalog even if its items are in use",
+			},
+
+			"href": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceVcdCatalogCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	name := d.Get("name").(string)
+
+	// The POST may have created the catalog even when it failed, so each
+	// attempt first checks whether it exists
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutCreate)
+	err := retryCall(timeout, func() *resource.RetryError {
+		org, err := vcdClient.getOrg()
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error refreshing org: %#v", err))
+		}
+		if catalog, err := org.FindCatalog(name); err == nil && catalog.Catalog != nil {
+			d.SetId(catalog.Catalog.HREF)
+			return nil
+		}
+
+		catalog, err := vcdClient.createCatalog(name, d.Get("description").(string), timeout)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error creating catalog: %#v", err))
+		}
+		// The ID is the href of the catalog in the org, not its admin view
+		d.SetId(strings.Replace(catalog.HREF, "/api/admin/catalog/", "/api/catalog/", 1))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdCatalogRead(d, meta)
+}
+
+func resourceVcdCatalogRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	org, err := vcdClient.getOrg()
+	if err != nil {
+		return fmt.Errorf("Error refreshing org: %#v", err)
+	}
+
+	var name string
+	for _, link := range org.Org.Link {
+		if link.Rel == "down" && link.Type == "application/vnd.vmware.vcloud.catalog+xml" && link.HREF == d.Id() {
+			name = link.Name
+		}
+	}
+
+	if name == "" {
+		log.Printf("[DEBUG] Catalog no longer exists. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	catalog, err := org.FindCatalog(name)
+	if err != nil {
+		return fmt.Errorf("Error reading catalog: %#v", err)
+	}
+
+	d.Set("name", catalog.Catalog.Name)
+	d.Set("description", catalog.Catalog.Description)
+	d.Set("href", catalog.Catalog.HREF)
+
+	return nil
+}
+
+// Only the delete flags can change in place, and they are only used on delete
+func resourceVcdCatalogUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceVcdCatalogRead(d, meta)
+}
+
+func resourceVcdCatalogDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	catalog, err := vcdClient.findCatalog(d.Get("name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding catalog: %#v", err)
+	}
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+	return retryCall(timeout, func() *resource.RetryError {
+		err := vcdClient.deleteCatalog(catalog, d.Get("delete_force").(bool), d.Get("delete_recursive").(bool), timeout)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error deleting catalog: %#v", err))
+		}
+		return nil
+	})
+}
+
+func resourceVcdCatalogImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	vcdClient := meta.(*VCDClient)
+
+	parts, err := splitImportID(vcdClient, d.Id(), 2, "org.catalog")
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := vcdClient.findCatalog(parts[1])
+	if err != nil || catalog.Catalog == nil {
+		return nil, fmt.Errorf("Unable to find catalog %s in org %s", parts[1], parts[0])
+	}
+
+	d.SetId(catalog.Catalog.HREF)
+	d.Set("name", catalog.Catalog.Name)
+	d.Set("delete_recursive", false)
+	d.Set("delete_force", false)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// splitImportID splits an import ID of the given format, such as
+// org.catalog, into its n dot separated parts. The last part keeps any dot
+// it contains. The org must be the one the provider is configured with.
+func splitImportID(vcdClient *VCDClient, id string, n int, format string) ([]string, error) {
+	parts := strings.SplitN(id, ".", n)
+	if len(parts) != n {
+		return nil, fmt.Errorf("Import ID %s must be of the form %s", id, format)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("Import ID %s must be of the form %s", id, format)
+		}
+	}
+
+	if parts[0] != vcdClient.Org.Org.Name {
+		return nil, fmt.Errorf("Unable to import from org %s, the provider is configured for org %s", parts[0], vcdClient.Org.Org.Name)
+	}
+
+	return parts, nil
+}
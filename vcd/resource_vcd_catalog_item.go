@@ -0,0 +1,257 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+func resourceVcdCatalogItem() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdCatalogItemCreate,
+		Read:   resourceVcdCatalogItemRead,
+		Update: resourceVcdCatalogItemUpdate,
+		Delete: resourceVcdCatalogItemDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdCatalogItemImport,
+		},
+
+		Timeouts: catalogItemTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"catalog": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"ova_path": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+				// The OVA of an imported item is not known, which must not
+				// upload it again
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return old == "" && d.Id() != ""
+				},
+			},
+
+			"storage_profile": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "VDC storage profile to store the uploaded template on. Defaults to the one of the catalog",
+			},
+
+			"upload_piece_size": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     1,
+				Description: "Size in MB of the pieces the files of the OVA are uploaded in",
+			},
+
+			"href": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// catalogItemTimeouts gives the import of the template an hour by default,
+// as large OVAs take longer than most vCD operations. The other operations
+// use the provider defaults like any resource.
+func catalogItemTimeouts() *schema.ResourceTimeout {
+	timeouts := resourceTimeouts()
+	timeouts.Create = schema.DefaultTimeout(60 * time.Minute)
+	return timeouts
+}
+
+func resourceVcdCatalogItemCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	catalogName := d.Get("catalog").(string)
+	name := d.Get("name").(string)
+
+	catalog, err := vcdClient.findCatalog(catalogName)
+	if err != nil {
+		return fmt.Errorf("Error finding catalog: %#v", err)
+	}
+
+	var storageProfile *types.Reference
+	if profileName := d.Get("storage_profile").(string); profileName != "" {
+		storageProfile, err = uploadStorageProfile(vcdClient, profileName, d.Get("ova_path").(string))
+		if err != nil {
+			return err
+		}
+	}
+
+	progress := func(fileName string, transferred, size int64) {
+		log.Printf("[INFO] Uploading %s to catalog item %s: %d of %d bytes", fileName, name, transferred, size)
+	}
+
+	timeout := int(d.Timeout(schema.TimeoutCreate).Seconds())
+
+	item, task, err := vcdClient.uploadOvf(catalog, d.Get("ova_path").(string), name, d.Get("description").(string), storageProfile,
+		int64(d.Get("upload_piece_size").(int))*1024*1024, timeout, progress)
+	// A failed upload leaves the catalog item behind, record it so that it
+	// is deleted instead of blocking the next attempt
+	if item.CatalogItem != nil && item.CatalogItem.HREF != "" {
+		d.SetId(item.CatalogItem.HREF)
+	}
+	if err != nil {
+		return fmt.Errorf("Error uploading OVA: %#v", err)
+	}
+
+	// The upload itself can take long, so vCD gets the whole timeout to
+	// import the template once it is done
+	err = retryCall(timeout, func() *resource.RetryError {
+		if err := task.Refresh(); err != nil {
+			return resource.NonRetryableError(fmt.Errorf("Error refreshing import task: %#v", err))
+		}
+
+		switch task.Task.Status {
+		case "queued", "preRunning", "running":
+			log.Printf("[INFO] Importing catalog item %s: %d%%", name, task.Task.Progress)
+			return resource.RetryableError(fmt.Errorf("Import of catalog item %s is still %s", name, task.Task.Status))
+		case "error":
+			return resource.NonRetryableError(fmt.Errorf("Error importing catalog item %s: %s", name, task.Task.Description))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdCatalogItemRead(d, meta)
+}
+
+func resourceVcdCatalogItemRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	catalog, err := vcdClient.findCatalog(d.Get("catalog").(string))
+	if err != nil {
+		log.Printf("[DEBUG] Catalog no longer exists. Removing catalog item from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	item, err := catalog.FindCatalogItem(d.Get("name").(string))
+	if err != nil {
+		log.Printf("[DEBUG] Catalog item no longer exists. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", item.CatalogItem.Name)
+	d.Set("description", item.CatalogItem.Description)
+	d.Set("href", item.CatalogItem.HREF)
+
+	template, err := item.GetVAppTemplate()
+	if err != nil {
+		return fmt.Errorf("Error reading vApp template: %#v", err)
+	}
+	// Older vCD versions do not report the storage profile of templates
+	if template.VAppTemplate.DefaultStorageProfile != "" {
+		d.Set("storage_profile", template.VAppTemplate.DefaultStorageProfile)
+	}
+
+	return nil
+}
+
+// uploadStorageProfile returns a reference to the named storage profile of
+// the provider VDC, checking that it is enabled and has room for the OVA.
+// The imported template can take more than the OVA, whose disks are
+// compressed, so vCD may still run out of space during the import.
+func uploadStorageProfile(vcdClient *VCDClient, name, ovaPath string) (*types.Reference, error) {
+	record, err := vcdClient.findStorageProfileRecord(vcdClient.OrgVdc, name)
+	if err != nil {
+		return nil, fmt.Errorf("Error finding storage profile: %#v", err)
+	}
+	if !record.IsEnabled {
+		return nil, fmt.Errorf("Storage profile %s is not enabled in VDC %s", name, record.VdcName)
+	}
+
+	info, err := os.Stat(ovaPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading OVA: %#v", err)
+	}
+	sizeMB := int(info.Size() / 1024 / 1024)
+	if record.StorageLimitMB > 0 && record.StorageUsedMB+sizeMB > record.StorageLimitMB {
+		return nil, fmt.Errorf("Storage profile %s has %d MB left, too little for the %d MB OVA",
+			name, record.StorageLimitMB-record.StorageUsedMB, sizeMB)
+	}
+
+	return &types.Reference{HREF: record.HREF, Name: record.Name}, nil
+}
+
+// Only upload_piece_size can change in place, and it is only used on create
+func resourceVcdCatalogItemUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceVcdCatalogItemRead(d, meta)
+}
+
+func resourceVcdCatalogItemDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	catalog, err := vcdClient.findCatalog(d.Get("catalog").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding catalog: %#v", err)
+	}
+
+	item, err := catalog.FindCatalogItem(d.Get("name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding catalog item: %#v", err)
+	}
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+	return retryCall(timeout, func() *resource.RetryError {
+		if err := vcdClient.deleteCatalogItem(item, timeout); err != nil {
+			return resource.RetryableError(fmt.Errorf("Error deleting catalog item: %#v", err))
+		}
+		return nil
+	})
+}
+
+func resourceVcdCatalogItemImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	vcdClient := meta.(*VCDClient)
+
+	parts, err := splitImportID(vcdClient, d.Id(), 3, "org.catalog.item")
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := vcdClient.findCatalog(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("Unable to find catalog %s in org %s", parts[1], parts[0])
+	}
+
+	item, err := catalog.FindCatalogItem(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("Unable to find catalog item %s in catalog %s", parts[2], parts[1])
+	}
+
+	d.SetId(item.CatalogItem.HREF)
+	d.Set("catalog", parts[1])
+	d.Set("name", item.CatalogItem.Name)
+	d.Set("upload_piece_size", 1)
+
+	return []*schema.ResourceData{d}, nil
+}
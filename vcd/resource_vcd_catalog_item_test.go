@@ -0,0 +1,120 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	govcd "github.com/ukcloud/govcloudair"
+)
+
+func TestAccVcdCatalogItem_Basic(t *testing.T) {
+	if v := os.Getenv("VCD_OVA_PATH"); v == "" {
+		t.Skip("Environment variable VCD_OVA_PATH must be set to run catalog item tests")
+		return
+	}
+
+	var item govcd.CatalogItem
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdCatalogItemDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdCatalogItem_basic, os.Getenv("VCD_OVA_PATH")),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdCatalogItemExists("vcd_catalog_item.fooitem", &item),
+					resource.TestCheckResourceAttr(
+						"vcd_catalog_item.fooitem", "name", "fooitem"),
+					resource.TestCheckResourceAttr(
+						"vcd_catalog_item.fooitem", "catalog", "fooitemcatalog"),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vcd_catalog_item.fooitem",
+				ImportState:       true,
+				ImportStateId:     os.Getenv("VCD_ORG") + ".fooitemcatalog.fooitem",
+				ImportStateVerify: true,
+				// Neither is stored in vCD, and an imported item is not
+				// uploaded again
+				ImportStateVerifyIgnore: []string{"ova_path", "upload_piece_size"},
+			},
+		},
+	})
+}
+
+func testAccCheckVcdCatalogItemExists(n string, item *govcd.CatalogItem) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No catalog item ID is set")
+		}
+
+		conn := testAccProvider.Meta().(*VCDClient)
+
+		catalog, err := conn.findCatalog(rs.Primary.Attributes["catalog"])
+		if err != nil {
+			return fmt.Errorf("Catalog does not exist.")
+		}
+
+		resp, err := catalog.FindCatalogItem(rs.Primary.Attributes["name"])
+		if err != nil {
+			return fmt.Errorf("Catalog item does not exist.")
+		}
+
+		*item = resp
+
+		return nil
+	}
+}
+
+func testAccCheckVcdCatalogItemDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_catalog_item" {
+			continue
+		}
+
+		catalog, err := conn.findCatalog(rs.Primary.Attributes["catalog"])
+		if err != nil {
+			// The catalog went away along with its items
+			return nil
+		}
+
+		_, err = catalog.FindCatalogItem(rs.Primary.Attributes["name"])
+
+		if err == nil {
+			return fmt.Errorf("Catalog item still exists.")
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+const testAccCheckVcdCatalogItem_basic = `
+resource "vcd_catalog" "fooitemcatalog" {
+	name = "fooitemcatalog"
+
+	delete_recursive = true
+	delete_force     = true
+}
+
+resource "vcd_catalog_item" "fooitem" {
+	catalog     = "${vcd_catalog.fooitemcatalog.name}"
+	name        = "fooitem"
+	description = "Terraform acceptance test template"
+	ova_path    = "%s"
+
+	upload_piece_size = 10
+}
+`
@@ -0,0 +1,111 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+	govcd "github.com/ukcloud/govcloudair"
+)
+
+func TestAccVcdCatalog_Basic(t *testing.T) {
+	var catalog govcd.Catalog
+	generatedHrefRegexp := regexp.MustCompile("^https://")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdCatalogDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdCatalog_basic, "foocatalog"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdCatalogExists("vcd_catalog.foocatalog", &catalog),
+					testAccCheckVcdCatalogAttributes(&catalog),
+					resource.TestCheckResourceAttr(
+						"vcd_catalog.foocatalog", "name", "foocatalog"),
+					resource.TestCheckResourceAttr(
+						"vcd_catalog.foocatalog", "description", "Terraform acceptance test catalog"),
+					resource.TestMatchResourceAttr(
+						"vcd_catalog.foocatalog", "href", generatedHrefRegexp),
+				),
+			},
+			resource.TestStep{
+				ResourceName:      "vcd_catalog.foocatalog",
+				ImportState:       true,
+				ImportStateId:     os.Getenv("VCD_ORG") + ".foocatalog",
+				ImportStateVerify: true,
+				// The delete flags are not stored in vCD
+				ImportStateVerifyIgnore: []string{"delete_recursive", "delete_force"},
+			},
+		},
+	})
+}
+
+func testAccCheckVcdCatalogExists(n string, catalog *govcd.Catalog) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No catalog ID is set")
+		}
+
+		conn := testAccProvider.Meta().(*VCDClient)
+
+		resp, err := conn.findCatalog(rs.Primary.Attributes["name"])
+		if err != nil {
+			return fmt.Errorf("Catalog does not exist.")
+		}
+
+		*catalog = resp
+
+		return nil
+	}
+}
+
+func testAccCheckVcdCatalogDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_catalog" {
+			continue
+		}
+
+		_, err := conn.findCatalog(rs.Primary.Attributes["name"])
+
+		if err == nil {
+			return fmt.Errorf("Catalog still exists.")
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+func testAccCheckVcdCatalogAttributes(catalog *govcd.Catalog) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+
+		if catalog.Catalog.Name != "foocatalog" {
+			return fmt.Errorf("Bad name: %s", catalog.Catalog.Name)
+		}
+
+		return nil
+	}
+}
+
+const testAccCheckVcdCatalog_basic = `
+resource "vcd_catalog" "foocatalog" {
+	name        = "%s"
+	description = "Terraform acceptance test catalog"
+
+	delete_recursive = true
+	delete_force     = true
+}
+`
@@ -13,6 +13,8 @@ func resourceVcdDNAT() *schema.Resource {
 		Delete: resourceVcdDNATDelete,
 		Read:   resourceVcdDNATRead,
 
+		Timeouts: resourceTimeouts(),
+
 		Schema: map[string]*schema.Schema{
 			"edge_gateway": &schema.Schema{
 				Type:     schema.TypeString,
@@ -27,13 +29,30 @@ func resourceVcdDNAT() *schema.Resource {
 			},
 
 			"port": &schema.Schema{
-				Type:     schema.TypeInt,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateNatPortRange,
 			},
 
 			"translated_port": &schema.Schema{
-				Type:     schema.TypeInt,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateNatPortRange,
+			},
+
+			"protocol": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "tcp",
+				ValidateFunc: validateNatProtocol,
+			},
+
+			"icmp_sub_type": &schema.Schema{
+				Type:     schema.TypeString,
 				Optional: true,
 				ForceNew: true,
 			},
@@ -49,18 +68,22 @@ func resourceVcdDNAT() *schema.Resource {
 
 func resourceVcdDNATCreate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
-	// Multiple VCD components need to run operations on the Edge Gateway, as
-	// the edge gatway will throw back an error if it is already performing an
-	// operation we must wait until we can aquire a lock on the client
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
-	portString := getPortString(d.Get("port").(int))
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	portString := d.Get("port").(string)
 	translatedPortString := portString // default
-	if d.Get("translated_port").(int) > 0 {
-		translatedPortString = getPortString(d.Get("translated_port").(int))
+	if v := d.Get("translated_port").(string); v != "" {
+		translatedPortString = v
+	}
+
+	if err := checkDNATRule(portString, translatedPortString, d.Get("protocol").(string), d.Get("icmp_sub_type").(string)); err != nil {
+		return err
 	}
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
 
 	if err != nil {
 		return fmt.Errorf("Unable to find edge gateway: %#v", err)
@@ -71,18 +94,21 @@ func resourceVcdDNATCreate(d *schema.ResourceData, meta interface{}) error {
 	// constrained by out lock. If the edge gateway reurns with a busy error, wait
 	// 3 seconds and then try again. Continue until a non-busy error or success
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := edgeGateway.AddNATPortMapping("DNAT",
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutCreate)
+	err = retryCall(timeout, func() *resource.RetryError {
+		task, err := edgeGateway.AddNATPortMappingWithProtocol("DNAT",
 			d.Get("external_ip").(string),
 			portString,
 			d.Get("internal_ip").(string),
-			translatedPortString)
+			translatedPortString,
+			d.Get("protocol").(string),
+			d.Get("icmp_sub_type").(string))
 		if err != nil {
 			return resource.RetryableError(
 				fmt.Errorf("Error setting DNAT rules: %#v", err))
 		}
 
-		return resource.RetryableError(task.WaitTaskCompletion())
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 	})
 
 	if err != nil {
@@ -95,63 +121,109 @@ func resourceVcdDNATCreate(d *schema.ResourceData, meta interface{}) error {
 
 func resourceVcdDNATRead(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
-	e, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	e, err := vcdClient.findEdgeGateway(d.Get("edge_gateway").(string))
 
 	if err != nil {
 		return fmt.Errorf("Unable to find edge gateway: %#v", err)
 	}
 
-	var found bool
-
-	for _, r := range e.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.NatService.NatRule {
-		if r.RuleType == "DNAT" &&
-			r.GatewayNatRule.OriginalIP == d.Get("external_ip").(string) &&
-			r.GatewayNatRule.OriginalPort == getPortString(d.Get("port").(int)) {
-			found = true
-			d.Set("internal_ip", r.GatewayNatRule.TranslatedIP)
-		}
+	portString := d.Get("port").(string)
+	translatedPortString := portString // default
+	if v := d.Get("translated_port").(string); v != "" {
+		translatedPortString = v
 	}
 
-	if !found {
+	// Rules for the same external port may translate other protocols, so
+	// the rule only exists when all of its settings match
+	rule := e.FindNATPortMapping(natPortMapping{
+		natType:      "DNAT",
+		externalIP:   d.Get("external_ip").(string),
+		externalPort: portString,
+		internalIP:   d.Get("internal_ip").(string),
+		internalPort: translatedPortString,
+		protocol:     d.Get("protocol").(string),
+		icmpSubType:  d.Get("icmp_sub_type").(string),
+	})
+	if rule == nil {
 		d.SetId("")
+		return nil
 	}
 
+	d.Set("translated_port", rule.GatewayNatRule.TranslatedPort)
+
 	return nil
 }
 
 func resourceVcdDNATDelete(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
-	// Multiple VCD components need to run operations on the Edge Gateway, as
-	// the edge gatway will throw back an error if it is already performing an
-	// operation we must wait until we can aquire a lock on the client
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
-	portString := getPortString(d.Get("port").(int))
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	portString := d.Get("port").(string)
 	translatedPortString := portString // default
-	if d.Get("translated_port").(int) > 0 {
-		translatedPortString = getPortString(d.Get("translated_port").(int))
+	if v := d.Get("translated_port").(string); v != "" {
+		translatedPortString = v
 	}
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
 
 	if err != nil {
 		return fmt.Errorf("Unable to find edge gateway: %#v", err)
 	}
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := edgeGateway.RemoveNATPortMapping("DNAT",
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+	err = retryCall(timeout, func() *resource.RetryError {
+		task, err := edgeGateway.RemoveNATPortMappingWithProtocol("DNAT",
 			d.Get("external_ip").(string),
 			portString,
 			d.Get("internal_ip").(string),
-			translatedPortString)
+			translatedPortString,
+			d.Get("protocol").(string),
+			d.Get("icmp_sub_type").(string))
 		if err != nil {
 			return resource.RetryableError(
 				fmt.Errorf("Error setting DNAT rules: %#v", err))
 		}
 
-		return resource.RetryableError(task.WaitTaskCompletion())
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 	})
 	if err != nil {
 		return fmt.Errorf("Error completing tasks: %#v", err)
 	}
 	return nil
 }
+
+// checkDNATRule verifies the settings which depend on each other: a port
+// range must be translated to a range of the same size, and an ICMP subtype
+// needs the icmp protocol.
+func checkDNATRule(port, translatedPort, protocol, icmpSubType string) error {
+	first, last, _ := parseNatPortRange(port)
+	translatedFirst, translatedLast, _ := parseNatPortRange(translatedPort)
+	if last-first != translatedLast-translatedFirst {
+		return fmt.Errorf("port %s and translated_port %s must cover the same number of ports", port, translatedPort)
+	}
+
+	if icmpSubType != "" && protocol != "icmp" {
+		return fmt.Errorf("icmp_sub_type can only be set with the icmp protocol, not %s", protocol)
+	}
+
+	return nil
+}
+
+func validateNatPortRange(v interface{}, k string) (ws []string, errors []error) {
+	if _, _, err := parseNatPortRange(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q %s", k, err))
+	}
+	return
+}
+
+func validateNatProtocol(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	switch value {
+	case "tcp", "udp", "tcpudp", "icmp", "any":
+	default:
+		errors = append(errors, fmt.Errorf("%q must be one of tcp, udp, tcpudp, icmp or any, got %q", k, value))
+	}
+	return
+}
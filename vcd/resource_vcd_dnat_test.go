@@ -3,6 +3,7 @@ package vcd
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
@@ -70,6 +71,36 @@ func TestAccVcdDNAT_tlate(t *testing.T) {
 	})
 }
 
+func TestAccVcdDNAT_range(t *testing.T) {
+	if v := os.Getenv("VCD_EXTERNAL_IP"); v == "" {
+		t.Skip("Environment variable VCD_EXTERNAL_IP must be set to run DNAT tests")
+		return
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdDNATDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckVcdDnat_range, os.Getenv("VCD_EDGE_GATEWAY"), os.Getenv("VCD_EXTERNAL_IP"), "8000-8100", "9000-9050"),
+				ExpectError: regexp.MustCompile("must cover the same number of ports"),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdDnat_range, os.Getenv("VCD_EDGE_GATEWAY"), os.Getenv("VCD_EXTERNAL_IP"), "8000-8100", "9000-9100"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_dnat.bar", "port", "8000-8100"),
+					resource.TestCheckResourceAttr(
+						"vcd_dnat.bar", "translated_port", "9000-9100"),
+					resource.TestCheckResourceAttr(
+						"vcd_dnat.bar", "protocol", "udp"),
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckVcdDNATExists(n string, gateway *govcd.EdgeGateway) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -199,3 +230,14 @@ resource "vcd_dnat" "bar" {
 	translated_port = 77
 }
 `
+
+const testAccCheckVcdDnat_range = `
+resource "vcd_dnat" "bar" {
+	edge_gateway = "%s"
+	external_ip = "%s"
+	port = "%s"
+	internal_ip = "10.10.102.60"
+	translated_port = "%s"
+	protocol = "udp"
+}
+`
@@ -0,0 +1,276 @@
+package vcd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/ukcloud/govcloudair"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+func resourceVcdEdgeGatewayDhcp() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdEdgeGatewayDhcpCreate,
+		Read:   resourceVcdEdgeGatewayDhcpRead,
+		Update: resourceVcdEdgeGatewayDhcpUpdate,
+		Delete: resourceVcdEdgeGatewayDhcpDelete,
+
+		Timeouts: resourceTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"edge_gateway": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"network_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"pool": &schema.Schema{
+				Type:     schema.TypeSet,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"start_address": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"end_address": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"default_lease": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  3600,
+						},
+
+						"max_lease": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  7200,
+						},
+					},
+				},
+			},
+
+			"dns1": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"dns2": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceVcdEdgeGatewayDhcpCreate(d *schema.ResourceData, meta interface{}) error {
+	if err := setEdgeGatewayDhcpPools(d, meta, schema.TimeoutCreate); err != nil {
+		return err
+	}
+
+	d.SetId(d.Get("edge_gateway").(string) + ":" + d.Get("network_name").(string))
+
+	return resourceVcdEdgeGatewayDhcpRead(d, meta)
+}
+
+func resourceVcdEdgeGatewayDhcpUpdate(d *schema.ResourceData, meta interface{}) error {
+	if err := setEdgeGatewayDhcpPools(d, meta, schema.TimeoutUpdate); err != nil {
+		return err
+	}
+
+	return resourceVcdEdgeGatewayDhcpRead(d, meta)
+}
+
+func resourceVcdEdgeGatewayDhcpRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	network, err := vcdClient.OrgVdc.FindVDCNetwork(d.Get("network_name").(string))
+	if err != nil {
+		log.Printf("[DEBUG] Network no longer exists. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	pools := edgeGateway.GetDhcpPools(network.OrgVDCNetwork.HREF)
+	if len(pools) == 0 {
+		log.Printf("[DEBUG] DHCP pools no longer exist. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	result := make([]map[string]interface{}, 0, len(pools))
+	for _, p := range pools {
+		result = append(result, map[string]interface{}{
+			"start_address": p.LowIPAddress,
+			"end_address":   p.HighIPAddress,
+			"default_lease": p.DefaultLeaseTime,
+			"max_lease":     p.MaxLeaseTime,
+		})
+	}
+	d.Set("pool", result)
+
+	if c := network.OrgVDCNetwork.Configuration; c != nil && c.IPScopes != nil {
+		d.Set("dns1", c.IPScopes.IPScope.DNS1)
+		d.Set("dns2", c.IPScopes.IPScope.DNS2)
+	}
+
+	return nil
+}
+
+func resourceVcdEdgeGatewayDhcpDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	network, err := vcdClient.OrgVdc.FindVDCNetwork(d.Get("network_name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding network: %#v", err)
+	}
+
+	ref := &types.Reference{HREF: network.OrgVDCNetwork.HREF, Name: network.OrgVDCNetwork.Name}
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+	err = retryCall(timeout, func() *resource.RetryError {
+		task, err := edgeGateway.SetDhcpPools(ref, nil)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error removing DHCP pools: %#v", err))
+		}
+
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing tasks: %#v", err)
+	}
+
+	return nil
+}
+
+// setEdgeGatewayDhcpPools replaces the DHCP pools the edge gateway serves to
+// the network by the configured pools.
+func setEdgeGatewayDhcpPools(d *schema.ResourceData, meta interface{}, timeoutKey string) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	network, err := vcdClient.OrgVdc.FindVDCNetwork(d.Get("network_name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding network: %#v", err)
+	}
+
+	pools := expandDhcpPools(d.Get("pool").(*schema.Set).List())
+	if err := checkDhcpPools(network, pools); err != nil {
+		return err
+	}
+
+	ref := &types.Reference{HREF: network.OrgVDCNetwork.HREF, Name: network.OrgVDCNetwork.Name}
+
+	log.Printf("[INFO] DHCP POOLS: %#v", pools)
+
+	timeout := vcdClient.retryTimeout(d, timeoutKey)
+	err = retryCall(timeout, func() *resource.RetryError {
+		task, err := edgeGateway.SetDhcpPools(ref, pools)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error setting DHCP pools: %#v", err))
+		}
+
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing tasks: %#v", err)
+	}
+
+	return nil
+}
+
+func expandDhcpPools(configured []interface{}) []*types.DhcpPoolService {
+	pools := make([]*types.DhcpPoolService, 0, len(configured))
+	for _, p := range configured {
+		data := p.(map[string]interface{})
+		pools = append(pools, &types.DhcpPoolService{
+			IsEnabled:        true,
+			DefaultLeaseTime: data["default_lease"].(int),
+			MaxLeaseTime:     data["max_lease"].(int),
+			LowIPAddress:     data["start_address"].(string),
+			HighIPAddress:    data["end_address"].(string),
+		})
+	}
+	return pools
+}
+
+// checkDhcpPools verifies that the pools are valid ranges of the network
+// subnet which do not overlap, and that their leases are consistent. vCD
+// only reports such errors once the edge gateway task fails.
+func checkDhcpPools(network govcd.OrgVDCNetwork, pools []*types.DhcpPoolService) error {
+	var subnet *net.IPNet
+	if c := network.OrgVDCNetwork.Configuration; c != nil && c.IPScopes != nil {
+		gateway := net.ParseIP(c.IPScopes.IPScope.Gateway)
+		mask := net.ParseIP(c.IPScopes.IPScope.Netmask)
+		if gateway != nil && mask != nil {
+			m := net.IPMask(mask.To4())
+			subnet = &net.IPNet{IP: gateway.To4().Mask(m), Mask: m}
+		}
+	}
+
+	for i, p := range pools {
+		start := net.ParseIP(p.LowIPAddress).To4()
+		end := net.ParseIP(p.HighIPAddress).To4()
+		if start == nil || end == nil {
+			return fmt.Errorf("DHCP pool %s-%s is not a range of IPv4 addresses", p.LowIPAddress, p.HighIPAddress)
+		}
+		if bytes.Compare(start, end) > 0 {
+			return fmt.Errorf("DHCP pool %s-%s starts after it ends", p.LowIPAddress, p.HighIPAddress)
+		}
+		if subnet != nil && (!subnet.Contains(start) || !subnet.Contains(end)) {
+			return fmt.Errorf("DHCP pool %s-%s is not within network %s (%s)",
+				p.LowIPAddress, p.HighIPAddress, network.OrgVDCNetwork.Name, subnet)
+		}
+		if p.DefaultLeaseTime > p.MaxLeaseTime {
+			return fmt.Errorf("DHCP pool %s-%s default_lease %d is greater than max_lease %d",
+				p.LowIPAddress, p.HighIPAddress, p.DefaultLeaseTime, p.MaxLeaseTime)
+		}
+
+		for _, o := range pools[:i] {
+			if bytes.Compare(start, net.ParseIP(o.HighIPAddress).To4()) <= 0 &&
+				bytes.Compare(end, net.ParseIP(o.LowIPAddress).To4()) >= 0 {
+				return fmt.Errorf("DHCP pools %s-%s and %s-%s overlap",
+					o.LowIPAddress, o.HighIPAddress, p.LowIPAddress, p.HighIPAddress)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVcdEdgeGatewayDhcp_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdEdgeGatewayDhcpDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdEdgeGatewayDhcp_basic, os.Getenv("VCD_EDGE_GATEWAY"), 3600),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdEdgeGatewayDhcpExists("vcd_edgegateway_dhcp.dhcp"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_dhcp.dhcp", "pool.#", "2"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_dhcp.dhcp", "dns1", "8.8.8.8"),
+				),
+			},
+
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdEdgeGatewayDhcp_basic, os.Getenv("VCD_EDGE_GATEWAY"), 1800),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdEdgeGatewayDhcpExists("vcd_edgegateway_dhcp.dhcp"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_dhcp.dhcp", "pool.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdEdgeGatewayDhcpExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No DHCP ID is set")
+		}
+
+		if testAccVcdDhcpPoolCount(rs) != 2 {
+			return fmt.Errorf("DHCP pools were not found")
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckVcdEdgeGatewayDhcpDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_edgegateway_dhcp" {
+			continue
+		}
+
+		if testAccVcdDhcpPoolCount(rs) != 0 {
+			return fmt.Errorf("DHCP pools still exist")
+		}
+	}
+
+	return nil
+}
+
+func testAccVcdDhcpPoolCount(rs *terraform.ResourceState) int {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	edgeGateway, err := conn.findEdgeGateway(rs.Primary.Attributes["edge_gateway"])
+	if err != nil {
+		return 0
+	}
+
+	network, err := conn.OrgVdc.FindVDCNetwork(rs.Primary.Attributes["network_name"])
+	if err != nil {
+		return 0
+	}
+
+	return len(edgeGateway.GetDhcpPools(network.OrgVDCNetwork.HREF))
+}
+
+const testAccCheckVcdEdgeGatewayDhcp_basic = `
+resource "vcd_network" "foodhcpnet" {
+	name = "foodhcpnet"
+	edge_gateway = "%[1]s"
+	gateway = "10.10.104.1"
+	dns1 = "8.8.8.8"
+	static_ip_pool {
+		start_address = "10.10.104.2"
+		end_address = "10.10.104.99"
+	}
+}
+
+resource "vcd_edgegateway_dhcp" "dhcp" {
+	edge_gateway = "%[1]s"
+	network_name = "${vcd_network.foodhcpnet.name}"
+
+	pool {
+		start_address = "10.10.104.100"
+		end_address   = "10.10.104.149"
+		default_lease = %[2]d
+	}
+
+	pool {
+		start_address = "10.10.104.200"
+		end_address   = "10.10.104.249"
+		max_lease     = 86400
+	}
+}
+`
@@ -0,0 +1,193 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+func resourceVcdEdgeGatewayStaticRoute() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdEdgeGatewayStaticRouteCreate,
+		Read:   resourceVcdEdgeGatewayStaticRouteRead,
+		Delete: resourceVcdEdgeGatewayStaticRouteDelete,
+
+		Timeouts: resourceTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"edge_gateway": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"network": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The network connected to the edge gateway interface the route is bound to",
+			},
+
+			"network_cidr": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"next_hop": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"interface": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "internal",
+				ValidateFunc: validateStaticRouteInterface,
+			},
+		},
+	}
+}
+
+func resourceVcdEdgeGatewayStaticRouteCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	name := d.Get("name").(string)
+	if name == "" {
+		name = d.Get("network_cidr").(string)
+	}
+
+	route := &types.StaticRoute{
+		Name:      name,
+		Network:   d.Get("network_cidr").(string),
+		NextHopIP: d.Get("next_hop").(string),
+		// vCD expects Internal or External
+		Interface: strings.Title(d.Get("interface").(string)),
+	}
+
+	if network, ok := d.GetOk("network"); ok {
+		var ref *types.Reference
+		if c := edgeGateway.EdgeGateway.Configuration; c != nil && c.GatewayInterfaces != nil {
+			for _, gi := range c.GatewayInterfaces.GatewayInterface {
+				if gi.Network != nil && gi.Network.Name == network.(string) {
+					ref = gi.Network
+				}
+			}
+		}
+		if ref == nil {
+			return fmt.Errorf("Edge gateway %s has no interface on network %s", edgeGatewayName, network.(string))
+		}
+		route.GatewayInterface = &types.Reference{
+			HREF: ref.HREF,
+			Name: ref.Name,
+			Type: ref.Type,
+		}
+	}
+
+	log.Printf("[INFO] STATIC ROUTE: %#v", route)
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutCreate)
+	err = retryCall(timeout, func() *resource.RetryError {
+		task, err := edgeGateway.AddStaticRoute(route)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error adding static route: %#v", err))
+		}
+
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing tasks: %#v", err)
+	}
+
+	d.SetId(edgeGatewayName + ":" + route.Network + " > " + route.NextHopIP)
+	d.Set("name", name)
+
+	return resourceVcdEdgeGatewayStaticRouteRead(d, meta)
+}
+
+func resourceVcdEdgeGatewayStaticRouteRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	var found *types.StaticRoute
+	for _, r := range edgeGateway.StaticRoutes() {
+		if r.Network == d.Get("network_cidr").(string) && r.NextHopIP == d.Get("next_hop").(string) {
+			found = r
+		}
+	}
+
+	if found == nil {
+		log.Printf("[DEBUG] Static route no longer exists. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", found.Name)
+	if found.GatewayInterface != nil {
+		d.Set("network", found.GatewayInterface.Name)
+	}
+
+	return nil
+}
+
+func resourceVcdEdgeGatewayStaticRouteDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+	err = retryCall(timeout, func() *resource.RetryError {
+		task, err := edgeGateway.RemoveStaticRoute(d.Get("network_cidr").(string), d.Get("next_hop").(string))
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error removing static route: %#v", err))
+		}
+
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing tasks: %#v", err)
+	}
+
+	return nil
+}
+
+func validateStaticRouteInterface(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if value != "internal" && value != "external" {
+		errors = append(errors, fmt.Errorf("%q must be either internal or external, got %q", k, value))
+	}
+	return
+}
@@ -0,0 +1,107 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVcdEdgeGatewayStaticRoute_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdEdgeGatewayStaticRouteDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdEdgeGatewayStaticRoute_basic, os.Getenv("VCD_EDGE_GATEWAY")),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdEdgeGatewayStaticRouteExists("vcd_edgegateway_static_route.route1"),
+					testAccCheckVcdEdgeGatewayStaticRouteExists("vcd_edgegateway_static_route.route2"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_static_route.route1", "name", "192.168.100.0/24"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_static_route.route2", "network", "foostaticnet"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdEdgeGatewayStaticRouteExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No static route ID is set")
+		}
+
+		if !testAccVcdStaticRouteFound(rs) {
+			return fmt.Errorf("Static route was not found")
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckVcdEdgeGatewayStaticRouteDestroy(s *terraform.State) error {
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_edgegateway_static_route" {
+			continue
+		}
+
+		if testAccVcdStaticRouteFound(rs) {
+			return fmt.Errorf("Static route still exists")
+		}
+	}
+
+	return nil
+}
+
+func testAccVcdStaticRouteFound(rs *terraform.ResourceState) bool {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	edgeGateway, err := conn.findEdgeGateway(rs.Primary.Attributes["edge_gateway"])
+	if err != nil {
+		return false
+	}
+
+	for _, r := range edgeGateway.StaticRoutes() {
+		if r.Network == rs.Primary.Attributes["network_cidr"] && r.NextHopIP == rs.Primary.Attributes["next_hop"] {
+			return true
+		}
+	}
+
+	return false
+}
+
+const testAccCheckVcdEdgeGatewayStaticRoute_basic = `
+resource "vcd_network" "foostaticnet" {
+	name = "foostaticnet"
+	edge_gateway = "%[1]s"
+	gateway = "10.10.103.1"
+	static_ip_pool {
+		start_address = "10.10.103.2"
+		end_address = "10.10.103.254"
+	}
+}
+
+resource "vcd_edgegateway_static_route" "route1" {
+	edge_gateway = "%[1]s"
+	network_cidr = "192.168.100.0/24"
+	next_hop     = "10.10.103.254"
+	depends_on   = ["vcd_network.foostaticnet"]
+}
+
+resource "vcd_edgegateway_static_route" "route2" {
+	edge_gateway = "%[1]s"
+	network      = "${vcd_network.foostaticnet.name}"
+	network_cidr = "192.168.101.0/24"
+	next_hop     = "10.10.103.253"
+}
+`
@@ -2,18 +2,30 @@ package vcd
 
 import (
 	"fmt"
+	"log"
+
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/ukcloud/govcloudair"
 	types "github.com/ukcloud/govcloudair/types/v56"
-	"log"
 )
 
+// vpnTunnelFields are the arguments describing a tunnel, shared by the
+// top-level single tunnel and the tunnel blocks.
+var vpnTunnelFields = []string{
+	"name", "description", "encryption_protocol", "local_ip_address", "local_id",
+	"mtu", "peer_ip_address", "peer_id", "shared_secret", "local_subnets", "peer_subnets",
+}
+
 func resourceVcdEdgeGatewayVpn() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceVcdEdgeGatewayVpnCreate,
 		Read:   resourceVcdEdgeGatewayVpnRead,
+		Update: resourceVcdEdgeGatewayVpnUpdate,
 		Delete: resourceVcdEdgeGatewayVpnDelete,
 
+		Timeouts: resourceTimeouts(),
+
 		Schema: map[string]*schema.Schema{
 
 			"edge_gateway": &schema.Schema{
@@ -23,103 +35,156 @@ func resourceVcdEdgeGatewayVpn() *schema.Resource {
 			},
 
 			"name": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"tunnel"},
 			},
 
 			"description": &schema.Schema{
-				Type:     schema.TypeString,
-				Optional: true,
-				ForceNew: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"tunnel"},
 			},
 
 			"encryption_protocol": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"tunnel"},
+				ValidateFunc:  validateVpnEncryptionProtocol,
 			},
 
 			"local_ip_address": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"tunnel"},
 			},
 
 			"local_id": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"tunnel"},
 			},
 
 			"mtu": &schema.Schema{
-				Type:     schema.TypeInt,
-				Required: true,
-				ForceNew: true,
+				Type:          schema.TypeInt,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"tunnel"},
 			},
 
 			"peer_ip_address": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"tunnel"},
 			},
 
 			"peer_id": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"tunnel"},
 			},
 
 			"shared_secret": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"tunnel"},
 			},
 
 			"local_subnets": &schema.Schema{
-				Type:     schema.TypeSet,
+				Type:          schema.TypeSet,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"tunnel"},
+				Elem:          vpnSubnetResource("local"),
+			},
+
+			"peer_subnets": &schema.Schema{
+				Type:          schema.TypeSet,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"tunnel"},
+				Elem:          vpnSubnetResource("peer"),
+			},
+
+			"tunnel": &schema.Schema{
+				Type:     schema.TypeList,
 				Optional: true,
-				ForceNew: true,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
-						"local_subnet_name": &schema.Schema{
+						"name": &schema.Schema{
 							Type:     schema.TypeString,
 							Required: true,
 						},
 
-						"local_subnet_gateway": &schema.Schema{
+						"description": &schema.Schema{
 							Type:     schema.TypeString,
-							Required: true,
+							Optional: true,
 						},
 
-						"local_subnet_mask": &schema.Schema{
+						"encryption_protocol": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateVpnEncryptionProtocol,
+						},
+
+						"local_ip_address": &schema.Schema{
 							Type:     schema.TypeString,
 							Required: true,
 						},
-					},
-				},
-			},
 
-			"peer_subnets": &schema.Schema{
-				Type:     schema.TypeSet,
-				Optional: true,
-				ForceNew: true,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"peer_subnet_name": &schema.Schema{
+						"local_id": &schema.Schema{
 							Type:     schema.TypeString,
 							Required: true,
 						},
 
-						"peer_subnet_gateway": &schema.Schema{
+						"mtu": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  1500,
+						},
+
+						"peer_ip_address": &schema.Schema{
 							Type:     schema.TypeString,
 							Required: true,
 						},
 
-						"peer_subnet_mask": &schema.Schema{
+						"peer_id": &schema.Schema{
 							Type:     schema.TypeString,
 							Required: true,
 						},
+
+						"shared_secret": &schema.Schema{
+							Type:      schema.TypeString,
+							Required:  true,
+							Sensitive: true,
+						},
+
+						"local_subnets": &schema.Schema{
+							Type:     schema.TypeSet,
+							Optional: true,
+							Elem:     vpnSubnetResource("local"),
+						},
+
+						"peer_subnets": &schema.Schema{
+							Type:     schema.TypeSet,
+							Optional: true,
+							Elem:     vpnSubnetResource("peer"),
+						},
+
+						"enabled": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
 					},
 				},
 			},
@@ -127,86 +192,77 @@ func resourceVcdEdgeGatewayVpn() *schema.Resource {
 	}
 }
 
-func resourceVcdEdgeGatewayVpnCreate(d *schema.ResourceData, meta interface{}) error {
-	vcdClient := meta.(*VCDClient)
-	log.Printf("[TRACE] CLIENT: %#v", vcdClient)
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
+// vpnSubnetResource returns the schema of a local or peer subnet, whose
+// fields are prefixed by the side of the tunnel they belong to.
+func vpnSubnetResource(side string) *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			side + "_subnet_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+			side + "_subnet_gateway": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
 
-	localSubnetsList := d.Get("local_subnets").(*schema.Set).List()
-	peerSubnetsList := d.Get("peer_subnets").(*schema.Set).List()
+			side + "_subnet_mask": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+		},
+	}
+}
 
-	localSubnets := make([]*types.IpsecVpnSubnet, len(localSubnetsList))
-	peerSubnets := make([]*types.IpsecVpnSubnet, len(peerSubnetsList))
+func resourceVcdEdgeGatewayVpnCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
 
-	for i, s := range localSubnetsList {
-		ls := s.(map[string]interface{})
-		localSubnets[i] = &types.IpsecVpnSubnet{
-			Name:    ls["local_subnet_name"].(string),
-			Gateway: ls["local_subnet_gateway"].(string),
-			Netmask: ls["local_subnet_mask"].(string),
-		}
+	tunnels, err := expandVpnTunnels(d)
+	if err != nil {
+		return err
 	}
 
-	for i, s := range peerSubnetsList {
-		ls := s.(map[string]interface{})
-		peerSubnets[i] = &types.IpsecVpnSubnet{
-			Name:    ls["peer_subnet_name"].(string),
-			Gateway: ls["peer_subnet_gateway"].(string),
-			Netmask: ls["peer_subnet_mask"].(string),
-		}
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
 	}
 
-	tunnel := &types.GatewayIpsecVpnTunnel{
-		Name:        d.Get("name").(string),
-		Description: d.Get("description").(string),
-		IpsecVpnLocalPeer: &types.IpsecVpnLocalPeer{
-			ID:   "",
-			Name: "",
-		},
-		EncryptionProtocol: d.Get("encryption_protocol").(string),
-		LocalIPAddress:     d.Get("local_ip_address").(string),
-		LocalID:            d.Get("local_id").(string),
-		LocalSubnet:        localSubnets,
-		Mtu:                d.Get("mtu").(int),
-		PeerID:             d.Get("peer_id").(string),
-		PeerIPAddress:      d.Get("peer_ip_address").(string),
-		PeerSubnet:         peerSubnets,
-		SharedSecret:       d.Get("shared_secret").(string),
-		IsEnabled:          true,
+	err = configureVpnTunnels(&vcdClient.Client, &edgeGateway, tunnels, vcdClient.retryTimeout(d, schema.TimeoutCreate))
+	if err != nil {
+		return err
 	}
 
-	tunnels := make([]*types.GatewayIpsecVpnTunnel, 1)
-	tunnels[0] = tunnel
+	d.SetId(edgeGatewayName)
 
-	ipsecVPNConfig := &types.EdgeGatewayServiceConfiguration{
-		Xmlns: "http://www.vmware.com/vcloud/v1.5",
-		GatewayIpsecVpnService: &types.GatewayIpsecVpnService{
-			IsEnabled: true,
-			Tunnel:    tunnels,
-		},
-	}
+	return resourceVcdEdgeGatewayVpnRead(d, meta)
+}
 
-	log.Printf("[INFO] ipsecVPNConfig: %#v", ipsecVPNConfig)
+func resourceVcdEdgeGatewayVpnUpdate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		edgeGateway.Refresh()
-		task, err := edgeGateway.AddIpsecVPN(ipsecVPNConfig)
-		if err != nil {
-			log.Printf("[INFO] Error setting ipsecVPNConfig rules: %s", err)
-			return resource.RetryableError(
-				fmt.Errorf("Error setting ipsecVPNConfig rules: %#v", err))
-		}
+	tunnels, err := expandVpnTunnels(d)
+	if err != nil {
+		return err
+	}
 
-		return resource.RetryableError(task.WaitTaskCompletion())
-	})
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(edgeGatewayName)
 	if err != nil {
-		return fmt.Errorf("Error completing tasks: %#v", err)
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
 	}
 
-	d.SetId(d.Get("edge_gateway").(string))
+	err = configureVpnTunnels(&vcdClient.Client, &edgeGateway, tunnels, vcdClient.retryTimeout(d, schema.TimeoutUpdate))
+	if err != nil {
+		return err
+	}
 
 	return resourceVcdEdgeGatewayVpnRead(d, meta)
 }
@@ -214,23 +270,86 @@ func resourceVcdEdgeGatewayVpnCreate(d *schema.ResourceData, meta interface{}) e
 func resourceVcdEdgeGatewayVpnDelete(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
 
-	log.Printf("[TRACE] CLIENT: %#v", vcdClient)
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
 
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
+	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	return configureVpnTunnels(&vcdClient.Client, &edgeGateway, nil, vcdClient.retryTimeout(d, schema.TimeoutDelete))
+}
+
+func resourceVcdEdgeGatewayVpnRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
 
 	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding edge gateway: %#v", err)
+	}
+
+	var tunnels []*types.GatewayIpsecVpnTunnel
+	if egsc := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.GatewayIpsecVpnService; egsc != nil {
+		tunnels = egsc.Tunnel
+	}
+
+	if len(tunnels) == 0 {
+		log.Printf("[DEBUG] Edge gateway has no VPN tunnels. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	// With tunnel blocks every tunnel of the gateway is read back, so that
+	// tunnels added or removed outside of Terraform show up as a change
+	if _, ok := d.GetOk("name"); !ok {
+		d.Set("tunnel", flattenVpnTunnels(tunnels, d.Get("tunnel").([]interface{})))
+		return nil
+	}
+
+	// The top-level arguments describe the tunnel of that name, any other
+	// tunnel of the gateway is left out of the state
+	name := d.Get("name").(string)
+	var tunnel *types.GatewayIpsecVpnTunnel
+	for _, t := range tunnels {
+		if t.Name == name {
+			tunnel = t
+			continue
+		}
+		log.Printf("[DEBUG] Ignoring VPN tunnel %s of edge gateway %s", t.Name, edgeGateway.EdgeGateway.Name)
+	}
 
+	if tunnel == nil {
+		log.Printf("[DEBUG] VPN tunnel %s no longer exists. Removing from tfstate", name)
+		d.SetId("")
+		return nil
+	}
+
+	configured := map[string]interface{}{"name": name, "shared_secret": d.Get("shared_secret")}
+	for k, v := range flattenVpnTunnels([]*types.GatewayIpsecVpnTunnel{tunnel}, []interface{}{configured})[0] {
+		if k != "enabled" {
+			d.Set(k, v)
+		}
+	}
+
+	return nil
+}
+
+// configureVpnTunnels replaces the tunnels of the edge gateway by tunnels.
+// The IPsec VPN service is disabled when there are no tunnels left.
+func configureVpnTunnels(c *govcd.Client, edgeGateway *govcd.EdgeGateway, tunnels []*types.GatewayIpsecVpnTunnel, timeout int) error {
 	ipsecVPNConfig := &types.EdgeGatewayServiceConfiguration{
 		Xmlns: "http://www.vmware.com/vcloud/v1.5",
 		GatewayIpsecVpnService: &types.GatewayIpsecVpnService{
-			IsEnabled: false,
+			IsEnabled: len(tunnels) > 0,
+			Tunnel:    tunnels,
 		},
 	}
 
 	log.Printf("[INFO] ipsecVPNConfig: %#v", ipsecVPNConfig)
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+	err := retryCall(timeout, func() *resource.RetryError {
 		edgeGateway.Refresh()
 		task, err := edgeGateway.AddIpsecVPN(ipsecVPNConfig)
 		if err != nil {
@@ -239,52 +358,136 @@ func resourceVcdEdgeGatewayVpnDelete(d *schema.ResourceData, meta interface{}) e
 				fmt.Errorf("Error setting ipsecVPNConfig rules: %#v", err))
 		}
 
-		return resource.RetryableError(task.WaitTaskCompletion())
+		return resource.RetryableError(waitTask(c, task, timeout))
 	})
 	if err != nil {
 		return fmt.Errorf("Error completing tasks: %#v", err)
 	}
 
-	d.SetId(d.Get("edge_gateway").(string))
+	return nil
+}
 
-	if err != nil {
-		return fmt.Errorf("Error finding edge gateway: %#v", err)
+// expandVpnTunnels returns the tunnels of the tunnel blocks, or the single
+// tunnel described by the top-level arguments when there are none. Those
+// arguments are optional to allow tunnel blocks, so they are checked here.
+func expandVpnTunnels(d *schema.ResourceData) ([]*types.GatewayIpsecVpnTunnel, error) {
+	var tunnels []*types.GatewayIpsecVpnTunnel
+
+	blocks := d.Get("tunnel").([]interface{})
+	if len(blocks) == 0 {
+		data := map[string]interface{}{"enabled": true}
+		for _, k := range vpnTunnelFields {
+			data[k] = d.Get(k)
+		}
+		for _, k := range []string{"name", "encryption_protocol", "local_ip_address", "local_id", "mtu", "peer_ip_address", "peer_id", "shared_secret"} {
+			if _, ok := d.GetOk(k); !ok {
+				return nil, fmt.Errorf("%s must be set when no tunnel blocks are given", k)
+			}
+		}
+		return append(tunnels, expandVpnTunnel(data)), nil
 	}
 
-	return nil
+	names := make(map[string]bool)
+	for _, t := range blocks {
+		tunnel := expandVpnTunnel(t.(map[string]interface{}))
+		if names[tunnel.Name] {
+			return nil, fmt.Errorf("Tunnel names must be unique, %s is used more than once", tunnel.Name)
+		}
+		names[tunnel.Name] = true
+		tunnels = append(tunnels, tunnel)
+	}
+
+	return tunnels, nil
 }
 
-func resourceVcdEdgeGatewayVpnRead(d *schema.ResourceData, meta interface{}) error {
-	vcdClient := meta.(*VCDClient)
+func expandVpnTunnel(data map[string]interface{}) *types.GatewayIpsecVpnTunnel {
+	return &types.GatewayIpsecVpnTunnel{
+		Name:        data["name"].(string),
+		Description: data["description"].(string),
+		IpsecVpnLocalPeer: &types.IpsecVpnLocalPeer{
+			ID:   "",
+			Name: "",
+		},
+		EncryptionProtocol: data["encryption_protocol"].(string),
+		LocalIPAddress:     data["local_ip_address"].(string),
+		LocalID:            data["local_id"].(string),
+		LocalSubnet:        expandVpnSubnets(data["local_subnets"].(*schema.Set).List(), "local"),
+		Mtu:                data["mtu"].(int),
+		PeerID:             data["peer_id"].(string),
+		PeerIPAddress:      data["peer_ip_address"].(string),
+		PeerSubnet:         expandVpnSubnets(data["peer_subnets"].(*schema.Set).List(), "peer"),
+		SharedSecret:       data["shared_secret"].(string),
+		IsEnabled:          data["enabled"].(bool),
+	}
+}
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
-	if err != nil {
-		return fmt.Errorf("Error finding edge gateway: %#v", err)
+func expandVpnSubnets(list []interface{}, side string) []*types.IpsecVpnSubnet {
+	subnets := make([]*types.IpsecVpnSubnet, len(list))
+	for i, s := range list {
+		data := s.(map[string]interface{})
+		subnets[i] = &types.IpsecVpnSubnet{
+			Name:    data[side+"_subnet_name"].(string),
+			Gateway: data[side+"_subnet_gateway"].(string),
+			Netmask: data[side+"_subnet_mask"].(string),
+		}
 	}
+	return subnets
+}
 
-	egsc := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.GatewayIpsecVpnService
+// flattenVpnTunnels returns the tunnels in the format of the tunnel blocks.
+// vCD may return the shared secret encrypted, or not at all, in which case
+// the secret configured for the tunnel of the same name is kept.
+func flattenVpnTunnels(tunnels []*types.GatewayIpsecVpnTunnel, configured []interface{}) []map[string]interface{} {
+	secrets := make(map[string]string)
+	for _, t := range configured {
+		if data, ok := t.(map[string]interface{}); ok {
+			secrets[data["name"].(string)] = data["shared_secret"].(string)
+		}
+	}
 
-	if len(egsc.Tunnel) == 0 {
-		d.SetId("")
-		return nil
+	result := make([]map[string]interface{}, 0, len(tunnels))
+	for _, tunnel := range tunnels {
+		secret := tunnel.SharedSecret
+		if secret == "" || tunnel.SharedSecretEncrypted {
+			secret = secrets[tunnel.Name]
+		}
+
+		result = append(result, map[string]interface{}{
+			"name":                tunnel.Name,
+			"description":         tunnel.Description,
+			"encryption_protocol": tunnel.EncryptionProtocol,
+			"local_ip_address":    tunnel.LocalIPAddress,
+			"local_id":            tunnel.LocalID,
+			"mtu":                 tunnel.Mtu,
+			"peer_ip_address":     tunnel.PeerIPAddress,
+			"peer_id":             tunnel.PeerID,
+			"shared_secret":       secret,
+			"local_subnets":       flattenVpnSubnets(tunnel.LocalSubnet, "local"),
+			"peer_subnets":        flattenVpnSubnets(tunnel.PeerSubnet, "peer"),
+			"enabled":             tunnel.IsEnabled,
+		})
 	}
+	return result
+}
 
-	if len(egsc.Tunnel) == 1 {
-		tunnel := egsc.Tunnel[0]
-		d.Set("name", tunnel.Name)
-		d.Set("description", tunnel.Description)
-		d.Set("encryption_protocol", tunnel.EncryptionProtocol)
-		d.Set("local_ip_address", tunnel.LocalIPAddress)
-		d.Set("local_id", tunnel.LocalID)
-		d.Set("mtu", tunnel.Mtu)
-		d.Set("peer_ip_address", tunnel.PeerIPAddress)
-		d.Set("peer_id", tunnel.PeerID)
-		d.Set("shared_secret", tunnel.SharedSecret)
-		d.Set("local_subnets", tunnel.LocalSubnet)
-		d.Set("peer_subnets", tunnel.PeerSubnet)
-	} else {
-		return fmt.Errorf("Multiple tunnels not currently supported")
+func flattenVpnSubnets(subnets []*types.IpsecVpnSubnet, side string) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(subnets))
+	for _, subnet := range subnets {
+		result = append(result, map[string]interface{}{
+			side + "_subnet_name":    subnet.Name,
+			side + "_subnet_gateway": subnet.Gateway,
+			side + "_subnet_mask":    subnet.Netmask,
+		})
 	}
+	return result
+}
 
-	return nil
+func validateVpnEncryptionProtocol(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	switch value {
+	case "AES", "AES256", "TRIPLEDES":
+	default:
+		errors = append(errors, fmt.Errorf("%q must be one of AES, AES256 or TRIPLEDES, got %q", k, value))
+	}
+	return
 }
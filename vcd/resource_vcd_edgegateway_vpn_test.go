@@ -27,6 +27,39 @@ func TestAccVcdVpn_Basic(t *testing.T) {
 	})
 }
 
+func TestAccVcdVpn_Tunnels(t *testing.T) {
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdVpnDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVpn_tunnels, os.Getenv("VCD_EDGE_GATEWAY")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_vpn.vpn", "tunnel.#", "2"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_vpn.vpn", "tunnel.1.name", "west-to-north"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_vpn.vpn", "tunnel.1.mtu", "1500"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVpn_tunnelsUpdated, os.Getenv("VCD_EDGE_GATEWAY")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_vpn.vpn", "tunnel.#", "1"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_vpn.vpn", "tunnel.0.name", "west-to-north"),
+					resource.TestCheckResourceAttr(
+						"vcd_edgegateway_vpn.vpn", "tunnel.0.encryption_protocol", "AES"),
+				),
+			},
+		},
+	})
+}
+
 func testAccCheckVcdVpnDestroy(s *terraform.State) error {
 
 	for _, rs := range s.RootModule().Resources {
@@ -78,3 +111,82 @@ resource "vcd_edgegateway_vpn" "vpn" {
     }
 }
 `
+
+const testAccCheckVcdVpn_tunnels = `
+resource "vcd_edgegateway_vpn" "vpn" {
+    edge_gateway = "%s"
+
+    tunnel {
+        name                = "west-to-east"
+        encryption_protocol = "AES256"
+        mtu                 = 1400
+        peer_id             = "51.179.218.226"
+        peer_ip_address     = "51.179.218.226"
+        local_id            = "51.179.218.225"
+        local_ip_address    = "51.179.218.225"
+        shared_secret       = "yZ4B8pxS5334m6ho692hjbtb7zo2vbesn7pe8ry5hyud86M433tbnnfxt6Dqn73g"
+
+        peer_subnets {
+            peer_subnet_name = "DMZ_WEST"
+            peer_subnet_gateway = "10.0.10.1"
+            peer_subnet_mask = "255.255.255.0"
+        }
+
+        local_subnets {
+            local_subnet_name = "DMZ_EAST"
+            local_subnet_gateway = "10.0.1.1"
+            local_subnet_mask = "255.255.255.0"
+        }
+    }
+
+    tunnel {
+        name                = "west-to-north"
+        encryption_protocol = "AES256"
+        peer_id             = "51.179.218.227"
+        peer_ip_address     = "51.179.218.227"
+        local_id            = "51.179.218.225"
+        local_ip_address    = "51.179.218.225"
+        shared_secret       = "Wq3pLhdk8Hn4bJx7Tz5vGm2cRf9sNy6aKe1uVo0iDt8wQl4pZr7xBc3jMn5gHs2y"
+
+        peer_subnets {
+            peer_subnet_name = "DMZ_NORTH"
+            peer_subnet_gateway = "10.0.30.1"
+            peer_subnet_mask = "255.255.255.0"
+        }
+
+        local_subnets {
+            local_subnet_name = "WEB_EAST"
+            local_subnet_gateway = "10.0.22.1"
+            local_subnet_mask = "255.255.255.0"
+        }
+    }
+}
+`
+
+const testAccCheckVcdVpn_tunnelsUpdated = `
+resource "vcd_edgegateway_vpn" "vpn" {
+    edge_gateway = "%s"
+
+    tunnel {
+        name                = "west-to-north"
+        encryption_protocol = "AES"
+        peer_id             = "51.179.218.227"
+        peer_ip_address     = "51.179.218.227"
+        local_id            = "51.179.218.225"
+        local_ip_address    = "51.179.218.225"
+        shared_secret       = "Wq3pLhdk8Hn4bJx7Tz5vGm2cRf9sNy6aKe1uVo0iDt8wQl4pZr7xBc3jMn5gHs2y"
+
+        peer_subnets {
+            peer_subnet_name = "DMZ_NORTH"
+            peer_subnet_gateway = "10.0.30.1"
+            peer_subnet_mask = "255.255.255.0"
+        }
+
+        local_subnets {
+            local_subnet_name = "WEB_EAST"
+            local_subnet_gateway = "10.0.22.1"
+            local_subnet_mask = "255.255.255.0"
+        }
+    }
+}
+`
@@ -16,6 +16,8 @@ func resourceVcdFirewallRules() *schema.Resource {
 		Delete: resourceFirewallRulesDelete,
 		Read:   resourceFirewallRulesRead,
 
+		Timeouts: resourceTimeouts(),
+
 		Schema: map[string]*schema.Schema{
 			"edge_gateway": &schema.Schema{
 				Type:     schema.TypeString,
@@ -75,6 +77,12 @@ func resourceVcdFirewallRules() *schema.Resource {
 							Type:     schema.TypeString,
 							Required: true,
 						},
+
+						"above_rule_id": &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The id of the existing rule to insert this rule above. The rule is appended when unset",
+						},
 					},
 				},
 			},
@@ -84,17 +92,23 @@ func resourceVcdFirewallRules() *schema.Resource {
 
 func resourceVcdFirewallRulesCreate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(edgeGatewayName)
 	if err != nil {
 		return fmt.Errorf("Unable to find edge gateway: %s", err)
 	}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutCreate)
+	err = retryCall(timeout, func() *resource.RetryError {
 		edgeGateway.Refresh()
-		firewallRules, _ := expandFirewallRules(d, edgeGateway.EdgeGateway)
+		firewallRules, err := expandFirewallRules(d, edgeGateway.EdgeGateway)
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
 		task, err := edgeGateway.CreateFirewallRules(d.Get("default_action").(string), firewallRules)
 		if err != nil {
 			log.Printf("[INFO] Error setting firewall rules: %s", err)
@@ -102,23 +116,29 @@ func resourceVcdFirewallRulesCreate(d *schema.ResourceData, meta interface{}) er
 				fmt.Errorf("Error setting firewall rules: %#v", err))
 		}
 
-		return resource.RetryableError(task.WaitTaskCompletion())
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 	})
 	if err != nil {
 		return fmt.Errorf("Error completing tasks: %#v", err)
 	}
 
-	d.SetId(d.Get("edge_gateway").(string))
+	// The rules are part of the configuration of the edge gateway
+	d.SetId(edgeGateway.EdgeGateway.HREF)
 
 	return resourceFirewallRulesRead(d, meta)
 }
 
 func resourceFirewallRulesDelete(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %s", err)
+	}
 
 	firewallRules := deleteFirewallRules(d, edgeGateway.EdgeGateway)
 	defaultAction := edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService.DefaultAction
@@ -127,7 +147,7 @@ func resourceFirewallRulesDelete(d *schema.ResourceData, meta interface{}) error
 		return fmt.Errorf("Error deleting firewall rules: %#v", err)
 	}
 
-	err = task.WaitTaskCompletion()
+	err = waitTask(&vcdClient.Client, task, vcdClient.retryTimeout(d, schema.TimeoutDelete))
 	if err != nil {
 		return fmt.Errorf("Error completing tasks: %#v", err)
 	}
@@ -142,20 +162,32 @@ func resourceFirewallRulesRead(d *schema.ResourceData, meta interface{}) error {
 	if err != nil {
 		return fmt.Errorf("Error finding edge gateway: %#v", err)
 	}
-	ruleList := d.Get("rule").([]interface{})
+	// Earlier versions used the edge gateway name as the ID
+	d.SetId(edgeGateway.EdgeGateway.HREF)
+
 	firewallRules := *edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService
-	rulesCount := d.Get("rule.#").(int)
-	for i := 0; i < rulesCount; i++ {
+
+	// Keep the rules of the resource found on the edge gateway, by id or, for
+	// rules created before ids were recorded, by their settings. A rule which
+	// was removed outside of Terraform is dropped, so it is created again.
+	claimed := make(map[string]bool)
+	ruleList := []interface{}{}
+	for i, r := range d.Get("rule").([]interface{}) {
 		prefix := fmt.Sprintf("rule.%d", i)
-		if d.Get(prefix+".id").(string) == "" {
-			log.Printf("[INFO] Rule %d has no id. Searching...", i)
-			ruleid, err := matchFirewallRule(d, prefix, firewallRules.FirewallRule)
-			if err == nil {
-				currentRule := ruleList[i].(map[string]interface{})
-				currentRule["id"] = ruleid
-				ruleList[i] = currentRule
+		ruleid := d.Get(prefix + ".id").(string)
+		if ruleid == "" || !hasFirewallRule(ruleid, firewallRules.FirewallRule) {
+			log.Printf("[INFO] Rule %d has no id on the edge gateway. Searching...", i)
+			ruleid, err = matchFirewallRule(d, prefix, firewallRules.FirewallRule, claimed)
+			if err != nil {
+				log.Printf("[DEBUG] Rule %d no longer exists. Removing from tfstate", i)
+				continue
 			}
 		}
+		claimed[ruleid] = true
+
+		currentRule := r.(map[string]interface{})
+		currentRule["id"] = ruleid
+		ruleList = append(ruleList, currentRule)
 	}
 	d.Set("rule", ruleList)
 	d.Set("default_action", firewallRules.DefaultAction)
@@ -183,9 +215,23 @@ func deleteFirewallRules(d *schema.ResourceData, gateway *types.EdgeGateway) []*
 	return fwrules
 }
 
-func matchFirewallRule(d *schema.ResourceData, prefix string, rules []*types.FirewallRule) (string, error) {
+func hasFirewallRule(id string, rules []*types.FirewallRule) bool {
+	for _, m := range rules {
+		if m.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFirewallRule returns the id of the first rule matching the settings of
+// the rule at prefix, skipping the rules already claimed by other rules.
+func matchFirewallRule(d *schema.ResourceData, prefix string, rules []*types.FirewallRule, claimed map[string]bool) (string, error) {
 
 	for _, m := range rules {
+		if claimed[m.ID] {
+			continue
+		}
 		if d.Get(prefix+".description").(string) == m.Description &&
 			d.Get(prefix+".policy").(string) == m.Policy &&
 			strings.ToLower(d.Get(prefix+".protocol").(string)) == getProtocol(*m.Protocols) &&
@@ -14,7 +14,7 @@ import (
 func TestAccVcdFirewallRules_basic(t *testing.T) {
 
 	var existingRules, fwRules govcd.EdgeGateway
-	newConfig := createFirewallRulesConfigs(&existingRules)
+	newConfig := createFirewallRulesConfigs(testAccCheckVcdFirewallRules_add, &existingRules)
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:  func() { testAccPreCheck(t) },
@@ -32,6 +32,27 @@ func TestAccVcdFirewallRules_basic(t *testing.T) {
 
 }
 
+func TestAccVcdFirewallRules_position(t *testing.T) {
+
+	var existingRules, fwRules govcd.EdgeGateway
+	newConfig := createFirewallRulesConfigs(testAccCheckVcdFirewallRules_position, &existingRules)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: newConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdFirewallRulesExists("vcd_firewall_rules.baz", &fwRules),
+					testAccCheckVcdFirewallRuleAbove(&fwRules, "vcd_firewall_rules.baz", "vcd_firewall_rules.bar"),
+				),
+			},
+		},
+	})
+
+}
+
 func testAccCheckVcdFirewallRulesExists(n string, gateway *govcd.EdgeGateway) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -46,7 +67,7 @@ func testAccCheckVcdFirewallRulesExists(n string, gateway *govcd.EdgeGateway) re
 
 		conn := testAccProvider.Meta().(*VCDClient)
 
-		resp, err := conn.OrgVdc.FindEdgeGateway(rs.Primary.ID)
+		resp, err := conn.OrgVdc.FindEdgeGateway(rs.Primary.Attributes["edge_gateway"])
 		if err != nil {
 			return fmt.Errorf("Edge Gateway does not exist.")
 		}
@@ -70,7 +91,31 @@ func testAccCheckVcdFirewallRulesAttributes(newRules, existingRules *govcd.EdgeG
 	}
 }
 
-func createFirewallRulesConfigs(existingRules *govcd.EdgeGateway) string {
+// testAccCheckVcdFirewallRuleAbove checks that the first rule of above comes
+// right before the first rule of below on the edge gateway.
+func testAccCheckVcdFirewallRuleAbove(gateway *govcd.EdgeGateway, above, below string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		aboveID := s.RootModule().Resources[above].Primary.Attributes["rule.0.id"]
+		belowID := s.RootModule().Resources[below].Primary.Attributes["rule.0.id"]
+		if aboveID == "" || belowID == "" {
+			return fmt.Errorf("Rule ids were not set: %q, %q", aboveID, belowID)
+		}
+
+		rules := gateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService.FirewallRule
+		for i := 1; i < len(rules); i++ {
+			if rules[i].ID == belowID {
+				if rules[i-1].ID != aboveID {
+					return fmt.Errorf("Rule %s is above rule %s instead of %s", rules[i-1].ID, belowID, aboveID)
+				}
+				return nil
+			}
+		}
+
+		return fmt.Errorf("Rule %s was not found below any rule", belowID)
+	}
+}
+
+func createFirewallRulesConfigs(template string, existingRules *govcd.EdgeGateway) string {
 	config := Config{
 		User:            os.Getenv("VCD_USER"),
 		Password:        os.Getenv("VCD_PASSWORD"),
@@ -81,13 +126,13 @@ func createFirewallRulesConfigs(existingRules *govcd.EdgeGateway) string {
 	}
 	conn, err := config.Client()
 	if err != nil {
-		return fmt.Sprintf(testAccCheckVcdFirewallRules_add, "", "")
+		return fmt.Sprintf(template, "", "")
 	}
 	edgeGateway, _ := conn.OrgVdc.FindEdgeGateway(os.Getenv("VCD_EDGE_GATEWAY"))
 	*existingRules = edgeGateway
 	log.Printf("[DEBUG] Edge gateway: %#v", edgeGateway)
 	firewallRules := *edgeGateway.EdgeGateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService
-	return fmt.Sprintf(testAccCheckVcdFirewallRules_add, os.Getenv("VCD_EDGE_GATEWAY"), firewallRules.DefaultAction)
+	return fmt.Sprintf(template, os.Getenv("VCD_EDGE_GATEWAY"), firewallRules.DefaultAction)
 }
 
 const testAccCheckVcdFirewallRules_add = `
@@ -106,3 +151,36 @@ resource "vcd_firewall_rules" "bar" {
 	}
 }
 `
+
+const testAccCheckVcdFirewallRules_position = `
+resource "vcd_firewall_rules" "bar" {
+	edge_gateway = "%[1]s"
+	default_action = "%[2]s"
+
+	rule {
+		description = "Test rule"
+		policy = "allow"
+		protocol = "any"
+		destination_port = "any"
+		destination_ip = "any"
+		source_port = "any"
+		source_ip = "any"
+	}
+}
+
+resource "vcd_firewall_rules" "baz" {
+	edge_gateway = "%[1]s"
+	default_action = "%[2]s"
+
+	rule {
+		description = "Test rule above"
+		policy = "deny"
+		protocol = "tcp"
+		destination_port = "21"
+		destination_ip = "any"
+		source_port = "any"
+		source_ip = "any"
+		above_rule_id = "${vcd_firewall_rules.bar.rule.0.id}"
+	}
+}
+`
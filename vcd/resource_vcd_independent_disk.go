@@ -0,0 +1,241 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// Bus types of independent disks, as numbered by the vCloud API
+var diskBusTypes = map[string]string{
+	"IDE":  "5",
+	"SCSI": "6",
+	"SATA": "20",
+}
+
+var diskDefaultBusSubTypes = map[string]string{
+	"IDE":  "ide",
+	"SCSI": "lsilogic",
+	"SATA": "vmware.sata.ahci",
+}
+
+func resourceVcdIndependentDisk() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdIndependentDiskCreate,
+		Read:   resourceVcdIndependentDiskRead,
+		Update: resourceVcdIndependentDiskUpdate,
+		Delete: resourceVcdIndependentDiskDelete,
+
+		Timeouts: resourceTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"vdc": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "The VDC to create the disk in. Defaults to the provider VDC",
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"size": &schema.Schema{
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "Size of the disk in MB",
+			},
+
+			"bus_type": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "SCSI",
+				ValidateFunc: validateDiskBusType,
+			},
+
+			"bus_sub_type": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"storage_profile": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+
+			"iops": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+
+			"href": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceVcdIndependentDiskCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	vdc, err := vcdClient.findVdc(d.Get("vdc").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding VDC: %#v", err)
+	}
+
+	busType := d.Get("bus_type").(string)
+	busSubType := d.Get("bus_sub_type").(string)
+	if busSubType == "" {
+		busSubType = diskDefaultBusSubTypes[busType]
+	}
+
+	newdisk := &diskType{
+		Name:       d.Get("name").(string),
+		Size:       int64(d.Get("size").(int)) * 1024 * 1024,
+		BusType:    diskBusTypes[busType],
+		BusSubType: busSubType,
+	}
+
+	if storageProfile, ok := d.GetOk("storage_profile"); ok {
+		ref, err := vdc.FindStorageProfileReference(storageProfile.(string))
+		if err != nil {
+			return fmt.Errorf("Error finding storage profile %s: %#v", storageProfile.(string), err)
+		}
+		newdisk.StorageProfile = &ref
+	}
+
+	log.Printf("[INFO] DISK: %#v", newdisk)
+
+	disk, err := vcdClient.createDisk(vdc, newdisk)
+	if err != nil {
+		return fmt.Errorf("Error creating disk: %#v", err)
+	}
+
+	// The disk exists as soon as vCD accepted it, so it is recorded before
+	// waiting, and deleted on the next apply if the creation fails
+	d.SetId(disk.Disk.HREF)
+	d.Set("vdc", vdc.Vdc.Name)
+
+	if err := disk.Wait(vcdClient.retryTimeout(d, schema.TimeoutCreate)); err != nil {
+		return fmt.Errorf("Error completing tasks: %#v", err)
+	}
+
+	return resourceVcdIndependentDiskRead(d, meta)
+}
+
+func resourceVcdIndependentDiskRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	disk, err := vcdClient.findDiskByHREF(d.Id())
+	if err != nil {
+		log.Printf("[DEBUG] Disk no longer exists. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", disk.Disk.Name)
+	d.Set("size", int(disk.Disk.Size/1024/1024))
+	for name, busType := range diskBusTypes {
+		if busType == disk.Disk.BusType {
+			d.Set("bus_type", name)
+		}
+	}
+	d.Set("bus_sub_type", disk.Disk.BusSubType)
+	if disk.Disk.StorageProfile != nil {
+		d.Set("storage_profile", disk.Disk.StorageProfile.Name)
+	}
+	d.Set("iops", disk.Disk.Iops)
+	d.Set("href", disk.Disk.HREF)
+
+	return nil
+}
+
+func resourceVcdIndependentDiskUpdate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	if d.HasChange("size") {
+		oldSize, newSize := d.GetChange("size")
+		if newSize.(int) < oldSize.(int) {
+			return fmt.Errorf("vCD cannot shrink independent disks, %s is %d MB and cannot be resized to %d MB", d.Get("name").(string), oldSize.(int), newSize.(int))
+		}
+
+		disk, err := vcdClient.findDiskByHREF(d.Id())
+		if err != nil {
+			return fmt.Errorf("Error finding disk: %#v", err)
+		}
+
+		timeout := vcdClient.retryTimeout(d, schema.TimeoutUpdate)
+		err = retryCall(timeout, func() *resource.RetryError {
+			task, err := disk.Resize(int64(newSize.(int)) * 1024 * 1024)
+			if err != nil {
+				return resource.RetryableError(fmt.Errorf("Error resizing disk: %#v", err))
+			}
+			return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+		})
+		if err != nil {
+			return fmt.Errorf("Error completing tasks: %#v", err)
+		}
+	}
+
+	return resourceVcdIndependentDiskRead(d, meta)
+}
+
+func resourceVcdIndependentDiskDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	disk, err := vcdClient.findDiskByHREF(d.Id())
+	if err != nil {
+		return fmt.Errorf("Error finding disk: %#v", err)
+	}
+
+	vms, err := disk.AttachedVMs()
+	if err != nil {
+		return fmt.Errorf("Error reading VMs attached to disk: %#v", err)
+	}
+	if len(vms) > 0 {
+		names := make([]string, 0, len(vms))
+		for _, vm := range vms {
+			names = append(names, vm.Name)
+		}
+		return fmt.Errorf("Disk %s is still attached to VMs %v, detach it before deleting it", disk.Disk.Name, names)
+	}
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+	err = retryCall(timeout, func() *resource.RetryError {
+		task, err := disk.Delete()
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error deleting disk: %#v", err))
+		}
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing tasks: %#v", err)
+	}
+
+	return nil
+}
+
+func validateDiskBusType(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if _, ok := diskBusTypes[value]; !ok {
+		errors = append(errors, fmt.Errorf("%q must be one of IDE, SCSI or SATA, got %q", k, value))
+	}
+	return
+}
@@ -0,0 +1,98 @@
+package vcd
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVcdIndependentDisk_Basic(t *testing.T) {
+	var disk independentDisk
+	generatedHrefRegexp := regexp.MustCompile("^https://")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdIndependentDiskDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdIndependentDisk_basic, 1024),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdIndependentDiskExists("vcd_independent_disk.foodisk", &disk),
+					resource.TestCheckResourceAttr(
+						"vcd_independent_disk.foodisk", "size", "1024"),
+					resource.TestCheckResourceAttr(
+						"vcd_independent_disk.foodisk", "bus_sub_type", "lsilogic"),
+					resource.TestMatchResourceAttr(
+						"vcd_independent_disk.foodisk", "href", generatedHrefRegexp),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdIndependentDisk_basic, 2048),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdIndependentDiskExists("vcd_independent_disk.foodisk", &disk),
+					resource.TestCheckResourceAttr(
+						"vcd_independent_disk.foodisk", "size", "2048"),
+				),
+			},
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckVcdIndependentDisk_basic, 1024),
+				ExpectError: regexp.MustCompile("cannot shrink"),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdIndependentDiskExists(n string, disk *independentDisk) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No disk ID is set")
+		}
+
+		conn := testAccProvider.Meta().(*VCDClient)
+
+		resp, err := conn.findDiskByHREF(rs.Primary.ID)
+		if err != nil {
+			return fmt.Errorf("Disk does not exist.")
+		}
+
+		*disk = resp
+
+		return nil
+	}
+}
+
+func testAccCheckVcdIndependentDiskDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_independent_disk" {
+			continue
+		}
+
+		_, err := conn.findDiskByHREF(rs.Primary.ID)
+
+		if err == nil {
+			return fmt.Errorf("Disk still exists.")
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+const testAccCheckVcdIndependentDisk_basic = `
+resource "vcd_independent_disk" "foodisk" {
+	name = "foodisk"
+	size = %d
+}
+`
@@ -0,0 +1,249 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+func resourceVcdInsertedMedia() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdInsertedMediaCreate,
+		Read:   resourceVcdInsertedMediaRead,
+		Update: resourceVcdInsertedMediaUpdate,
+		Delete: resourceVcdInsertedMediaDelete,
+
+		Timeouts: resourceTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"catalog": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The name of the media item in the catalog",
+			},
+
+			"vapp_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"vm_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"force": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Eject any media already inserted in the VM instead of failing",
+			},
+		},
+	}
+}
+
+func resourceVcdInsertedMediaCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	media, err := findCatalogMedia(vcdClient, d.Get("catalog").(string), d.Get("name").(string))
+	if err != nil {
+		return err
+	}
+
+	vm, err := findInsertedMediaVM(vcdClient, d)
+	if err != nil {
+		return err
+	}
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutCreate)
+
+	if inserted := vm.InsertedMedia(); len(inserted) > 0 {
+		if !d.Get("force").(bool) {
+			return fmt.Errorf("VM %s already has media %v inserted, eject it or set force", d.Get("vm_name").(string), inserted)
+		}
+
+		for _, name := range inserted {
+			ref, err := findMediaByName(vcdClient, name)
+			if err != nil {
+				return err
+			}
+
+			err = retryCall(timeout, func() *resource.RetryError {
+				task, err := vm.EjectMedia(ref)
+				if err != nil {
+					return resource.RetryableError(fmt.Errorf("Error ejecting media %s: %#v", name, err))
+				}
+				return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+			})
+			if err != nil {
+				return fmt.Errorf("Error completing tasks: %#v", err)
+			}
+		}
+	}
+
+	err = retryCall(timeout, func() *resource.RetryError {
+		task, err := vm.InsertMedia(media)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error inserting media: %#v", err))
+		}
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing tasks: %#v", err)
+	}
+
+	d.SetId(vm.VM.HREF + ":" + media.HREF)
+
+	return resourceVcdInsertedMediaRead(d, meta)
+}
+
+func resourceVcdInsertedMediaRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vm, err := findInsertedMediaVM(vcdClient, d)
+	if err != nil {
+		log.Printf("[DEBUG] VM no longer exists. Removing inserted media from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	// vCD only reports the names of the inserted media, so they are looked
+	// up to tell the media of the ID from others with the same name
+	mediaHREF := insertedMediaHREF(d.Id())
+	var found bool
+	for _, name := range vm.InsertedMedia() {
+		records, err := vcdClient.queryMedia(fmt.Sprintf("name==%s", queryFilterValue(name)))
+		if err != nil {
+			return fmt.Errorf("Error querying media: %#v", err)
+		}
+		for _, record := range records {
+			if record.HREF == mediaHREF {
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		log.Printf("[DEBUG] Media is no longer inserted. Removing from tfstate")
+		d.SetId("")
+	}
+
+	return nil
+}
+
+// Only force can change in place, and it is only used on create
+func resourceVcdInsertedMediaUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceVcdInsertedMediaRead(d, meta)
+}
+
+func resourceVcdInsertedMediaDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	media, err := findCatalogMedia(vcdClient, d.Get("catalog").(string), d.Get("name").(string))
+	if err != nil {
+		return err
+	}
+
+	vm, err := findInsertedMediaVM(vcdClient, d)
+	if err != nil {
+		return err
+	}
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+	err = retryCall(timeout, func() *resource.RetryError {
+		task, err := vm.EjectMedia(media)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error ejecting media: %#v", err))
+		}
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing tasks: %#v", err)
+	}
+
+	return nil
+}
+
+func findInsertedMediaVM(vcdClient *VCDClient, d *schema.ResourceData) (vcdVM, error) {
+	vapp, err := vcdClient.findVAppByName(d.Get("vapp_name").(string))
+	if err != nil {
+		return vcdVM{}, fmt.Errorf("Error finding vApp: %#v", err)
+	}
+
+	vm, err := vcdClient.findVMByName(vapp, d.Get("vm_name").(string))
+	if err != nil {
+		return vcdVM{}, fmt.Errorf("Error finding VM: %#v", err)
+	}
+
+	return vm, nil
+}
+
+// insertedMediaHREF returns the href of the media from the ID of an
+// inserted media, which is the href of the VM followed by the one of the
+// media.
+func insertedMediaHREF(id string) string {
+	if i := strings.LastIndex(id, ":http"); i >= 0 {
+		return id[i+1:]
+	}
+	return ""
+}
+
+// findCatalogMedia returns a reference to the media behind the named item of
+// the catalog.
+func findCatalogMedia(vcdClient *VCDClient, catalogName, name string) (*types.Reference, error) {
+	catalog, err := vcdClient.findCatalog(catalogName)
+	if err != nil {
+		return nil, fmt.Errorf("Error finding catalog: %#v", err)
+	}
+
+	item, err := catalog.FindCatalogItem(name)
+	if err != nil {
+		return nil, fmt.Errorf("Error finding catalog item: %#v", err)
+	}
+
+	if item.CatalogItem.Entity == nil || item.CatalogItem.Entity.Type != "application/vnd.vmware.vcloud.media+xml" {
+		return nil, fmt.Errorf("Catalog item %s in catalog %s is not a media item", name, catalogName)
+	}
+
+	return &types.Reference{
+		HREF: item.CatalogItem.Entity.HREF,
+		Name: item.CatalogItem.Entity.Name,
+		Type: item.CatalogItem.Entity.Type,
+	}, nil
+}
+
+// findMediaByName looks up media which may be in any catalog, such as media
+// inserted outside of Terraform.
+func findMediaByName(vcdClient *VCDClient, name string) (*types.Reference, error) {
+	records, err := vcdClient.queryMedia(fmt.Sprintf("name==%s", queryFilterValue(name)))
+	if err != nil {
+		return nil, fmt.Errorf("Error querying media: %#v", err)
+	}
+
+	if len(records) != 1 {
+		return nil, fmt.Errorf("Unable to identify inserted media %s, found %d media with that name", name, len(records))
+	}
+
+	return &types.Reference{
+		HREF: records[0].HREF,
+		Name: records[0].Name,
+		Type: "application/vnd.vmware.vcloud.media+xml",
+	}, nil
+}
@@ -0,0 +1,133 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVcdInsertedMedia_Basic(t *testing.T) {
+	if v := os.Getenv("VCD_MEDIA"); v == "" {
+		t.Skip("Environment variable VCD_MEDIA must be set to the name of a media item in the test catalog to run inserted media tests")
+		return
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdInsertedMediaDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdInsertedMedia_basic, os.Getenv("VCD_EDGE_GATEWAY"), os.Getenv("VCD_MEDIA")),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdInsertedMediaExists("vcd_inserted_media.iso"),
+					resource.TestCheckResourceAttr(
+						"vcd_inserted_media.iso", "vm_name", "moo"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdInsertedMediaExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No inserted media ID is set")
+		}
+
+		conn := testAccProvider.Meta().(*VCDClient)
+
+		vapp, err := conn.findVAppByName(rs.Primary.Attributes["vapp_name"])
+		if err != nil {
+			return err
+		}
+
+		vm, err := conn.findVMByName(vapp, rs.Primary.Attributes["vm_name"])
+		if err != nil {
+			return err
+		}
+
+		for _, name := range vm.InsertedMedia() {
+			if name == rs.Primary.Attributes["name"] {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("Media %s is not inserted in VM %s", rs.Primary.Attributes["name"], rs.Primary.Attributes["vm_name"])
+	}
+}
+
+func testAccCheckVcdInsertedMediaDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_inserted_media" {
+			continue
+		}
+
+		vapp, err := conn.findVAppByName(rs.Primary.Attributes["vapp_name"])
+		if err != nil {
+			return nil
+		}
+
+		vm, err := conn.findVMByName(vapp, rs.Primary.Attributes["vm_name"])
+		if err != nil {
+			return nil
+		}
+
+		for _, name := range vm.InsertedMedia() {
+			if name == rs.Primary.Attributes["name"] {
+				return fmt.Errorf("Media %s is still inserted", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+const testAccCheckVcdInsertedMedia_basic = `
+resource "vcd_network" "foonet" {
+	name = "foonet"
+	edge_gateway = "%s"
+	gateway = "10.10.102.1"
+	static_ip_pool {
+		start_address = "10.10.102.2"
+		end_address = "10.10.102.254"
+	}
+}
+
+resource "vcd_vapp" "foobar" {
+  name          = "foobar"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  catalog_name  = "Skyscape Catalogue"
+  network_name  = "${vcd_network.foonet.name}"
+  memory        = 1024
+  cpus          = 1
+  ip            = "10.10.102.160"
+}
+
+resource "vcd_vapp_vm" "moo" {
+  vapp_name     = "${vcd_vapp.foobar.name}"
+  name          = "moo"
+  catalog_name  = "Skyscape Catalogue"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  memory        = 1024
+  cpus          = 1
+  ip            = "10.10.102.161"
+}
+
+resource "vcd_inserted_media" "iso" {
+  catalog   = "Skyscape Catalogue"
+  name      = "%s"
+  vapp_name = "${vcd_vapp.foobar.name}"
+  vm_name   = "${vcd_vapp_vm.moo.name}"
+}
+`
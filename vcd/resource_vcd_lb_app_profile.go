@@ -0,0 +1,300 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceVcdLbAppProfile() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdLbAppProfileCreate,
+		Read:   resourceVcdLbAppProfileRead,
+		Update: resourceVcdLbAppProfileUpdate,
+		Delete: resourceVcdLbAppProfileDelete,
+
+		Timeouts: resourceTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"edge_gateway": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"type": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateLbProtocol,
+			},
+
+			"persistence": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"method": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateLbPersistenceMethod,
+						},
+
+						"cookie_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+
+						"cookie_mode": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							ValidateFunc: validateLbCookieMode,
+						},
+					},
+				},
+			},
+
+			"enable_ssl_passthrough": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+
+			"insert_xforwarded_for": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+func resourceVcdLbAppProfileCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	profile := expandLbAppProfile(d)
+	if err := checkLbAppProfile(profile); err != nil {
+		return err
+	}
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	log.Printf("[INFO] LB APPLICATION PROFILE: %#v", profile)
+
+	var id string
+	attempted := false
+	err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutCreate), func() *resource.RetryError {
+		// A failed attempt may still have created the application
+		// profile, whose name is unique on the edge gateway
+		if attempted {
+			existing, err := edgeGateway.FindLbAppProfileByName(profile.Name)
+			if err != nil {
+				return resource.RetryableError(fmt.Errorf("Error reading application profiles: %#v", err))
+			}
+			if existing != nil {
+				id = existing.ID
+				return nil
+			}
+		}
+		attempted = true
+
+		id, err = edgeGateway.CreateLbAppProfile(profile)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error creating application profile: %#v", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(id)
+
+	return resourceVcdLbAppProfileRead(d, meta)
+}
+
+func resourceVcdLbAppProfileRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	profile, err := edgeGateway.GetLbAppProfile(d.Id())
+	if err != nil {
+		return fmt.Errorf("Error reading application profile: %#v", err)
+	}
+
+	if profile == nil {
+		log.Printf("[DEBUG] Application profile no longer exists. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", profile.Name)
+	d.Set("type", strings.ToLower(profile.Template))
+	d.Set("enable_ssl_passthrough", profile.SslPassthrough)
+	d.Set("insert_xforwarded_for", profile.InsertXForwardedFor)
+
+	persistence := []map[string]interface{}{}
+	if p := profile.Persistence; p != nil && p.Method != "" {
+		persistence = append(persistence, map[string]interface{}{
+			"method":      p.Method,
+			"cookie_name": p.CookieName,
+			"cookie_mode": p.CookieMode,
+		})
+	}
+	d.Set("persistence", persistence)
+
+	return nil
+}
+
+func resourceVcdLbAppProfileUpdate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	profile := expandLbAppProfile(d)
+	profile.ID = d.Id()
+	if err := checkLbAppProfile(profile); err != nil {
+		return err
+	}
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutUpdate), func() *resource.RetryError {
+		if err := edgeGateway.UpdateLbAppProfile(profile); err != nil {
+			return resource.RetryableError(fmt.Errorf("Error updating application profile: %#v", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdLbAppProfileRead(d, meta)
+}
+
+func resourceVcdLbAppProfileDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	return retryCall(vcdClient.retryTimeout(d, schema.TimeoutDelete), func() *resource.RetryError {
+		if err := edgeGateway.DeleteLbAppProfile(d.Id()); err != nil {
+			return resource.RetryableError(fmt.Errorf("Error deleting application profile: %#v", err))
+		}
+		return nil
+	})
+}
+
+func expandLbAppProfile(d *schema.ResourceData) *lbAppProfileType {
+	profile := &lbAppProfileType{
+		Name: d.Get("name").(string),
+		// NSX expects HTTP, HTTPS or TCP
+		Template:            strings.ToUpper(d.Get("type").(string)),
+		SslPassthrough:      d.Get("enable_ssl_passthrough").(bool),
+		InsertXForwardedFor: d.Get("insert_xforwarded_for").(bool),
+	}
+
+	if p := d.Get("persistence").([]interface{}); len(p) > 0 && p[0] != nil {
+		data := p[0].(map[string]interface{})
+		profile.Persistence = &lbAppProfilePersistenceType{
+			Method:     data["method"].(string),
+			CookieName: data["cookie_name"].(string),
+			CookieMode: data["cookie_mode"].(string),
+		}
+	}
+
+	return profile
+}
+
+// checkLbAppProfile rejects the settings, and the persistence methods, which
+// do not apply to the type of the profile.
+func checkLbAppProfile(profile *lbAppProfileType) error {
+	profileType := strings.ToLower(profile.Template)
+
+	if profile.SslPassthrough && profileType != "https" {
+		return fmt.Errorf("enable_ssl_passthrough can only be set for https profiles, not %s", profileType)
+	}
+	if profile.InsertXForwardedFor && profileType == "tcp" {
+		return fmt.Errorf("insert_xforwarded_for can only be set for http and https profiles, not %s", profileType)
+	}
+
+	p := profile.Persistence
+	if p == nil {
+		return nil
+	}
+
+	switch p.Method {
+	case "cookie":
+		if profileType == "tcp" || profile.SslPassthrough {
+			return fmt.Errorf("cookie persistence needs the HTTP traffic to be readable, so it is only allowed for http profiles and https profiles without ssl passthrough")
+		}
+		if p.CookieName == "" {
+			return fmt.Errorf("cookie_name must be set for cookie persistence")
+		}
+	case "ssl_sessionid":
+		if !profile.SslPassthrough {
+			return fmt.Errorf("ssl_sessionid persistence is only allowed for https profiles with enable_ssl_passthrough")
+		}
+	case "msrdp":
+		if profileType != "tcp" {
+			return fmt.Errorf("msrdp persistence is only allowed for tcp profiles, not %s", profileType)
+		}
+	}
+
+	if p.Method != "cookie" && (p.CookieName != "" || p.CookieMode != "") {
+		return fmt.Errorf("cookie_name and cookie_mode can only be set for cookie persistence, not %s", p.Method)
+	}
+
+	return nil
+}
+
+func validateLbPersistenceMethod(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	switch value {
+	case "cookie", "ssl_sessionid", "sourceip", "msrdp":
+	default:
+		errors = append(errors, fmt.Errorf("%q must be one of cookie, ssl_sessionid, sourceip or msrdp, got %q", k, value))
+	}
+	return
+}
+
+func validateLbCookieMode(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	switch value {
+	case "insert", "prefix", "app":
+	default:
+		errors = append(errors, fmt.Errorf("%q must be one of insert, prefix or app, got %q", k, value))
+	}
+	return
+}
@@ -0,0 +1,130 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVcdLbAppProfile_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdLbAppProfileDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdLbAppProfile_basic, os.Getenv("VCD_EDGE_GATEWAY"), "insert"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdLbAppProfileExists("vcd_lb_app_profile.fooprofile"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_app_profile.fooprofile", "type", "http"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_app_profile.fooprofile", "persistence.0.cookie_name", "JSESSIONID"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_app_profile.fooprofile", "persistence.0.cookie_mode", "insert"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdLbAppProfile_basic, os.Getenv("VCD_EDGE_GATEWAY"), "app"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdLbAppProfileExists("vcd_lb_app_profile.fooprofile"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_app_profile.fooprofile", "persistence.0.cookie_mode", "app"),
+				),
+			},
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckVcdLbAppProfile_tcpCookie, os.Getenv("VCD_EDGE_GATEWAY")),
+				ExpectError: regexp.MustCompile("cookie persistence needs the HTTP traffic to be readable"),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdLbAppProfileExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No application profile ID is set")
+		}
+
+		conn := testAccProvider.Meta().(*VCDClient)
+
+		edgeGateway, err := conn.findEdgeGateway(rs.Primary.Attributes["edge_gateway"])
+		if err != nil {
+			return fmt.Errorf("Could not find edge gateway: %s", err)
+		}
+
+		profile, err := edgeGateway.GetLbAppProfile(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		if profile == nil {
+			return fmt.Errorf("Application profile was not found")
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckVcdLbAppProfileDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_lb_app_profile" {
+			continue
+		}
+
+		edgeGateway, err := conn.findEdgeGateway(rs.Primary.Attributes["edge_gateway"])
+		if err != nil {
+			return fmt.Errorf("Could not find edge gateway: %s", err)
+		}
+
+		profile, err := edgeGateway.GetLbAppProfile(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		if profile != nil {
+			return fmt.Errorf("Application profile still exists")
+		}
+	}
+
+	return nil
+}
+
+const testAccCheckVcdLbAppProfile_basic = `
+resource "vcd_lb_app_profile" "fooprofile" {
+	edge_gateway          = "%s"
+	name                  = "fooprofile"
+	type                  = "http"
+	insert_xforwarded_for = true
+
+	persistence {
+		method      = "cookie"
+		cookie_name = "JSESSIONID"
+		cookie_mode = "%s"
+	}
+}
+`
+
+const testAccCheckVcdLbAppProfile_tcpCookie = `
+resource "vcd_lb_app_profile" "fooprofile" {
+	edge_gateway = "%s"
+	name         = "fooprofile"
+	type         = "tcp"
+
+	persistence {
+		method      = "cookie"
+		cookie_name = "JSESSIONID"
+	}
+}
+`
@@ -0,0 +1,280 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceVcdLbServerPool() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdLbServerPoolCreate,
+		Read:   resourceVcdLbServerPoolRead,
+		Update: resourceVcdLbServerPoolUpdate,
+		Delete: resourceVcdLbServerPoolDelete,
+
+		Timeouts: resourceTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"edge_gateway": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"algorithm": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "round-robin",
+				ValidateFunc: validateLbAlgorithm,
+			},
+
+			"monitor_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"member": &schema.Schema{
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Computed: true,
+						},
+
+						"ip_address": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"port": &schema.Schema{
+							Type:     schema.TypeInt,
+							Required: true,
+						},
+
+						"monitor_port": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Computed: true,
+						},
+
+						"weight": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Default:  1,
+						},
+
+						"condition": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "enabled",
+							ValidateFunc: validateLbMemberCondition,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceVcdLbServerPoolCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	pool := expandLbServerPool(d)
+
+	log.Printf("[INFO] LB SERVER POOL: %#v", pool)
+
+	var id string
+	attempted := false
+	err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutCreate), func() *resource.RetryError {
+		// A failed attempt may still have created the pool, whose name is
+		// unique on the edge gateway
+		if attempted {
+			existing, err := edgeGateway.FindLbServerPoolByName(pool.Name)
+			if err != nil {
+				return resource.RetryableError(fmt.Errorf("Error reading server pools: %#v", err))
+			}
+			if existing != nil {
+				id = existing.ID
+				return nil
+			}
+		}
+		attempted = true
+
+		id, err = edgeGateway.CreateLbServerPool(pool)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error creating server pool: %#v", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(id)
+
+	return resourceVcdLbServerPoolRead(d, meta)
+}
+
+func resourceVcdLbServerPoolRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	pool, err := edgeGateway.GetLbServerPool(d.Id())
+	if err != nil {
+		return fmt.Errorf("Error reading server pool: %#v", err)
+	}
+
+	if pool == nil {
+		log.Printf("[DEBUG] Server pool no longer exists. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", pool.Name)
+	d.Set("algorithm", pool.Algorithm)
+	d.Set("monitor_id", pool.MonitorID)
+	d.Set("member", flattenLbPoolMembers(pool.Member))
+
+	return nil
+}
+
+func resourceVcdLbServerPoolUpdate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	pool := expandLbServerPool(d)
+	pool.ID = d.Id()
+
+	err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutUpdate), func() *resource.RetryError {
+		if err := edgeGateway.UpdateLbServerPool(pool); err != nil {
+			return resource.RetryableError(fmt.Errorf("Error updating server pool: %#v", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdLbServerPoolRead(d, meta)
+}
+
+func resourceVcdLbServerPoolDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	return retryCall(vcdClient.retryTimeout(d, schema.TimeoutDelete), func() *resource.RetryError {
+		if err := edgeGateway.DeleteLbServerPool(d.Id()); err != nil {
+			return resource.RetryableError(fmt.Errorf("Error deleting server pool: %#v", err))
+		}
+		return nil
+	})
+}
+
+func expandLbServerPool(d *schema.ResourceData) *lbPoolType {
+	pool := &lbPoolType{
+		Name:      d.Get("name").(string),
+		Algorithm: d.Get("algorithm").(string),
+		MonitorID: d.Get("monitor_id").(string),
+	}
+
+	for _, m := range d.Get("member").([]interface{}) {
+		data := m.(map[string]interface{})
+
+		member := &lbPoolMemberType{
+			Name:        data["name"].(string),
+			IPAddress:   data["ip_address"].(string),
+			Port:        data["port"].(int),
+			MonitorPort: data["monitor_port"].(int),
+			Weight:      data["weight"].(int),
+			Condition:   data["condition"].(string),
+		}
+		if member.Name == "" {
+			member.Name = fmt.Sprintf("%s:%d", member.IPAddress, member.Port)
+		}
+
+		pool.Member = append(pool.Member, member)
+	}
+
+	return pool
+}
+
+func flattenLbPoolMembers(members []*lbPoolMemberType) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(members))
+	for _, member := range members {
+		monitorPort := member.MonitorPort
+		if monitorPort == 0 {
+			monitorPort = member.Port
+		}
+
+		result = append(result, map[string]interface{}{
+			"name":         member.Name,
+			"ip_address":   member.IPAddress,
+			"port":         member.Port,
+			"monitor_port": monitorPort,
+			"weight":       member.Weight,
+			"condition":    member.Condition,
+		})
+	}
+	return result
+}
+
+func validateLbAlgorithm(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	switch value {
+	case "round-robin", "ip-hash", "leastconn", "uri":
+	default:
+		errors = append(errors, fmt.Errorf("%q must be one of round-robin, ip-hash, leastconn or uri, got %q", k, value))
+	}
+	return
+}
+
+func validateLbMemberCondition(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	switch value {
+	case "enabled", "disabled", "drain":
+	default:
+		errors = append(errors, fmt.Errorf("%q must be one of enabled, disabled or drain, got %q", k, value))
+	}
+	return
+}
@@ -0,0 +1,117 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVcdLbServerPool_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdLbServerPoolDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdLbServerPool_basic, os.Getenv("VCD_EDGE_GATEWAY"), 1),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdLbServerPoolExists("vcd_lb_server_pool.foopool"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_server_pool.foopool", "member.#", "2"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_server_pool.foopool", "member.0.name", "10.10.102.11:80"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_server_pool.foopool", "member.0.monitor_port", "80"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdLbServerPool_basic, os.Getenv("VCD_EDGE_GATEWAY"), 3),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdLbServerPoolExists("vcd_lb_server_pool.foopool"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_server_pool.foopool", "member.1.weight", "3"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdLbServerPoolExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No server pool ID is set")
+		}
+
+		conn := testAccProvider.Meta().(*VCDClient)
+
+		edgeGateway, err := conn.findEdgeGateway(rs.Primary.Attributes["edge_gateway"])
+		if err != nil {
+			return fmt.Errorf("Could not find edge gateway: %s", err)
+		}
+
+		pool, err := edgeGateway.GetLbServerPool(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		if pool == nil {
+			return fmt.Errorf("Server pool was not found")
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckVcdLbServerPoolDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_lb_server_pool" {
+			continue
+		}
+
+		edgeGateway, err := conn.findEdgeGateway(rs.Primary.Attributes["edge_gateway"])
+		if err != nil {
+			return fmt.Errorf("Could not find edge gateway: %s", err)
+		}
+
+		pool, err := edgeGateway.GetLbServerPool(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		if pool != nil {
+			return fmt.Errorf("Server pool still exists")
+		}
+	}
+
+	return nil
+}
+
+const testAccCheckVcdLbServerPool_basic = `
+resource "vcd_lb_server_pool" "foopool" {
+	edge_gateway = "%s"
+	name         = "foopool"
+	algorithm    = "leastconn"
+
+	member {
+		ip_address = "10.10.102.11"
+		port       = 80
+	}
+
+	member {
+		name       = "second"
+		ip_address = "10.10.102.12"
+		port       = 80
+		weight     = %d
+	}
+}
+`
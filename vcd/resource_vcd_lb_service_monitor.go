@@ -0,0 +1,268 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceVcdLbServiceMonitor() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdLbServiceMonitorCreate,
+		Read:   resourceVcdLbServiceMonitorRead,
+		Update: resourceVcdLbServiceMonitorUpdate,
+		Delete: resourceVcdLbServiceMonitorDelete,
+
+		Timeouts: resourceTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"edge_gateway": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"type": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateLbMonitorType,
+			},
+
+			"interval": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  10,
+			},
+
+			"timeout": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  15,
+			},
+
+			"max_retries": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  3,
+			},
+
+			"url": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"method": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateLbMonitorMethod,
+			},
+
+			"expected": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"send": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"receive": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+		},
+	}
+}
+
+func resourceVcdLbServiceMonitorCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	monitor := expandLbServiceMonitor(d)
+	if err := checkLbServiceMonitor(monitor); err != nil {
+		return err
+	}
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	log.Printf("[INFO] LB SERVICE MONITOR: %#v", monitor)
+
+	var id string
+	attempted := false
+	err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutCreate), func() *resource.RetryError {
+		// A failed attempt may still have created the service monitor,
+		// whose name is unique on the edge gateway
+		if attempted {
+			existing, err := edgeGateway.FindLbServiceMonitorByName(monitor.Name)
+			if err != nil {
+				return resource.RetryableError(fmt.Errorf("Error reading service monitors: %#v", err))
+			}
+			if existing != nil {
+				id = existing.ID
+				return nil
+			}
+		}
+		attempted = true
+
+		id, err = edgeGateway.CreateLbServiceMonitor(monitor)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error creating service monitor: %#v", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(id)
+
+	return resourceVcdLbServiceMonitorRead(d, meta)
+}
+
+func resourceVcdLbServiceMonitorRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	monitor, err := edgeGateway.GetLbServiceMonitor(d.Id())
+	if err != nil {
+		return fmt.Errorf("Error reading service monitor: %#v", err)
+	}
+
+	if monitor == nil {
+		log.Printf("[DEBUG] Service monitor no longer exists. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", monitor.Name)
+	d.Set("type", monitor.Type)
+	d.Set("interval", monitor.Interval)
+	d.Set("timeout", monitor.Timeout)
+	d.Set("max_retries", monitor.MaxRetries)
+	d.Set("url", monitor.URL)
+	d.Set("method", monitor.Method)
+	d.Set("expected", monitor.Expected)
+	d.Set("send", monitor.Send)
+	d.Set("receive", monitor.Receive)
+
+	return nil
+}
+
+func resourceVcdLbServiceMonitorUpdate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	monitor := expandLbServiceMonitor(d)
+	monitor.ID = d.Id()
+	if err := checkLbServiceMonitor(monitor); err != nil {
+		return err
+	}
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutUpdate), func() *resource.RetryError {
+		if err := edgeGateway.UpdateLbServiceMonitor(monitor); err != nil {
+			return resource.RetryableError(fmt.Errorf("Error updating service monitor: %#v", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdLbServiceMonitorRead(d, meta)
+}
+
+func resourceVcdLbServiceMonitorDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	return retryCall(vcdClient.retryTimeout(d, schema.TimeoutDelete), func() *resource.RetryError {
+		if err := edgeGateway.DeleteLbServiceMonitor(d.Id()); err != nil {
+			return resource.RetryableError(fmt.Errorf("Error deleting service monitor: %#v", err))
+		}
+		return nil
+	})
+}
+
+func expandLbServiceMonitor(d *schema.ResourceData) *lbMonitorType {
+	return &lbMonitorType{
+		Name:       d.Get("name").(string),
+		Type:       d.Get("type").(string),
+		Interval:   d.Get("interval").(int),
+		Timeout:    d.Get("timeout").(int),
+		MaxRetries: d.Get("max_retries").(int),
+		URL:        d.Get("url").(string),
+		Method:     d.Get("method").(string),
+		Expected:   d.Get("expected").(string),
+		Send:       d.Get("send").(string),
+		Receive:    d.Get("receive").(string),
+	}
+}
+
+// checkLbServiceMonitor rejects the fields which do not apply to the type of
+// the monitor. Terraform cannot compare fields when planning, so this is done
+// before any change is made.
+func checkLbServiceMonitor(monitor *lbMonitorType) error {
+	if monitor.Type != "http" && monitor.Type != "https" {
+		if monitor.URL != "" || monitor.Method != "" || monitor.Expected != "" {
+			return fmt.Errorf("url, method and expected can only be set for http and https monitors, not %s", monitor.Type)
+		}
+	}
+	if monitor.Type == "icmp" && (monitor.Send != "" || monitor.Receive != "") {
+		return fmt.Errorf("send and receive cannot be set for icmp monitors")
+	}
+	return nil
+}
+
+func validateLbMonitorType(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	switch value {
+	case "http", "https", "tcp", "icmp":
+	default:
+		errors = append(errors, fmt.Errorf("%q must be one of http, https, tcp or icmp, got %q", k, value))
+	}
+	return
+}
+
+func validateLbMonitorMethod(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	switch value {
+	case "GET", "POST", "OPTIONS":
+	default:
+		errors = append(errors, fmt.Errorf("%q must be one of GET, POST or OPTIONS, got %q", k, value))
+	}
+	return
+}
@@ -0,0 +1,134 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVcdLbServiceMonitor_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdLbServiceMonitorDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdLbServiceMonitor_basic, os.Getenv("VCD_EDGE_GATEWAY"), 10),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdLbServiceMonitorExists("vcd_lb_service_monitor.foomonitor"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_service_monitor.foomonitor", "url", "/health"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_service_monitor.foomonitor", "interval", "10"),
+					resource.TestCheckResourceAttrPair(
+						"vcd_lb_server_pool.foopool", "monitor_id", "vcd_lb_service_monitor.foomonitor", "id"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdLbServiceMonitor_basic, os.Getenv("VCD_EDGE_GATEWAY"), 30),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdLbServiceMonitorExists("vcd_lb_service_monitor.foomonitor"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_service_monitor.foomonitor", "interval", "30"),
+				),
+			},
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckVcdLbServiceMonitor_tcpURL, os.Getenv("VCD_EDGE_GATEWAY")),
+				ExpectError: regexp.MustCompile("url, method and expected can only be set for http and https monitors"),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdLbServiceMonitorExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No service monitor ID is set")
+		}
+
+		conn := testAccProvider.Meta().(*VCDClient)
+
+		edgeGateway, err := conn.findEdgeGateway(rs.Primary.Attributes["edge_gateway"])
+		if err != nil {
+			return fmt.Errorf("Could not find edge gateway: %s", err)
+		}
+
+		monitor, err := edgeGateway.GetLbServiceMonitor(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		if monitor == nil {
+			return fmt.Errorf("Service monitor was not found")
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckVcdLbServiceMonitorDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_lb_service_monitor" {
+			continue
+		}
+
+		edgeGateway, err := conn.findEdgeGateway(rs.Primary.Attributes["edge_gateway"])
+		if err != nil {
+			return fmt.Errorf("Could not find edge gateway: %s", err)
+		}
+
+		monitor, err := edgeGateway.GetLbServiceMonitor(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		if monitor != nil {
+			return fmt.Errorf("Service monitor still exists")
+		}
+	}
+
+	return nil
+}
+
+const testAccCheckVcdLbServiceMonitor_basic = `
+resource "vcd_lb_service_monitor" "foomonitor" {
+	edge_gateway = "%[1]s"
+	name         = "foomonitor"
+	type         = "http"
+	interval     = %[2]d
+	method       = "GET"
+	url          = "/health"
+	expected     = "HTTP/1.1 200"
+}
+
+resource "vcd_lb_server_pool" "foopool" {
+	edge_gateway = "%[1]s"
+	name         = "foomonitorpool"
+	monitor_id   = "${vcd_lb_service_monitor.foomonitor.id}"
+
+	member {
+		ip_address = "10.10.102.11"
+		port       = 80
+	}
+}
+`
+
+const testAccCheckVcdLbServiceMonitor_tcpURL = `
+resource "vcd_lb_service_monitor" "foomonitor" {
+	edge_gateway = "%s"
+	name         = "foomonitor"
+	type         = "tcp"
+	url          = "/health"
+}
+`
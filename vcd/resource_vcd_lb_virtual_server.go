@@ -0,0 +1,260 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceVcdLbVirtualServer() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdLbVirtualServerCreate,
+		Read:   resourceVcdLbVirtualServerRead,
+		Update: resourceVcdLbVirtualServerUpdate,
+		Delete: resourceVcdLbVirtualServerDelete,
+
+		Timeouts: resourceTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"edge_gateway": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"ip_address": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"protocol": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateLbProtocol,
+			},
+
+			"port": &schema.Schema{
+				Type:     schema.TypeInt,
+				Required: true,
+			},
+
+			"server_pool_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"app_profile_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+
+			"enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+		},
+	}
+}
+
+func resourceVcdLbVirtualServerCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	server := expandLbVirtualServer(d)
+
+	if err := checkLbVirtualServer(edgeGateway, server); err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] LB VIRTUAL SERVER: %#v", server)
+
+	var id string
+	attempted := false
+	err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutCreate), func() *resource.RetryError {
+		// A failed attempt may still have created the virtual server, whose
+		// name is unique on the edge gateway
+		if attempted {
+			existing, err := edgeGateway.FindLbVirtualServerByName(server.Name)
+			if err != nil {
+				return resource.RetryableError(fmt.Errorf("Error reading virtual servers: %#v", err))
+			}
+			if existing != nil {
+				id = existing.ID
+				return nil
+			}
+		}
+		attempted = true
+
+		id, err = edgeGateway.CreateLbVirtualServer(server)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error creating virtual server: %#v", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(id)
+
+	return resourceVcdLbVirtualServerRead(d, meta)
+}
+
+func resourceVcdLbVirtualServerRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(d.Get("edge_gateway").(string))
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	server, err := edgeGateway.GetLbVirtualServer(d.Id())
+	if err != nil {
+		return fmt.Errorf("Error reading virtual server: %#v", err)
+	}
+
+	if server == nil {
+		log.Printf("[DEBUG] Virtual server no longer exists. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", server.Name)
+	d.Set("ip_address", server.IPAddress)
+	d.Set("protocol", server.Protocol)
+	d.Set("port", server.Port)
+	d.Set("server_pool_id", server.DefaultPoolID)
+	d.Set("app_profile_id", server.ApplicationProfileID)
+	d.Set("enabled", server.Enabled)
+
+	return nil
+}
+
+func resourceVcdLbVirtualServerUpdate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	server := expandLbVirtualServer(d)
+	server.ID = d.Id()
+
+	if err := checkLbVirtualServer(edgeGateway, server); err != nil {
+		return err
+	}
+
+	err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutUpdate), func() *resource.RetryError {
+		if err := edgeGateway.UpdateLbVirtualServer(server); err != nil {
+			return resource.RetryableError(fmt.Errorf("Error updating virtual server: %#v", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return resourceVcdLbVirtualServerRead(d, meta)
+}
+
+func resourceVcdLbVirtualServerDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.findEdgeGateway(edgeGatewayName)
+	if err != nil {
+		return fmt.Errorf("Unable to find edge gateway: %#v", err)
+	}
+
+	return retryCall(vcdClient.retryTimeout(d, schema.TimeoutDelete), func() *resource.RetryError {
+		if err := edgeGateway.DeleteLbVirtualServer(d.Id()); err != nil {
+			return resource.RetryableError(fmt.Errorf("Error deleting virtual server: %#v", err))
+		}
+		return nil
+	})
+}
+
+func expandLbVirtualServer(d *schema.ResourceData) *lbVirtualServerType {
+	return &lbVirtualServerType{
+		Name:                 d.Get("name").(string),
+		Enabled:              d.Get("enabled").(bool),
+		IPAddress:            d.Get("ip_address").(string),
+		Protocol:             d.Get("protocol").(string),
+		Port:                 d.Get("port").(int),
+		ApplicationProfileID: d.Get("app_profile_id").(string),
+		DefaultPoolID:        d.Get("server_pool_id").(string),
+	}
+}
+
+// checkLbVirtualServer verifies that the pool and application profile of the
+// virtual server exist on the edge gateway, and that no other virtual server
+// listens on the same address and port. The referenced ids are usually not
+// known when planning, so this is done before creating or updating instead.
+func checkLbVirtualServer(edgeGateway vcdEdgeGateway, server *lbVirtualServerType) error {
+	name := edgeGateway.EdgeGateway.Name
+
+	if server.DefaultPoolID != "" {
+		pool, err := edgeGateway.GetLbServerPool(server.DefaultPoolID)
+		if err != nil {
+			return fmt.Errorf("Error reading server pool: %#v", err)
+		}
+		if pool == nil {
+			return fmt.Errorf("Server pool %s not found on edge gateway %s", server.DefaultPoolID, name)
+		}
+	}
+
+	profile, err := edgeGateway.GetLbAppProfile(server.ApplicationProfileID)
+	if err != nil {
+		return fmt.Errorf("Error reading application profile: %#v", err)
+	}
+	if profile == nil {
+		return fmt.Errorf("Application profile %s not found on edge gateway %s", server.ApplicationProfileID, name)
+	}
+
+	servers, err := edgeGateway.GetLbVirtualServers()
+	if err != nil {
+		return fmt.Errorf("Error reading virtual servers: %#v", err)
+	}
+	for _, s := range servers {
+		if s.ID != server.ID && s.IPAddress == server.IPAddress && s.Port == server.Port {
+			return fmt.Errorf("IP address %s port %d is already in use by virtual server %s on edge gateway %s",
+				server.IPAddress, server.Port, s.Name, name)
+		}
+	}
+
+	return nil
+}
+
+func validateLbProtocol(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	switch value {
+	case "http", "https", "tcp":
+	default:
+		errors = append(errors, fmt.Errorf("%q must be one of http, https or tcp, got %q", k, value))
+	}
+	return
+}
@@ -0,0 +1,180 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVcdLbVirtualServer_Basic(t *testing.T) {
+	if v := os.Getenv("VCD_EXTERNAL_IP"); v == "" {
+		t.Skip("Environment variable VCD_EXTERNAL_IP must be set to run load balancer virtual server tests")
+		return
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdLbVirtualServerDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdLbVirtualServer_basic, os.Getenv("VCD_EDGE_GATEWAY"), os.Getenv("VCD_EXTERNAL_IP"), true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdLbVirtualServerExists("vcd_lb_virtual_server.foovs"),
+					resource.TestCheckResourceAttrPair(
+						"vcd_lb_virtual_server.foovs", "server_pool_id", "vcd_lb_server_pool.foopool", "id"),
+					resource.TestCheckResourceAttrPair(
+						"vcd_lb_virtual_server.foovs", "app_profile_id", "vcd_lb_app_profile.fooprofile", "id"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdLbVirtualServer_basic, os.Getenv("VCD_EDGE_GATEWAY"), os.Getenv("VCD_EXTERNAL_IP"), false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdLbVirtualServerExists("vcd_lb_virtual_server.foovs"),
+					resource.TestCheckResourceAttr(
+						"vcd_lb_virtual_server.foovs", "enabled", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVcdLbVirtualServer_MissingProfile(t *testing.T) {
+	if v := os.Getenv("VCD_EXTERNAL_IP"); v == "" {
+		t.Skip("Environment variable VCD_EXTERNAL_IP must be set to run load balancer virtual server tests")
+		return
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdLbVirtualServerDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckVcdLbVirtualServer_missingProfile, os.Getenv("VCD_EDGE_GATEWAY"), os.Getenv("VCD_EXTERNAL_IP")),
+				ExpectError: regexp.MustCompile("Application profile applicationProfile-999 not found"),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdLbVirtualServerExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No virtual server ID is set")
+		}
+
+		conn := testAccProvider.Meta().(*VCDClient)
+
+		edgeGateway, err := conn.findEdgeGateway(rs.Primary.Attributes["edge_gateway"])
+		if err != nil {
+			return fmt.Errorf("Could not find edge gateway: %s", err)
+		}
+
+		server, err := edgeGateway.GetLbVirtualServer(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		if server == nil {
+			return fmt.Errorf("Virtual server was not found")
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckVcdLbVirtualServerDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_lb_virtual_server" {
+			continue
+		}
+
+		edgeGateway, err := conn.findEdgeGateway(rs.Primary.Attributes["edge_gateway"])
+		if err != nil {
+			return fmt.Errorf("Could not find edge gateway: %s", err)
+		}
+
+		server, err := edgeGateway.GetLbVirtualServer(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		if server != nil {
+			return fmt.Errorf("Virtual server still exists")
+		}
+	}
+
+	return nil
+}
+
+const testAccCheckVcdLbVirtualServer_basic = `
+resource "vcd_lb_service_monitor" "foomonitor" {
+	edge_gateway = "%[1]s"
+	name         = "foovsmonitor"
+	type         = "http"
+	method       = "GET"
+	url          = "/"
+}
+
+resource "vcd_lb_server_pool" "foopool" {
+	edge_gateway = "%[1]s"
+	name         = "foovspool"
+	monitor_id   = "${vcd_lb_service_monitor.foomonitor.id}"
+
+	member {
+		ip_address = "10.10.102.11"
+		port       = 80
+	}
+}
+
+resource "vcd_lb_app_profile" "fooprofile" {
+	edge_gateway = "%[1]s"
+	name         = "foovsprofile"
+	type         = "http"
+}
+
+resource "vcd_lb_virtual_server" "foovs" {
+	edge_gateway   = "%[1]s"
+	name           = "foovs"
+	ip_address     = "%[2]s"
+	protocol       = "http"
+	port           = 80
+	server_pool_id = "${vcd_lb_server_pool.foopool.id}"
+	app_profile_id = "${vcd_lb_app_profile.fooprofile.id}"
+	enabled        = %[3]t
+}
+`
+
+const testAccCheckVcdLbVirtualServer_missingProfile = `
+resource "vcd_lb_server_pool" "foopool" {
+	edge_gateway = "%[1]s"
+	name         = "foovspool"
+
+	member {
+		ip_address = "10.10.102.11"
+		port       = 80
+	}
+}
+
+resource "vcd_lb_virtual_server" "foovs" {
+	edge_gateway   = "%[1]s"
+	name           = "foovs"
+	ip_address     = "%[2]s"
+	protocol       = "http"
+	port           = 80
+	server_pool_id = "${vcd_lb_server_pool.foopool.id}"
+	app_profile_id = "applicationProfile-999"
+}
+`
@@ -5,11 +5,13 @@ import (
 
 	"bytes"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/hashicorp/terraform/helper/hashcode"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/ukcloud/govcloudair"
 	types "github.com/ukcloud/govcloudair/types/v56"
 )
 
@@ -18,6 +20,11 @@ func resourceVcdNetwork() *schema.Resource {
 		Create: resourceVcdNetworkCreate,
 		Read:   resourceVcdNetworkRead,
 		Delete: resourceVcdNetworkDelete,
+		Importer: &schema.ResourceImporter{
+			State: resourceVcdNetworkImport,
+		},
+
+		Timeouts: resourceTimeouts(),
 
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
@@ -26,16 +33,32 @@ func resourceVcdNetwork() *schema.Resource {
 				ForceNew: true,
 			},
 
+			"type": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"fence_mode"},
+				ValidateFunc:  validateNetworkType,
+			},
+
 			"fence_mode": &schema.Schema{
+				Type:       schema.TypeString,
+				Optional:   true,
+				Computed:   true,
+				ForceNew:   true,
+				Deprecated: "Use type instead",
+			},
+
+			"edge_gateway": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
 				ForceNew: true,
-				Default:  "natRouted",
 			},
 
-			"edge_gateway": &schema.Schema{
+			"external_network": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
 				ForceNew: true,
 			},
 
@@ -48,7 +71,7 @@ func resourceVcdNetwork() *schema.Resource {
 
 			"gateway": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
 				ForceNew: true,
 			},
 
@@ -124,13 +147,15 @@ func resourceVcdNetwork() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"start_address": &schema.Schema{
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateIPv4Address,
 						},
 
 						"end_address": &schema.Schema{
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateIPv4Address,
 						},
 					},
 				},
@@ -146,37 +171,65 @@ func resourceVcdNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient.Mutex.Lock()
 	defer vcdClient.Mutex.Unlock()
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
-
-	ipRanges := expandIPRange(d.Get("static_ip_pool").(*schema.Set).List())
+	networkType := d.Get("type").(string)
+	if networkType == "" {
+		networkType = vcdNetworkType(d.Get("fence_mode").(string))
+	}
+	if err := checkNetworkType(d, networkType); err != nil {
+		return err
+	}
 
 	newnetwork := &types.OrgVDCNetwork{
 		Xmlns: "http://www.vmware.com/vcloud/v1.5",
 		Name:  d.Get("name").(string),
 		Configuration: &types.NetworkConfiguration{
-			FenceMode: d.Get("fence_mode").(string),
-			IPScopes: &types.IPScopes{
-				IPScope: types.IPScope{
-					IsInherited: false,
-					Gateway:     d.Get("gateway").(string),
-					Netmask:     d.Get("netmask").(string),
-					DNS1:        d.Get("dns1").(string),
-					DNS2:        d.Get("dns2").(string),
-					DNSSuffix:   d.Get("dns_suffix").(string),
-					IPRanges:    &ipRanges,
-				},
-			},
+			FenceMode:                 networkFenceModes[networkType],
 			BackwardCompatibilityMode: true,
 		},
-		EdgeGateway: &types.Reference{
-			HREF: edgeGateway.EdgeGateway.HREF,
-		},
 		IsShared: d.Get("shared").(bool),
 	}
 
+	var edgeGateway govcd.EdgeGateway
+	var err error
+	if name := d.Get("edge_gateway").(string); name != "" {
+		edgeGateway, err = vcdClient.OrgVdc.FindEdgeGateway(name)
+		if err != nil {
+			return fmt.Errorf("Unable to find edge gateway: %#v", err)
+		}
+		newnetwork.EdgeGateway = &types.Reference{
+			HREF: edgeGateway.EdgeGateway.HREF,
+		}
+	}
+
+	if networkType == "direct" {
+		// The IP settings of direct networks are those of the external
+		// network
+		ref, err := externalNetworkReference(vcdClient, d.Get("external_network").(string))
+		if err != nil {
+			return err
+		}
+		newnetwork.Configuration.ParentNetwork = ref
+	} else {
+		ipRanges := expandIPRange(d.Get("static_ip_pool").(*schema.Set).List())
+		if err := checkIPRanges(ipRanges); err != nil {
+			return fmt.Errorf("Invalid static_ip_pool: %s", err)
+		}
+		newnetwork.Configuration.IPScopes = &types.IPScopes{
+			IPScope: types.IPScope{
+				IsInherited: false,
+				Gateway:     d.Get("gateway").(string),
+				Netmask:     d.Get("netmask").(string),
+				DNS1:        d.Get("dns1").(string),
+				DNS2:        d.Get("dns2").(string),
+				DNSSuffix:   d.Get("dns_suffix").(string),
+				IPRanges:    &ipRanges,
+			},
+		}
+	}
+
 	log.Printf("[INFO] NETWORK: %#v", newnetwork)
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+	err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutCreate), func() *resource.RetryError {
 		return resource.RetryableError(vcdClient.OrgVdc.CreateOrgVDCNetwork(newnetwork))
 	})
 	if err != nil {
@@ -194,13 +247,25 @@ func resourceVcdNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 
 	if dhcp, ok := d.GetOk("dhcp_pool"); ok {
-		err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+		// The other edge gateway resources only take the lock of the edge
+		// gateway, so the global lock does not keep them from editing it
+		edgeGatewayName := edgeGateway.EdgeGateway.Name
+		edgeGatewayMutexKV.Lock(edgeGatewayName)
+		defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+		timeout := vcdClient.retryTimeout(d, schema.TimeoutCreate)
+		err = retryCall(timeout, func() *resource.RetryError {
+			// AddDhcpPool keeps the other pools of the edge gateway as it
+			// last read them
+			if err := edgeGateway.Refresh(); err != nil {
+				return resource.RetryableError(fmt.Errorf("Error refreshing edge gateway: %#v", err))
+			}
 			task, err := edgeGateway.AddDhcpPool(network.OrgVDCNetwork, dhcp.(*schema.Set).List())
 			if err != nil {
 				return resource.RetryableError(fmt.Errorf("Error adding DHCP pool: %#v", err))
 			}
 
-			return resource.RetryableError(task.WaitTaskCompletion())
+			return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 		})
 		if err != nil {
 			return fmt.Errorf("Error completing tasks: %#v", err)
@@ -208,7 +273,7 @@ func resourceVcdNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 
 	}
 
-	d.SetId(d.Get("name").(string))
+	d.SetId(network.OrgVDCNetwork.HREF)
 
 	return resourceVcdNetworkRead(d, meta)
 }
@@ -223,28 +288,152 @@ func resourceVcdNetworkRead(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error refreshing vdc: %#v", err)
 	}
 
-	network, err := vcdClient.OrgVdc.FindVDCNetwork(d.Id())
+	network, err := findVDCNetwork(vcdClient, d.Id())
 	if err != nil {
 		log.Printf("[DEBUG] Network no longer exists. Removing from tfstate")
 		d.SetId("")
 		return nil
 	}
 
+	// Earlier versions used the name as the ID
+	d.SetId(network.OrgVDCNetwork.HREF)
 	d.Set("name", network.OrgVDCNetwork.Name)
 	d.Set("href", network.OrgVDCNetwork.HREF)
+	d.Set("shared", network.OrgVDCNetwork.IsShared)
 	if c := network.OrgVDCNetwork.Configuration; c != nil {
+		d.Set("type", vcdNetworkType(c.FenceMode))
 		d.Set("fence_mode", c.FenceMode)
-		if c.IPScopes != nil {
+		if c.FenceMode == networkFenceModes["direct"] {
+			// The IP scope is inherited from the external network, so the
+			// arguments were not used
+			if c.ParentNetwork != nil {
+				d.Set("external_network", c.ParentNetwork.Name)
+			}
+		} else if c.IPScopes != nil {
 			d.Set("gateway", c.IPScopes.IPScope.Gateway)
 			d.Set("netmask", c.IPScopes.IPScope.Netmask)
 			d.Set("dns1", c.IPScopes.IPScope.DNS1)
 			d.Set("dns2", c.IPScopes.IPScope.DNS2)
+			d.Set("dns_suffix", c.IPScopes.IPScope.DNSSuffix)
+			if c.IPScopes.IPScope.IPRanges != nil {
+				d.Set("static_ip_pool", flattenIPRange(c.IPScopes.IPScope.IPRanges))
+			}
+		}
+	}
+
+	if network.OrgVDCNetwork.EdgeGateway != nil {
+		edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(network.OrgVDCNetwork.EdgeGateway.Name)
+		if err != nil {
+			return fmt.Errorf("Unable to find edge gateway: %#v", err)
+		}
+		d.Set("edge_gateway", edgeGateway.EdgeGateway.Name)
+
+		// The pools of the network may also be managed with
+		// vcd_edgegateway_dhcp, so only the pools the configuration
+		// declares are read back. A declared pool which is gone replaces
+		// the network.
+		live := flattenNetworkDhcpPools(edgeGateway, network)
+		declared := d.Get("dhcp_pool").(*schema.Set).List()
+		dhcpPools := make([]map[string]interface{}, 0, len(declared))
+		for _, p := range declared {
+			data := p.(map[string]interface{})
+			for _, pool := range live {
+				if pool["start_address"] == data["start_address"] && pool["end_address"] == data["end_address"] {
+					dhcpPools = append(dhcpPools, pool)
+					break
+				}
+			}
 		}
+		d.Set("dhcp_pool", dhcpPools)
 	}
 
 	return nil
 }
 
+func resourceVcdNetworkImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	vcdClient := meta.(*VCDClient)
+
+	err := vcdClient.OrgVdc.Refresh()
+	if err != nil {
+		return nil, fmt.Errorf("Error refreshing vdc: %#v", err)
+	}
+
+	var matches []*types.Reference
+	for _, an := range vcdClient.OrgVdc.Vdc.AvailableNetworks {
+		for _, n := range an.Network {
+			if n.Name == d.Id() {
+				matches = append(matches, n)
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("Unable to find network %s in VDC %s", d.Id(), vcdClient.OrgVdc.Vdc.Name)
+	}
+	if len(matches) > 1 {
+		return nil, fmt.Errorf("Found %d networks named %s in VDC %s", len(matches), d.Id(), vcdClient.OrgVdc.Vdc.Name)
+	}
+
+	d.SetId(matches[0].HREF)
+	d.Set("name", matches[0].Name)
+
+	// Read only keeps the declared DHCP pools, so an imported network
+	// starts out with all of them
+	network, err := vcdClient.OrgVdc.FindVDCNetwork(matches[0].Name)
+	if err != nil {
+		return nil, fmt.Errorf("Error finding network: %#v", err)
+	}
+	if network.OrgVDCNetwork.EdgeGateway != nil {
+		edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(network.OrgVDCNetwork.EdgeGateway.Name)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to find edge gateway: %#v", err)
+		}
+		d.Set("dhcp_pool", flattenNetworkDhcpPools(edgeGateway, network))
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// flattenNetworkDhcpPools returns the DHCP pools the edge gateway has for the
+// network.
+func flattenNetworkDhcpPools(edgeGateway govcd.EdgeGateway, network govcd.OrgVDCNetwork) []map[string]interface{} {
+	pools := make([]map[string]interface{}, 0)
+
+	c := edgeGateway.EdgeGateway.Configuration
+	if c == nil || c.EdgeGatewayServiceConfiguration == nil || c.EdgeGatewayServiceConfiguration.GatewayDhcpService == nil {
+		return pools
+	}
+
+	for _, pool := range c.EdgeGatewayServiceConfiguration.GatewayDhcpService.Pool {
+		if pool.Network == nil || pool.Network.HREF != network.OrgVDCNetwork.HREF {
+			continue
+		}
+		pools = append(pools, map[string]interface{}{
+			"start_address":      pool.LowIPAddress,
+			"end_address":        pool.HighIPAddress,
+			"default_lease_time": pool.DefaultLeaseTime,
+			"max_lease_time":     pool.MaxLeaseTime,
+		})
+	}
+
+	return pools
+}
+
+// findVDCNetwork looks a network up by its href, falling back to the
+// name that was used as the resource ID by earlier versions
+func findVDCNetwork(vcdClient *VCDClient, id string) (govcd.OrgVDCNetwork, error) {
+	uuid := uuidFromHref(id)
+	for _, an := range vcdClient.OrgVdc.Vdc.AvailableNetworks {
+		for _, n := range an.Network {
+			if uuid != "" && uuidFromHref(n.HREF) == uuid {
+				return vcdClient.OrgVdc.FindVDCNetwork(n.Name)
+			}
+		}
+	}
+
+	return vcdClient.OrgVdc.FindVDCNetwork(id)
+}
+
 func resourceVcdNetworkDelete(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
 	vcdClient.Mutex.Lock()
@@ -254,18 +443,19 @@ func resourceVcdNetworkDelete(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error refreshing vdc: %#v", err)
 	}
 
-	network, err := vcdClient.OrgVdc.FindVDCNetwork(d.Id())
+	network, err := findVDCNetwork(vcdClient, d.Id())
 	if err != nil {
 		return fmt.Errorf("Error finding network: %#v", err)
 	}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+	err = retryCall(timeout, func() *resource.RetryError {
 		task, err := network.Delete()
 		if err != nil {
 			return resource.RetryableError(
 				fmt.Errorf("Error Deleting Network: %#v", err))
 		}
-		return resource.RetryableError(task.WaitTaskCompletion())
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 	})
 	if err != nil {
 		return err
@@ -274,6 +464,93 @@ func resourceVcdNetworkDelete(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
+// networkFenceModes maps the network types to the vCD fence modes.
+var networkFenceModes = map[string]string{
+	"routed":   "natRouted",
+	"isolated": "isolated",
+	"direct":   "bridged",
+}
+
+// vcdNetworkType returns the network type of a vCD fence mode, routed by
+// default.
+func vcdNetworkType(fenceMode string) string {
+	for networkType, mode := range networkFenceModes {
+		if mode == fenceMode {
+			return networkType
+		}
+	}
+	return "routed"
+}
+
+// checkNetworkType verifies that the arguments needed by the network type
+// are set, and that those which do not apply to it are not. Terraform cannot
+// compare fields when planning, so this is done before creating the network.
+func checkNetworkType(d *schema.ResourceData, networkType string) error {
+	_, hasDhcpPool := d.GetOk("dhcp_pool")
+	_, hasStaticIPPool := d.GetOk("static_ip_pool")
+	edgeGateway := d.Get("edge_gateway").(string)
+	externalNetwork := d.Get("external_network").(string)
+	gateway := d.Get("gateway").(string)
+
+	switch networkType {
+	case "direct":
+		if externalNetwork == "" {
+			return fmt.Errorf("external_network must be set for direct networks")
+		}
+		if edgeGateway != "" || gateway != "" || hasDhcpPool || hasStaticIPPool {
+			return fmt.Errorf("edge_gateway, gateway, dhcp_pool and static_ip_pool cannot be set for direct networks, which use the settings of external network %s", externalNetwork)
+		}
+	case "routed":
+		if edgeGateway == "" || gateway == "" {
+			return fmt.Errorf("edge_gateway and gateway must be set for routed networks")
+		}
+	default:
+		if gateway == "" {
+			return fmt.Errorf("gateway must be set for %s networks", networkType)
+		}
+	}
+
+	if externalNetwork != "" && networkType != "direct" {
+		return fmt.Errorf("external_network can only be set for direct networks, not %s", networkType)
+	}
+	if hasDhcpPool && edgeGateway == "" {
+		return fmt.Errorf("dhcp_pool needs edge_gateway to be set, as the edge gateway serves the DHCP pools")
+	}
+
+	return nil
+}
+
+// externalNetworkReference returns a reference to the named external
+// network which can be used as the parent of an org VDC network.
+func externalNetworkReference(vcdClient *VCDClient, name string) (*types.Reference, error) {
+	network, err := vcdClient.findExternalNetwork(name)
+	if err != nil {
+		return nil, fmt.Errorf("Error finding external network %s: %#v", name, err)
+	}
+
+	// External networks are listed under the extension API, while org
+	// networks refer to them as networks
+	u, err := url.ParseRequestURI(network.HREF)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding external network href: %#v", err)
+	}
+	u.Path = "/api/admin/network/" + uuidFromHref(network.HREF)
+
+	return &types.Reference{
+		HREF: u.String(),
+		Name: network.Name,
+		Type: "application/vnd.vmware.admin.network+xml",
+	}, nil
+}
+
+func validateNetworkType(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if _, ok := networkFenceModes[value]; !ok {
+		errors = append(errors, fmt.Errorf("%q must be one of routed, isolated or direct, got %q", k, value))
+	}
+	return
+}
+
 func resourceVcdNetworkIPAddressHash(v interface{}) int {
 	var buf bytes.Buffer
 	m := v.(map[string]interface{})
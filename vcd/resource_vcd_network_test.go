@@ -35,6 +35,132 @@ func TestAccVcdNetwork_Basic(t *testing.T) {
 						"vcd_network.foonet", "href", generatedHrefRegexp),
 				),
 			},
+			resource.TestStep{
+				ResourceName:      "vcd_network.foonet",
+				ImportState:       true,
+				ImportStateId:     "foonet",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccVcdNetwork_StaticIPPools(t *testing.T) {
+	var network govcd.OrgVDCNetwork
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdNetworkDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckVcdNetwork_staticIPPools, os.Getenv("VCD_EDGE_GATEWAY"), "10.10.102.50"),
+				ExpectError: regexp.MustCompile("IP ranges .* overlap"),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdNetwork_staticIPPools, os.Getenv("VCD_EDGE_GATEWAY"), "10.10.102.150"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdNetworkExists("vcd_network.foonet", &network),
+					testAccCheckVcdNetworkIPRanges(&network, 2),
+					resource.TestCheckResourceAttr(
+						"vcd_network.foonet", "static_ip_pool.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdNetworkIPRanges(network *govcd.OrgVDCNetwork, count int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		c := network.OrgVDCNetwork.Configuration
+		if c == nil || c.IPScopes == nil || c.IPScopes.IPScope.IPRanges == nil {
+			return fmt.Errorf("Network %s has no IP ranges", network.OrgVDCNetwork.Name)
+		}
+		if got := len(c.IPScopes.IPScope.IPRanges.IPRange); got != count {
+			return fmt.Errorf("Network %s has %d IP ranges, expected %d", network.OrgVDCNetwork.Name, got, count)
+		}
+		return nil
+	}
+}
+
+func TestAccVcdNetwork_DNSDrift(t *testing.T) {
+	var network govcd.OrgVDCNetwork
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdNetworkDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdNetwork_basic, os.Getenv("VCD_EDGE_GATEWAY")),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdNetworkExists("vcd_network.foonet", &network),
+					resource.TestCheckResourceAttr(
+						"vcd_network.foonet", "dns1", "8.8.8.8"),
+					testAccCheckVcdNetworkChangeDNS(&network, "1.1.1.1", "1.0.0.1"),
+				),
+				// The refresh before the final plan must pick up the DNS
+				// servers changed outside of Terraform
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+// testAccCheckVcdNetworkChangeDNS changes the DNS servers of the network
+// through the API, as another vCD user would.
+func testAccCheckVcdNetworkChangeDNS(network *govcd.OrgVDCNetwork, dns1, dns2 string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		c := network.OrgVDCNetwork.Configuration
+		if c == nil || c.IPScopes == nil {
+			return fmt.Errorf("Network %s has no IP scope", network.OrgVDCNetwork.Name)
+		}
+		c.IPScopes.IPScope.DNS1 = dns1
+		c.IPScopes.IPScope.DNS2 = dns2
+
+		conn := testAccProvider.Meta().(*VCDClient)
+
+		task, err := conn.updateNetwork(network.OrgVDCNetwork)
+		if err != nil {
+			return fmt.Errorf("Error updating network: %#v", err)
+		}
+		return task.WaitTaskCompletion()
+	}
+}
+
+func TestAccVcdNetwork_Direct(t *testing.T) {
+	if v := os.Getenv("VCD_SYSTEM_ADMIN"); v == "" {
+		t.Skip("Environment variable VCD_SYSTEM_ADMIN must be set to run direct network tests")
+		return
+	}
+	if v := os.Getenv("VCD_EXTERNAL_NETWORK"); v == "" {
+		t.Skip("Environment variable VCD_EXTERNAL_NETWORK must be set to run direct network tests")
+		return
+	}
+
+	var network govcd.OrgVDCNetwork
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdNetworkDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config:      testAccCheckVcdNetwork_directWithoutExternal,
+				ExpectError: regexp.MustCompile("external_network must be set for direct networks"),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdNetwork_direct, os.Getenv("VCD_EXTERNAL_NETWORK")),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdNetworkExists("vcd_network.directnet", &network),
+					resource.TestCheckResourceAttr(
+						"vcd_network.directnet", "type", "direct"),
+					resource.TestCheckResourceAttr(
+						"vcd_network.directnet", "fence_mode", "bridged"),
+					resource.TestCheckResourceAttr(
+						"vcd_network.directnet", "external_network", os.Getenv("VCD_EXTERNAL_NETWORK")),
+				),
+			},
 		},
 	})
 }
@@ -52,7 +178,7 @@ func testAccCheckVcdNetworkExists(n string, network *govcd.OrgVDCNetwork) resour
 
 		conn := testAccProvider.Meta().(*VCDClient)
 
-		resp, err := conn.OrgVdc.FindVDCNetwork(rs.Primary.ID)
+		resp, err := findVDCNetwork(conn, rs.Primary.ID)
 		if err != nil {
 			return fmt.Errorf("Network does not exist.")
 		}
@@ -71,7 +197,7 @@ func testAccCheckVcdNetworkDestroy(s *terraform.State) error {
 			continue
 		}
 
-		_, err := conn.OrgVdc.FindVDCNetwork(rs.Primary.ID)
+		_, err := findVDCNetwork(conn, rs.Primary.ID)
 
 		if err == nil {
 			return fmt.Errorf("Network still exists.")
@@ -105,3 +231,34 @@ resource "vcd_network" "foonet" {
 	}
 }
 `
+
+const testAccCheckVcdNetwork_direct = `
+resource "vcd_network" "directnet" {
+	name = "directnet"
+	type = "direct"
+	external_network = "%s"
+}
+`
+
+const testAccCheckVcdNetwork_directWithoutExternal = `
+resource "vcd_network" "directnet" {
+	name = "directnet"
+	type = "direct"
+}
+`
+
+const testAccCheckVcdNetwork_staticIPPools = `
+resource "vcd_network" "foonet" {
+	name = "foonet"
+	edge_gateway = "%s"
+	gateway = "10.10.102.1"
+	static_ip_pool {
+		start_address = "10.10.102.10"
+		end_address = "10.10.102.99"
+	}
+	static_ip_pool {
+		start_address = "%s"
+		end_address = "10.10.102.199"
+	}
+}
+`
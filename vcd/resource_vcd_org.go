@@ -0,0 +1,194 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceVcdOrg() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdOrgCreate,
+		Read:   resourceVcdOrgRead,
+		Update: resourceVcdOrgUpdate,
+		Delete: resourceVcdOrgDelete,
+
+		Timeouts: resourceTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"full_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"is_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"deployed_vm_quota": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "Maximum number of deployed VMs, 0 meaning unlimited",
+			},
+
+			"stored_vm_quota": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "Maximum number of stored VMs, 0 meaning unlimited",
+			},
+
+			"delete_force": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Disable the org before deleting it, as vCD refuses to delete an enabled org",
+			},
+		},
+	}
+}
+
+func resourceVcdOrgCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	neworg := &adminOrgType{
+		Name:        d.Get("name").(string),
+		FullName:    d.Get("full_name").(string),
+		Description: d.Get("description").(string),
+		IsEnabled:   d.Get("is_enabled").(bool),
+		Settings: &orgSettingsType{
+			OrgGeneralSettings: &orgGeneralSettingsType{
+				DeployedVMQuota: d.Get("deployed_vm_quota").(int),
+				StoredVMQuota:   d.Get("stored_vm_quota").(int),
+			},
+		},
+	}
+
+	log.Printf("[INFO] ORG: %#v", neworg)
+
+	org, err := vcdClient.createOrg(neworg, vcdClient.retryTimeout(d, schema.TimeoutCreate))
+	if err != nil {
+		if strings.Contains(err.Error(), "API Error: 403") {
+			return fmt.Errorf("Error creating org %s, system administrator credentials are required: %#v", neworg.Name, err)
+		}
+		return fmt.Errorf("Error creating org %s: %#v", neworg.Name, err)
+	}
+
+	d.SetId(org.AdminOrg.HREF)
+
+	return resourceVcdOrgRead(d, meta)
+}
+
+func resourceVcdOrgRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	org, err := vcdClient.getAdminOrg(d.Id())
+	if err != nil {
+		log.Printf("[DEBUG] Org no longer exists. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", org.AdminOrg.Name)
+	d.Set("full_name", org.AdminOrg.FullName)
+	d.Set("description", org.AdminOrg.Description)
+	d.Set("is_enabled", org.AdminOrg.IsEnabled)
+	if s := org.AdminOrg.Settings; s != nil && s.OrgGeneralSettings != nil {
+		d.Set("deployed_vm_quota", s.OrgGeneralSettings.DeployedVMQuota)
+		d.Set("stored_vm_quota", s.OrgGeneralSettings.StoredVMQuota)
+	}
+
+	return nil
+}
+
+func resourceVcdOrgUpdate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	org, err := vcdClient.getAdminOrg(d.Id())
+	if err != nil {
+		return fmt.Errorf("Error finding org: %#v", err)
+	}
+
+	if d.HasChange("deployed_vm_quota") || d.HasChange("stored_vm_quota") {
+		settings := &orgGeneralSettingsType{}
+		if s := org.AdminOrg.Settings; s != nil && s.OrgGeneralSettings != nil {
+			settings = s.OrgGeneralSettings
+		}
+		settings.DeployedVMQuota = d.Get("deployed_vm_quota").(int)
+		settings.StoredVMQuota = d.Get("stored_vm_quota").(int)
+
+		timeout := vcdClient.retryTimeout(d, schema.TimeoutUpdate)
+		err = retryCall(timeout, func() *resource.RetryError {
+			return resource.RetryableError(org.UpdateGeneralSettings(settings, timeout))
+		})
+		if err != nil {
+			return fmt.Errorf("Error updating org settings: %#v", err)
+		}
+	}
+
+	if d.HasChange("is_enabled") {
+		err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutUpdate), func() *resource.RetryError {
+			if d.Get("is_enabled").(bool) {
+				return resource.RetryableError(org.Enable())
+			}
+			return resource.RetryableError(org.Disable())
+		})
+		if err != nil {
+			return fmt.Errorf("Error changing org enablement: %#v", err)
+		}
+	}
+
+	return resourceVcdOrgRead(d, meta)
+}
+
+func resourceVcdOrgDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	org, err := vcdClient.getAdminOrg(d.Id())
+	if err != nil {
+		return fmt.Errorf("Error finding org: %#v", err)
+	}
+
+	if org.AdminOrg.IsEnabled {
+		if !d.Get("delete_force").(bool) {
+			return fmt.Errorf("Org %s is enabled, disable it or set delete_force before deleting it", org.AdminOrg.Name)
+		}
+
+		err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutDelete), func() *resource.RetryError {
+			return resource.RetryableError(org.Disable())
+		})
+		if err != nil {
+			return fmt.Errorf("Error disabling org: %#v", err)
+		}
+	}
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+	return retryCall(timeout, func() *resource.RetryError {
+		return resource.RetryableError(org.Delete(timeout))
+	})
+}
@@ -0,0 +1,99 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVcdOrg_Basic(t *testing.T) {
+	if v := os.Getenv("VCD_SYSTEM_ADMIN"); v == "" {
+		t.Skip("Environment variable VCD_SYSTEM_ADMIN must be set to run org tests")
+		return
+	}
+
+	var org adminOrg
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdOrgDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdOrg_basic, 10),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdOrgExists("vcd_org.fooorg", &org),
+					resource.TestCheckResourceAttr(
+						"vcd_org.fooorg", "name", "fooorg"),
+					resource.TestCheckResourceAttr(
+						"vcd_org.fooorg", "deployed_vm_quota", "10"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdOrg_basic, 20),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdOrgExists("vcd_org.fooorg", &org),
+					resource.TestCheckResourceAttr(
+						"vcd_org.fooorg", "deployed_vm_quota", "20"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdOrgExists(n string, org *adminOrg) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No org ID is set")
+		}
+
+		conn := testAccProvider.Meta().(*VCDClient)
+
+		resp, err := conn.getAdminOrg(rs.Primary.ID)
+		if err != nil {
+			return fmt.Errorf("Org does not exist.")
+		}
+
+		*org = resp
+
+		return nil
+	}
+}
+
+func testAccCheckVcdOrgDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_org" {
+			continue
+		}
+
+		_, err := conn.getAdminOrg(rs.Primary.ID)
+
+		if err == nil {
+			return fmt.Errorf("Org still exists.")
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+const testAccCheckVcdOrg_basic = `
+resource "vcd_org" "fooorg" {
+	name      = "fooorg"
+	full_name = "Terraform acceptance test org"
+
+	deployed_vm_quota = %d
+	delete_force      = true
+}
+`
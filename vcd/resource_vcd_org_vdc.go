@@ -0,0 +1,486 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+func resourceVcdOrgVdc() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdOrgVdcCreate,
+		Read:   resourceVcdOrgVdcRead,
+		Update: resourceVcdOrgVdcUpdate,
+		Delete: resourceVcdOrgVdcDelete,
+
+		Timeouts: resourceTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"org": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"allocation_model": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateAllocationModel,
+			},
+
+			"provider_vdc_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"network_pool_name": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Network pool of the provider VDC, which can be changed in place. Defaults to the one vCD picks",
+			},
+
+			"cpu_allocated": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "CPU in MHz allocated to, or reserved for, the VDC",
+			},
+
+			"memory_allocated": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "Memory in MB allocated to, or reserved for, the VDC",
+			},
+
+			"cpu_limit": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "Maximum CPU in MHz the VDC can use, 0 meaning unlimited",
+			},
+
+			"memory_limit": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "Maximum memory in MB the VDC can use, 0 meaning unlimited",
+			},
+
+			"storage_profile": &schema.Schema{
+				Type:     schema.TypeList,
+				Required: true,
+				ForceNew: true,
+				MinItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"limit": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     0,
+							Description: "Storage in MB the VDC can use from this profile, 0 meaning unlimited",
+						},
+
+						"default": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+
+						"enabled": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+					},
+				},
+			},
+
+			"enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+
+			"delete_recursive": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Remove the vApps and other objects in the VDC along with it",
+			},
+
+			"delete_force": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Remove the VDC even if its objects are in use",
+			},
+		},
+	}
+}
+
+func resourceVcdOrgVdcCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	if err := validateOrgVdcCapacity(d); err != nil {
+		return err
+	}
+
+	org, err := vcdClient.findAdminOrg(d.Get("org").(string))
+	if err != nil {
+		if strings.Contains(err.Error(), "API Error: 403") {
+			return fmt.Errorf("Error finding org %s, system administrator credentials are required: %#v", d.Get("org").(string), err)
+		}
+		return fmt.Errorf("Error finding org: %#v", err)
+	}
+
+	pvdc, err := vcdClient.findProviderVdc(d.Get("provider_vdc_name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding provider VDC: %#v", err)
+	}
+
+	params := &createVdcParamsType{
+		Name:                 d.Get("name").(string),
+		Description:          d.Get("description").(string),
+		AllocationModel:      d.Get("allocation_model").(string),
+		ComputeCapacity:      expandOrgVdcComputeCapacity(d),
+		IsEnabled:            d.Get("enabled").(bool),
+		ProviderVdcReference: &types.Reference{HREF: pvdc.ProviderVdc.HREF},
+	}
+
+	profiles := d.Get("storage_profile").([]interface{})
+	hasDefault := false
+	for _, raw := range profiles {
+		hasDefault = hasDefault || raw.(map[string]interface{})["default"].(bool)
+	}
+
+	for i, raw := range profiles {
+		profile := raw.(map[string]interface{})
+
+		ref, err := pvdc.FindStorageProfileReference(profile["name"].(string))
+		if err != nil {
+			return fmt.Errorf("Error finding storage profile: %#v", err)
+		}
+
+		params.VdcStorageProfile = append(params.VdcStorageProfile, &vdcStorageProfileParamsType{
+			Enabled: profile["enabled"].(bool),
+			Units:   "MB",
+			Limit:   int64(profile["limit"].(int)),
+			// vCD needs exactly one default, which is the first profile
+			// unless another is marked
+			Default:                   profile["default"].(bool) || (!hasDefault && i == 0),
+			ProviderVdcStorageProfile: ref,
+		})
+	}
+
+	if pool, ok := d.GetOk("network_pool_name"); ok {
+		params.NetworkPoolReference, err = pvdc.FindNetworkPoolReference(pool.(string))
+		if err != nil {
+			return fmt.Errorf("Error finding network pool: %#v", err)
+		}
+	}
+
+	log.Printf("[INFO] ORG VDC: %#v", params)
+
+	vdc, err := org.CreateVdc(params)
+	if err != nil {
+		return fmt.Errorf("Error creating VDC %s: %#v", params.Name, err)
+	}
+
+	// The VDC exists as soon as vCD accepted it, so it is recorded before
+	// waiting, and deleted on the next apply if the creation fails
+	d.SetId(vdc.AdminVdc.HREF)
+
+	if err := vdc.Wait(vcdClient.retryTimeout(d, schema.TimeoutCreate)); err != nil {
+		return fmt.Errorf("Error completing tasks: %#v", err)
+	}
+
+	return resourceVcdOrgVdcRead(d, meta)
+}
+
+func resourceVcdOrgVdcRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vdc, err := vcdClient.getAdminVdc(d.Id())
+	if err != nil {
+		log.Printf("[DEBUG] VDC no longer exists. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", vdc.AdminVdc.Name)
+	d.Set("description", vdc.AdminVdc.Description)
+	d.Set("allocation_model", vdc.AdminVdc.AllocationModel)
+	d.Set("enabled", vdc.AdminVdc.IsEnabled)
+	if c := vdc.AdminVdc.ComputeCapacity; c != nil {
+		if c.CPU != nil {
+			d.Set("cpu_allocated", c.CPU.Allocated)
+			d.Set("cpu_limit", flattenOrgVdcLimit(d, "cpu_limit", c.CPU))
+		}
+		if c.Memory != nil {
+			d.Set("memory_allocated", c.Memory.Allocated)
+			d.Set("memory_limit", flattenOrgVdcLimit(d, "memory_limit", c.Memory))
+		}
+	}
+	if vdc.AdminVdc.ProviderVdcReference != nil {
+		d.Set("provider_vdc_name", vdc.AdminVdc.ProviderVdcReference.Name)
+	}
+	if vdc.AdminVdc.NetworkPoolReference != nil {
+		d.Set("network_pool_name", vdc.AdminVdc.NetworkPoolReference.Name)
+	}
+
+	orgHREF, err := vdc.OrgHREF()
+	if err != nil {
+		return err
+	}
+	org, err := vcdClient.getAdminOrg(orgHREF)
+	if err != nil {
+		return fmt.Errorf("Error finding org: %#v", err)
+	}
+	d.Set("org", org.AdminOrg.Name)
+
+	profiles, err := vdc.StorageProfiles()
+	if err != nil {
+		return fmt.Errorf("Error reading storage profiles: %#v", err)
+	}
+	d.Set("storage_profile", flattenOrgVdcStorageProfiles(d.Get("storage_profile").([]interface{}), profiles))
+
+	return nil
+}
+
+func resourceVcdOrgVdcUpdate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	if err := validateOrgVdcCapacity(d); err != nil {
+		return err
+	}
+
+	vdc, err := vcdClient.getAdminVdc(d.Id())
+	if err != nil {
+		return fmt.Errorf("Error finding VDC: %#v", err)
+	}
+
+	changePool := d.HasChange("network_pool_name") && d.Get("network_pool_name").(string) != ""
+	if changePool {
+		pvdc, err := vcdClient.findProviderVdc(d.Get("provider_vdc_name").(string))
+		if err != nil {
+			return fmt.Errorf("Error finding provider VDC: %#v", err)
+		}
+		vdc.AdminVdc.NetworkPoolReference, err = pvdc.FindNetworkPoolReference(d.Get("network_pool_name").(string))
+		if err != nil {
+			return fmt.Errorf("Error finding network pool: %#v", err)
+		}
+	}
+
+	if changePool || d.HasChange("cpu_allocated") || d.HasChange("memory_allocated") || d.HasChange("cpu_limit") || d.HasChange("memory_limit") {
+		vdc.AdminVdc.ComputeCapacity = expandOrgVdcComputeCapacity(d)
+
+		timeout := vcdClient.retryTimeout(d, schema.TimeoutUpdate)
+		err = retryCall(timeout, func() *resource.RetryError {
+			return resource.RetryableError(vdc.Update(timeout))
+		})
+		if err != nil {
+			return fmt.Errorf("Error updating VDC: %#v", err)
+		}
+	}
+
+	if d.HasChange("enabled") {
+		err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutUpdate), func() *resource.RetryError {
+			if d.Get("enabled").(bool) {
+				return resource.RetryableError(vdc.Enable())
+			}
+			return resource.RetryableError(vdc.Disable())
+		})
+		if err != nil {
+			return fmt.Errorf("Error changing VDC enablement: %#v", err)
+		}
+	}
+
+	return resourceVcdOrgVdcRead(d, meta)
+}
+
+func resourceVcdOrgVdcDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+	vcdClient.Mutex.Lock()
+	defer vcdClient.Mutex.Unlock()
+
+	vdc, err := vcdClient.getAdminVdc(d.Id())
+	if err != nil {
+		return fmt.Errorf("Error finding VDC: %#v", err)
+	}
+
+	// vCD refuses to delete an enabled VDC
+	if vdc.AdminVdc.IsEnabled {
+		err = retryCall(vcdClient.retryTimeout(d, schema.TimeoutDelete), func() *resource.RetryError {
+			return resource.RetryableError(vdc.Disable())
+		})
+		if err != nil {
+			return fmt.Errorf("Error disabling VDC: %#v", err)
+		}
+	}
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+	return retryCall(timeout, func() *resource.RetryError {
+		err := vdc.Delete(d.Get("delete_force").(bool), d.Get("delete_recursive").(bool), timeout)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error deleting VDC: %#v", err))
+		}
+		return nil
+	})
+}
+
+// expandOrgVdcComputeCapacity builds the compute capacity of the VDC. The pool
+// models cap usage at the allocation unless a higher limit is given.
+func expandOrgVdcComputeCapacity(d *schema.ResourceData) *types.ComputeCapacity {
+	cpuLimit := d.Get("cpu_limit").(int)
+	memoryLimit := d.Get("memory_limit").(int)
+	if d.Get("allocation_model").(string) != "AllocationVApp" {
+		if cpuLimit == 0 {
+			cpuLimit = d.Get("cpu_allocated").(int)
+		}
+		if memoryLimit == 0 {
+			memoryLimit = d.Get("memory_allocated").(int)
+		}
+	}
+
+	return &types.ComputeCapacity{
+		CPU: &types.CapacityWithUsage{
+			Units:     "MHz",
+			Allocated: int64(d.Get("cpu_allocated").(int)),
+			Limit:     int64(cpuLimit),
+		},
+		Memory: &types.CapacityWithUsage{
+			Units:     "MB",
+			Allocated: int64(d.Get("memory_allocated").(int)),
+			Limit:     int64(memoryLimit),
+		},
+	}
+}
+
+// flattenOrgVdcLimit reports a limit which expandOrgVdcComputeCapacity set to
+// the allocation as unset, so leaving the limit out does not cause a diff.
+func flattenOrgVdcLimit(d *schema.ResourceData, key string, capacity *types.CapacityWithUsage) int64 {
+	if d.Get("allocation_model").(string) != "AllocationVApp" && d.Get(key).(int) == 0 && capacity.Limit == capacity.Allocated {
+		return 0
+	}
+	return capacity.Limit
+}
+
+// flattenOrgVdcStorageProfiles lists the storage profiles of the VDC in the
+// order they are declared in, followed by the ones added outside Terraform.
+// A default vCD was given because none was declared is reported as unset,
+// so leaving default out does not cause a diff.
+func flattenOrgVdcStorageProfiles(declared []interface{}, profiles []*adminVdcStorageProfileType) []map[string]interface{} {
+	var names []string
+	listed := make(map[string]bool)
+	hasDefault := false
+	for _, raw := range declared {
+		profile := raw.(map[string]interface{})
+		names = append(names, profile["name"].(string))
+		listed[profile["name"].(string)] = true
+		hasDefault = hasDefault || profile["default"].(bool)
+	}
+
+	byName := make(map[string]*adminVdcStorageProfileType)
+	for _, p := range profiles {
+		name := p.Name
+		if p.ProviderVdcStorageProfile != nil && p.ProviderVdcStorageProfile.Name != "" {
+			name = p.ProviderVdcStorageProfile.Name
+		}
+		if _, ok := byName[name]; !ok {
+			if !listed[name] {
+				names = append(names, name)
+			}
+			byName[name] = p
+		}
+	}
+
+	var flattened []map[string]interface{}
+	for i, name := range names {
+		p, ok := byName[name]
+		if !ok {
+			continue
+		}
+		flattened = append(flattened, map[string]interface{}{
+			"name":    name,
+			"limit":   int(p.Limit),
+			"default": p.Default && (hasDefault || i != 0),
+			"enabled": p.Enabled,
+		})
+	}
+	return flattened
+}
+
+// validateOrgVdcCapacity checks the compute arguments the allocation model
+// depends on, and that at most one storage profile is the default. The
+// Terraform version this provider builds against cannot validate across
+// arguments, or lists at all, during plan, so this runs before any API call.
+func validateOrgVdcCapacity(d *schema.ResourceData) error {
+	defaults := 0
+	for _, raw := range d.Get("storage_profile").([]interface{}) {
+		if raw.(map[string]interface{})["default"].(bool) {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		return fmt.Errorf("only one storage_profile can be the default, got %d", defaults)
+	}
+
+	model := d.Get("allocation_model").(string)
+
+	switch model {
+	case "AllocationPool", "ReservationPool":
+		for _, k := range []string{"cpu_allocated", "memory_allocated"} {
+			if d.Get(k).(int) <= 0 {
+				return fmt.Errorf("%s must be set for the %s allocation model", k, model)
+			}
+		}
+	}
+
+	if limit := d.Get("cpu_limit").(int); limit > 0 && limit < d.Get("cpu_allocated").(int) {
+		return fmt.Errorf("cpu_limit must not be lower than cpu_allocated")
+	}
+	if limit := d.Get("memory_limit").(int); limit > 0 && limit < d.Get("memory_allocated").(int) {
+		return fmt.Errorf("memory_limit must not be lower than memory_allocated")
+	}
+
+	return nil
+}
+
+func validateAllocationModel(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	switch value {
+	case "AllocationPool", "ReservationPool", "AllocationVApp":
+	default:
+		errors = append(errors, fmt.Errorf("%q must be one of AllocationPool, ReservationPool or AllocationVApp, got %q", k, value))
+	}
+	return
+}
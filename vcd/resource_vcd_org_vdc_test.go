@@ -0,0 +1,117 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVcdOrgVdc_Basic(t *testing.T) {
+	if v := os.Getenv("VCD_SYSTEM_ADMIN"); v == "" {
+		t.Skip("Environment variable VCD_SYSTEM_ADMIN must be set to run org VDC tests")
+		return
+	}
+
+	var vdc adminVdc
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdOrgVdcDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdOrgVdc_basic, os.Getenv("VCD_ORG"), os.Getenv("VCD_PROVIDER_VDC"),
+					2048, os.Getenv("VCD_NETWORK_POOL"), os.Getenv("VCD_PROVIDER_STORAGE_PROFILE")),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdOrgVdcExists("vcd_org_vdc.foovdc", &vdc),
+					resource.TestCheckResourceAttr(
+						"vcd_org_vdc.foovdc", "memory_allocated", "2048"),
+					resource.TestCheckResourceAttr(
+						"vcd_org_vdc.foovdc", "memory_limit", "0"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdOrgVdc_basic, os.Getenv("VCD_ORG"), os.Getenv("VCD_PROVIDER_VDC"),
+					4096, os.Getenv("VCD_NETWORK_POOL"), os.Getenv("VCD_PROVIDER_STORAGE_PROFILE")),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdOrgVdcExists("vcd_org_vdc.foovdc", &vdc),
+					resource.TestCheckResourceAttr(
+						"vcd_org_vdc.foovdc", "memory_allocated", "4096"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdOrgVdc_basic, os.Getenv("VCD_ORG"), os.Getenv("VCD_PROVIDER_VDC"),
+					0, os.Getenv("VCD_NETWORK_POOL"), os.Getenv("VCD_PROVIDER_STORAGE_PROFILE")),
+				ExpectError: regexp.MustCompile("memory_allocated must be set for the AllocationPool allocation model"),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdOrgVdcExists(n string, vdc *adminVdc) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No VDC ID is set")
+		}
+
+		conn := testAccProvider.Meta().(*VCDClient)
+
+		resp, err := conn.getAdminVdc(rs.Primary.ID)
+		if err != nil {
+			return fmt.Errorf("VDC does not exist.")
+		}
+
+		*vdc = resp
+
+		return nil
+	}
+}
+
+func testAccCheckVcdOrgVdcDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_org_vdc" {
+			continue
+		}
+
+		_, err := conn.getAdminVdc(rs.Primary.ID)
+
+		if err == nil {
+			return fmt.Errorf("VDC still exists.")
+		}
+	}
+
+	return nil
+}
+
+const testAccCheckVcdOrgVdc_basic = `
+resource "vcd_org_vdc" "foovdc" {
+	org               = "%s"
+	name              = "foovdc"
+	allocation_model  = "AllocationPool"
+	provider_vdc_name = "%s"
+
+	cpu_allocated    = 1000
+	memory_allocated = %d
+
+	network_pool_name = "%s"
+
+	storage_profile {
+		name  = "%s"
+		limit = 10240
+	}
+
+	delete_force     = true
+	delete_recursive = true
+}
+`
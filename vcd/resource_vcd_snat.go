@@ -13,6 +13,8 @@ func resourceVcdSNAT() *schema.Resource {
 		Delete: resourceVcdSNATDelete,
 		Read:   resourceVcdSNATRead,
 
+		Timeouts: resourceTimeouts(),
+
 		Schema: map[string]*schema.Schema{
 			"edge_gateway": &schema.Schema{
 				Type:     schema.TypeString,
@@ -37,29 +39,29 @@ func resourceVcdSNAT() *schema.Resource {
 
 func resourceVcdSNATCreate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
-	// Multiple VCD components need to run operations on the Edge Gateway, as
-	// the edge gatway will throw back an error if it is already performing an
-	// operation we must wait until we can aquire a lock on the client
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
+
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
 
 	// Creating a loop to offer further protection from the edge gateway erroring
 	// due to being busy eg another person is using another client so wouldn't be
 	// constrained by out lock. If the edge gateway reurns with a busy error, wait
 	// 3 seconds and then try again. Continue until a non-busy error or success
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(edgeGatewayName)
 	if err != nil {
 		return fmt.Errorf("Unable to find edge gateway: %#v", err)
 	}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutCreate)
+	err = retryCall(timeout, func() *resource.RetryError {
 		task, err := edgeGateway.AddNATMapping("SNAT", d.Get("internal_ip").(string),
 			d.Get("external_ip").(string),
 			"any")
 		if err != nil {
 			return resource.RetryableError(fmt.Errorf("Error setting SNAT rules: %#v", err))
 		}
-		return resource.RetryableError(task.WaitTaskCompletion())
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 	})
 	if err != nil {
 		return err
@@ -96,25 +98,25 @@ func resourceVcdSNATRead(d *schema.ResourceData, meta interface{}) error {
 
 func resourceVcdSNATDelete(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
-	// Multiple VCD components need to run operations on the Edge Gateway, as
-	// the edge gatway will throw back an error if it is already performing an
-	// operation we must wait until we can aquire a lock on the client
-	vcdClient.Mutex.Lock()
-	defer vcdClient.Mutex.Unlock()
 
-	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(d.Get("edge_gateway").(string))
+	edgeGatewayName := d.Get("edge_gateway").(string)
+	edgeGatewayMutexKV.Lock(edgeGatewayName)
+	defer edgeGatewayMutexKV.Unlock(edgeGatewayName)
+
+	edgeGateway, err := vcdClient.OrgVdc.FindEdgeGateway(edgeGatewayName)
 	if err != nil {
 		return fmt.Errorf("Unable to find edge gateway: %#v", err)
 	}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+	err = retryCall(timeout, func() *resource.RetryError {
 		task, err := edgeGateway.RemoveNATMapping("SNAT", d.Get("internal_ip").(string),
 			d.Get("external_ip").(string),
 			"")
 		if err != nil {
 			return resource.RetryableError(fmt.Errorf("Error setting SNAT rules: %#v", err))
 		}
-		return resource.RetryableError(task.WaitTaskCompletion())
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 	})
 	if err != nil {
 		return err
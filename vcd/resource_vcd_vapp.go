@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/ukcloud/govcloudair"
 	types "github.com/ukcloud/govcloudair/types/v56"
 )
 
@@ -16,6 +17,8 @@ func resourceVcdVApp() *schema.Resource {
 		Read:   resourceVcdVAppRead,
 		Delete: resourceVcdVAppDelete,
 
+		Timeouts: resourceTimeouts(),
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
@@ -80,6 +83,21 @@ func resourceVcdVApp() *schema.Resource {
 				Optional: true,
 				Default:  true,
 			},
+			"shutdown_guest": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"shutdown_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      300,
+				ValidateFunc: validateShutdownTimeout,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 		},
 	}
 }
@@ -90,7 +108,7 @@ func resourceVcdVAppCreate(d *schema.ResourceData, meta interface{}) error {
 	if _, ok := d.GetOk("template_name"); ok {
 		if _, ok := d.GetOk("catalog_name"); ok {
 
-			catalog, err := vcdClient.Org.FindCatalog(d.Get("catalog_name").(string))
+			catalog, err := vcdClient.findCatalog(d.Get("catalog_name").(string))
 			if err != nil {
 				return fmt.Errorf("Error finding catalog: %#v", err)
 			}
@@ -123,18 +141,20 @@ func resourceVcdVAppCreate(d *schema.ResourceData, meta interface{}) error {
 
 			log.Printf("storage_profile %s", storage_profile_reference)
 
-			vapp, err := vcdClient.OrgVdc.FindVAppByName(d.Get("name").(string))
+			timeout := vcdClient.retryTimeout(d, schema.TimeoutCreate)
+
+			vapp, err := vcdClient.findVAppByName(d.Get("name").(string))
 
 			if err != nil {
-				vapp = vcdClient.NewVApp(&vcdClient.Client)
+				vapp = vcdClient.newVApp(vcdClient.NewVApp(&vcdClient.Client))
 
-				err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+				err = retryCall(timeout, func() *resource.RetryError {
 					task, err := vapp.ComposeVApp(net, vapptemplate, storage_profile_reference, d.Get("name").(string), d.Get("description").(string))
 					if err != nil {
 						return resource.RetryableError(fmt.Errorf("Error creating vapp: %#v", err))
 					}
 
-					return resource.RetryableError(task.WaitTaskCompletion())
+					return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 				})
 
 				if err != nil {
@@ -142,66 +162,66 @@ func resourceVcdVAppCreate(d *schema.ResourceData, meta interface{}) error {
 				}
 			}
 
-			err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+			err = retryCall(timeout, func() *resource.RetryError {
 				task, err := vapp.ChangeVMName(d.Get("name").(string))
 				if err != nil {
 					return resource.RetryableError(fmt.Errorf("Error with vm name change: %#v", err))
 				}
 
-				return resource.RetryableError(task.WaitTaskCompletion())
+				return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 			})
 			if err != nil {
 				return fmt.Errorf("Error changing vmname: %#v", err)
 			}
 
-			err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+			err = retryCall(timeout, func() *resource.RetryError {
 				task, err := vapp.ChangeNetworkConfig(d.Get("network_name").(string), d.Get("ip").(string))
 				if err != nil {
 					return resource.RetryableError(fmt.Errorf("Error with Networking change: %#v", err))
 				}
-				return resource.RetryableError(task.WaitTaskCompletion())
+				return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 			})
 			if err != nil {
 				return fmt.Errorf("Error changing network: %#v", err)
 			}
 
 			if ovf, ok := d.GetOk("ovf"); ok {
-				err := retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+				err := retryCall(timeout, func() *resource.RetryError {
 					task, err := vapp.SetOvf(convertToStringMap(ovf.(map[string]interface{})))
 
 					if err != nil {
 						return resource.RetryableError(fmt.Errorf("Error set ovf: %#v", err))
 					}
-					return resource.RetryableError(task.WaitTaskCompletion())
+					return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 				})
 				if err != nil {
 					return fmt.Errorf("Error completing tasks: %#v", err)
 				}
 			}
 
-			if d.Get("power_on").(bool) == true {
-				err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-					task, err := vapp.PowerOn()
-					if err != nil {
-						return resource.RetryableError(fmt.Errorf("Error powerOn machine: %#v", err))
-					}
-					return resource.RetryableError(task.WaitTaskCompletion())
-				})
-
-				if err != nil {
-					return fmt.Errorf("Error completing powerOn tasks: %#v", err)
+			// Metadata is set before the vApp is powered on, so that it is
+			// already there when its VMs are deployed. Update only sets it
+			// again if it changed in the meantime.
+			if _, ok := d.GetOk("metadata"); ok {
+				if err := updateMetadata(vapp.c, d, &vapp, timeout); err != nil {
+					return err
 				}
 			}
 
+			err = setVAppPowerState(d, &vapp, timeout)
+			if err != nil {
+				return err
+			}
+
 			initscript := d.Get("initscript").(string)
 
-			err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+			err = retryCall(timeout, func() *resource.RetryError {
 				log.Printf("running customisation script")
 				task, err := vapp.RunCustomizationScript(d.Get("name").(string), initscript)
 				if err != nil {
 					return resource.RetryableError(fmt.Errorf("Error with setting init script: %#v", err))
 				}
-				return resource.RetryableError(task.WaitTaskCompletion())
+				return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 			})
 			if err != nil {
 				return fmt.Errorf("Error completing tasks: %#v", err)
@@ -209,7 +229,7 @@ func resourceVcdVAppCreate(d *schema.ResourceData, meta interface{}) error {
 
 		}
 	} else {
-		err := retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+		err := retryCall(vcdClient.retryTimeout(d, schema.TimeoutCreate), func() *resource.RetryError {
 			e := vcdClient.OrgVdc.ComposeRawVApp(d.Get("name").(string))
 
 			if e != nil {
@@ -227,14 +247,19 @@ func resourceVcdVAppCreate(d *schema.ResourceData, meta interface{}) error {
 		}
 	}
 
-	d.SetId(d.Get("name").(string))
+	vapp, err := vcdClient.findVAppByName(d.Get("name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding VApp: %#v", err)
+	}
+
+	d.SetId(vapp.VApp.HREF)
 
 	return resourceVcdVAppUpdate(d, meta)
 }
 
 func resourceVcdVAppUpdate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
-	vapp, err := vcdClient.OrgVdc.FindVAppByName(d.Id())
+	vapp, err := findVApp(vcdClient, d.Id())
 
 	if err != nil {
 		return fmt.Errorf("Error finding VApp: %#v", err)
@@ -245,50 +270,32 @@ func resourceVcdVAppUpdate(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error getting VApp status: %#v", err)
 	}
 
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutUpdate)
+
 	if d.HasChange("metadata") {
-		oraw, nraw := d.GetChange("metadata")
-		metadata := oraw.(map[string]interface{})
-		for k := range metadata {
-			task, err := vapp.DeleteMetadata(k)
-			if err != nil {
-				return fmt.Errorf("Error deleting metadata: %#v", err)
-			}
-			err = task.WaitTaskCompletion()
-			if err != nil {
-				return fmt.Errorf("Error completing tasks: %#v", err)
-			}
-		}
-		metadata = nraw.(map[string]interface{})
-		for k, v := range metadata {
-			task, err := vapp.AddMetadata(k, v.(string))
-			if err != nil {
-				return fmt.Errorf("Error adding metadata: %#v", err)
-			}
-			err = task.WaitTaskCompletion()
-			if err != nil {
-				return fmt.Errorf("Error completing tasks: %#v", err)
-			}
+		err = updateMetadata(vapp.c, d, &vapp, timeout)
+		if err != nil {
+			return err
 		}
-
 	}
 
 	if d.HasChange("storage_profile") {
-		err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+		err = retryCall(timeout, func() *resource.RetryError {
 			task, err := vapp.ChangeStorageProfile(d.Get("storage_profile").(string))
 			if err != nil {
 				return resource.RetryableError(fmt.Errorf("Error changing storage_profile: %#v", err))
 			}
 
-			return resource.RetryableError(task.WaitTaskCompletion())
+			return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 		})
 		if err != nil {
 			return err
 		}
 	}
 
-	if d.HasChange("memory") || d.HasChange("cpus") || d.HasChange("power_on") || d.HasChange("ovf") {
+	if d.HasChange("memory") || d.HasChange("cpus") || d.HasChange("ovf") {
 
-		if status != "POWERED_OFF" {
+		if status != "POWERED_OFF" && !shutdownVApp(d, &vapp) {
 
 			task, err := vapp.PowerOff()
 			if err != nil {
@@ -299,7 +306,7 @@ func resourceVcdVAppUpdate(d *schema.ResourceData, meta interface{}) error {
 			}
 
 			if task.Task != nil {
-				err = task.WaitTaskCompletion()
+				err = waitTask(&vcdClient.Client, task, timeout)
 				if err != nil {
 					return fmt.Errorf("Error completing tasks: %#v", err)
 				}
@@ -307,13 +314,13 @@ func resourceVcdVAppUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 
 		if d.HasChange("memory") {
-			err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+			err = retryCall(timeout, func() *resource.RetryError {
 				task, err := vapp.ChangeMemorySize(d.Get("memory").(int))
 				if err != nil {
 					return resource.RetryableError(fmt.Errorf("Error changing memory size: %#v", err))
 				}
 
-				return resource.RetryableError(task.WaitTaskCompletion())
+				return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 			})
 			if err != nil {
 				return err
@@ -321,38 +328,27 @@ func resourceVcdVAppUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 
 		if d.HasChange("cpus") {
-			err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+			err = retryCall(timeout, func() *resource.RetryError {
 				task, err := vapp.ChangeCPUcount(d.Get("cpus").(int))
 				if err != nil {
 					return resource.RetryableError(fmt.Errorf("Error changing cpu count: %#v", err))
 				}
 
-				return resource.RetryableError(task.WaitTaskCompletion())
+				return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 			})
 			if err != nil {
 				return fmt.Errorf("Error completing task: %#v", err)
 			}
 		}
 
-		if d.Get("power_on").(bool) {
-			task, err := vapp.PowerOn()
-			if err != nil {
-				return fmt.Errorf("Error Powering Up: %#v", err)
-			}
-			err = task.WaitTaskCompletion()
-			if err != nil {
-				return fmt.Errorf("Error completing tasks: %#v", err)
-			}
-		}
-
 		if ovf, ok := d.GetOk("ovf"); ok {
-			err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+			err = retryCall(timeout, func() *resource.RetryError {
 				task, err := vapp.SetOvf(convertToStringMap(ovf.(map[string]interface{})))
 
 				if err != nil {
 					return resource.RetryableError(fmt.Errorf("Error set ovf: %#v", err))
 				}
-				return resource.RetryableError(task.WaitTaskCompletion())
+				return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 			})
 			if err != nil {
 				return fmt.Errorf("Error completing tasks: %#v", err)
@@ -361,9 +357,86 @@ func resourceVcdVAppUpdate(d *schema.ResourceData, meta interface{}) error {
 
 	}
 
+	err = setVAppPowerState(d, &vapp, timeout)
+	if err != nil {
+		return err
+	}
+
 	return resourceVcdVAppRead(d, meta)
 }
 
+// setVAppPowerState powers the vApp on or off as power_on requests and waits
+// for it, unless it is already in the requested state. A vApp without VMs
+// cannot be powered on, so it is left as it is.
+func setVAppPowerState(d *schema.ResourceData, vapp *vcdVApp, timeout int) error {
+	powerOn := d.Get("power_on").(bool)
+
+	status, err := vapp.GetStatus()
+	if err != nil {
+		return fmt.Errorf("Error getting VApp status: %#v", err)
+	}
+
+	if vapp.VApp.Children == nil || len(vapp.VApp.Children.VM) == 0 {
+		return nil
+	}
+	if powerOn == (status == "POWERED_ON") {
+		return nil
+	}
+	if !powerOn && shutdownVApp(d, vapp) {
+		return nil
+	}
+
+	err = retryCall(timeout, func() *resource.RetryError {
+		var task govcd.Task
+		var err error
+		if powerOn {
+			task, err = vapp.PowerOn()
+		} else {
+			task, err = vapp.PowerOff()
+		}
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error changing power state: %#v", err))
+		}
+
+		return resource.RetryableError(waitTask(vapp.c, task, timeout))
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing power tasks: %#v", err)
+	}
+
+	return nil
+}
+
+// shutdownVApp shuts the guest operating systems of the vApp down when
+// shutdown_guest is set and all its VMs have VMware Tools installed. It
+// reports whether the vApp is powered off within shutdown_timeout, so that
+// the caller powers it off otherwise.
+func shutdownVApp(d *schema.ResourceData, vapp *vcdVApp) bool {
+	if !d.Get("shutdown_guest").(bool) {
+		return false
+	}
+	tools, err := vapp.HasVMwareTools()
+	if err != nil {
+		log.Printf("[DEBUG] Unable to check VMware Tools of vApp %s, powering it off: %s", vapp.VApp.Name, err)
+		return false
+	}
+	if !tools {
+		log.Printf("[DEBUG] Not all VMs of vApp %s have VMware Tools, powering it off", vapp.VApp.Name)
+		return false
+	}
+
+	return shutdownGuest(func(timeout int) error {
+		task, err := vapp.Shutdown()
+		if err != nil {
+			return err
+		}
+		return waitTask(vapp.c, task, timeout)
+	}, func() (bool, error) {
+		status, err := vapp.GetStatus()
+		return status == "POWERED_OFF", err
+	}, d.Get("shutdown_timeout").(int))
+}
+
 func resourceVcdVAppRead(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
 
@@ -372,13 +445,39 @@ func resourceVcdVAppRead(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error refreshing vdc: %#v", err)
 	}
 
-	_, err = vcdClient.OrgVdc.FindVAppByName(d.Id())
+	vapp, err := findVApp(vcdClient, d.Id())
 	if err != nil {
 		log.Printf("[DEBUG] Unable to find vapp. Removing from tfstate")
 		d.SetId("")
 		return nil
 	}
 
+	// Earlier versions used the name as the ID
+	d.SetId(vapp.VApp.HREF)
+	d.Set("href", vapp.VApp.HREF)
+
+	err = readMetadata(d, &vapp)
+	if err != nil {
+		return err
+	}
+
+	status, err := vapp.GetStatus()
+	if err != nil {
+		return fmt.Errorf("Error getting VApp status: %#v", err)
+	}
+	d.Set("status", status)
+
+	// Only a vApp with VMs has a power state. Transient and mixed states
+	// are not reported as a change.
+	if vapp.VApp.Children != nil && len(vapp.VApp.Children.VM) > 0 {
+		switch status {
+		case "POWERED_ON":
+			d.Set("power_on", true)
+		case "POWERED_OFF", "RESOLVED":
+			d.Set("power_on", false)
+		}
+	}
+
 	if _, ok := d.GetOk("ip"); ok {
 		ip := "allocated"
 
@@ -404,16 +503,36 @@ func resourceVcdVAppRead(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
+// findVApp looks a vApp up by its href, falling back to the name that was
+// used as the resource ID by earlier versions
+func findVApp(vcdClient *VCDClient, id string) (vcdVApp, error) {
+	if uuid := uuidFromHref(id); uuid != "" {
+		if err := vcdClient.OrgVdc.Refresh(); err != nil {
+			return vcdVApp{}, fmt.Errorf("error refreshing vdc: %s", err)
+		}
+		for _, entities := range vcdClient.OrgVdc.Vdc.ResourceEntities {
+			for _, entity := range entities.ResourceEntity {
+				if entity.Type == "application/vnd.vmware.vcloud.vApp+xml" && uuidFromHref(entity.HREF) == uuid {
+					return vcdClient.findVAppByName(entity.Name)
+				}
+			}
+		}
+		return vcdVApp{}, fmt.Errorf("can't find vApp: %s", id)
+	}
+
+	return vcdClient.findVAppByName(id)
+}
+
 func getVAppIPAddress(d *schema.ResourceData, meta interface{}) (string, error) {
 	vcdClient := meta.(*VCDClient)
 	var ip string
 
-	err := retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+	err := retryCall(vcdClient.retryTimeout(d, schema.TimeoutRead), func() *resource.RetryError {
 		err := vcdClient.OrgVdc.Refresh()
 		if err != nil {
 			return resource.RetryableError(fmt.Errorf("Error refreshing vdc: %#v", err))
 		}
-		vapp, err := vcdClient.OrgVdc.FindVAppByName(d.Id())
+		vapp, err := findVApp(vcdClient, d.Id())
 		if err != nil {
 			return resource.RetryableError(fmt.Errorf("Unable to find vapp."))
 		}
@@ -421,9 +540,11 @@ func getVAppIPAddress(d *schema.ResourceData, meta interface{}) (string, error)
 		// getting the IP of the specific Vm, rather than index zero.
 		// Required as once we add more VM's, index zero doesn't guarantee the
 		// 'first' one, and tests will fail sometimes (annoying huh?)
-		vm, err := vcdClient.OrgVdc.FindVMByName(vapp, d.Get("name").(string))
+		vm, err := vcdClient.findVMByName(vapp, d.Get("name").(string))
 
-		ip = vm.VM.NetworkConnectionSection.NetworkConnection.IPAddress
+		if primary := vm.PrimaryNetworkConnection(); primary != nil {
+			ip = primary.IPAddress
+		}
 		if ip == "" {
 			return resource.RetryableError(fmt.Errorf("Timeout: VM did not acquire IP address"))
 		}
@@ -435,32 +556,45 @@ func getVAppIPAddress(d *schema.ResourceData, meta interface{}) (string, error)
 
 func resourceVcdVAppDelete(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
-	vapp, err := vcdClient.OrgVdc.FindVAppByName(d.Id())
+	vapp, err := findVApp(vcdClient, d.Id())
 
 	if err != nil {
 		return fmt.Errorf("error finding vapp: %s", err)
 	}
 
+	status, err := vapp.GetStatus()
 	if err != nil {
 		return fmt.Errorf("Error getting VApp status: %#v", err)
 	}
 
-	_ = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := vapp.Undeploy()
-		if err != nil {
-			return resource.RetryableError(fmt.Errorf("Error undeploying: %#v", err))
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+
+	// vCD only deletes undeployed vApps. Undeploying powers the VMs off
+	if vapp.VApp.Deployed || status == "POWERED_ON" {
+		if status == "POWERED_ON" {
+			shutdownVApp(d, &vapp)
 		}
 
-		return resource.RetryableError(task.WaitTaskCompletion())
-	})
+		err = retryCall(timeout, func() *resource.RetryError {
+			task, err := vapp.Undeploy()
+			if err != nil {
+				return resource.RetryableError(fmt.Errorf("Error undeploying: %#v", err))
+			}
+
+			return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+		})
+		if err != nil {
+			return fmt.Errorf("Error undeploying vApp: %#v", err)
+		}
+	}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+	err = retryCall(timeout, func() *resource.RetryError {
 		task, err := vapp.Delete()
 		if err != nil {
 			return resource.RetryableError(fmt.Errorf("Error deleting: %#v", err))
 		}
 
-		return resource.RetryableError(task.WaitTaskCompletion())
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 	})
 
 	return err
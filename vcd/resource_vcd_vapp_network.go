@@ -0,0 +1,241 @@
+package vcd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+func resourceVcdVAppNetwork() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVcdVAppNetworkCreate,
+		Read:   resourceVcdVAppNetworkRead,
+		Delete: resourceVcdVAppNetworkDelete,
+
+		Timeouts: resourceTimeouts(),
+
+		Schema: map[string]*schema.Schema{
+			"vapp_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"netmask": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "255.255.255.0",
+			},
+
+			"gateway": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"dns1": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "8.8.8.8",
+			},
+
+			"dns2": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "8.8.4.4",
+			},
+
+			"dns_suffix": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"org_network": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The org VDC network to NAT route the vApp network to. The network is isolated when unset",
+			},
+
+			"static_ip_pool": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"start_address": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+
+						"end_address": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+					},
+				},
+				Set: resourceVcdNetworkIPAddressHash,
+			},
+		},
+	}
+}
+
+func resourceVcdVAppNetworkCreate(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vappName := d.Get("vapp_name").(string)
+	vAppMutexKV.Lock(vappName)
+	defer vAppMutexKV.Unlock(vappName)
+
+	vapp, err := vcdClient.findVAppByName(vappName)
+	if err != nil {
+		return fmt.Errorf("Error finding vApp: %#v", err)
+	}
+
+	ipRanges := expandIPRange(d.Get("static_ip_pool").(*schema.Set).List())
+
+	config := &types.VAppNetworkConfiguration{
+		NetworkName: d.Get("name").(string),
+		Configuration: &types.NetworkConfiguration{
+			FenceMode: "isolated",
+			IPScopes: &types.IPScopes{
+				IPScope: types.IPScope{
+					IsInherited: false,
+					Gateway:     d.Get("gateway").(string),
+					Netmask:     d.Get("netmask").(string),
+					DNS1:        d.Get("dns1").(string),
+					DNS2:        d.Get("dns2").(string),
+					DNSSuffix:   d.Get("dns_suffix").(string),
+					IsEnabled:   true,
+					IPRanges:    &ipRanges,
+				},
+			},
+		},
+	}
+
+	if orgNetwork, ok := d.GetOk("org_network"); ok {
+		network, err := vcdClient.OrgVdc.FindVDCNetwork(orgNetwork.(string))
+		if err != nil {
+			return fmt.Errorf("Error finding org network: %#v", err)
+		}
+
+		config.Configuration.FenceMode = "natRouted"
+		config.Configuration.ParentNetwork = &types.Reference{
+			HREF: network.OrgVDCNetwork.HREF,
+			Name: network.OrgVDCNetwork.Name,
+			Type: network.OrgVDCNetwork.Type,
+		}
+	}
+
+	log.Printf("[INFO] VAPP NETWORK: %#v", config)
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutCreate)
+	err = retryCall(timeout, func() *resource.RetryError {
+		task, err := vapp.AddNetworkConfig(config)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error adding vApp network: %#v", err))
+		}
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing tasks: %#v", err)
+	}
+
+	d.SetId(vappName + ":" + config.NetworkName)
+
+	return resourceVcdVAppNetworkRead(d, meta)
+}
+
+func resourceVcdVAppNetworkRead(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vapp, err := vcdClient.findVAppByName(d.Get("vapp_name").(string))
+	if err != nil {
+		log.Printf("[DEBUG] vApp no longer exists. Removing vApp network from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	networkConfig, err := vapp.GetNetworkConfig()
+	if err != nil {
+		return fmt.Errorf("Error getting vApp networks: %#v", err)
+	}
+
+	config := findVAppNetworkConfig(networkConfig, d.Get("name").(string))
+	if config == nil {
+		log.Printf("[DEBUG] vApp network no longer exists. Removing from tfstate")
+		d.SetId("")
+		return nil
+	}
+
+	if config.Configuration.IPScopes != nil {
+		scope := config.Configuration.IPScopes.IPScope
+		d.Set("gateway", scope.Gateway)
+		d.Set("netmask", scope.Netmask)
+		d.Set("dns1", scope.DNS1)
+		d.Set("dns2", scope.DNS2)
+		d.Set("dns_suffix", scope.DNSSuffix)
+		if scope.IPRanges != nil {
+			d.Set("static_ip_pool", flattenIPRange(scope.IPRanges))
+		}
+	}
+
+	if config.Configuration.ParentNetwork != nil {
+		d.Set("org_network", config.Configuration.ParentNetwork.Name)
+	} else {
+		d.Set("org_network", "")
+	}
+
+	return nil
+}
+
+func resourceVcdVAppNetworkDelete(d *schema.ResourceData, meta interface{}) error {
+	vcdClient := meta.(*VCDClient)
+
+	vappName := d.Get("vapp_name").(string)
+	vAppMutexKV.Lock(vappName)
+	defer vAppMutexKV.Unlock(vappName)
+
+	vapp, err := vcdClient.findVAppByName(vappName)
+	if err != nil {
+		return fmt.Errorf("Error finding vApp: %#v", err)
+	}
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+	err = retryCall(timeout, func() *resource.RetryError {
+		task, err := vapp.RemoveNetworkConfig(d.Get("name").(string))
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error removing vApp network: %#v", err))
+		}
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing tasks: %#v", err)
+	}
+
+	return nil
+}
+
+// findVAppNetworkConfig returns the configuration of the named vApp network,
+// or nil when the vApp has no such network.
+func findVAppNetworkConfig(networkConfig *networkConfigSectionType, name string) *types.VAppNetworkConfiguration {
+	for _, config := range networkConfig.NetworkConfig {
+		if config.NetworkName == name && config.Configuration != nil {
+			return config
+		}
+	}
+	return nil
+}
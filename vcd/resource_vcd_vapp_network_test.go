@@ -0,0 +1,139 @@
+package vcd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccVcdVAppNetwork_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdVAppNetworkDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppNetwork_basic, os.Getenv("VCD_EDGE_GATEWAY")),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppNetworkExists("vcd_vapp_network.isolated"),
+					testAccCheckVcdVAppNetworkExists("vcd_vapp_network.routed"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_network.isolated", "gateway", "192.168.2.1"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_network.routed", "org_network", "foonet"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "ip", "192.168.2.10"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdVAppNetworkExists(n string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No vApp network ID is set")
+		}
+
+		conn := testAccProvider.Meta().(*VCDClient)
+
+		vapp, err := conn.findVAppByName(rs.Primary.Attributes["vapp_name"])
+		if err != nil {
+			return err
+		}
+
+		networkConfig, err := vapp.GetNetworkConfig()
+		if err != nil {
+			return err
+		}
+
+		if findVAppNetworkConfig(networkConfig, rs.Primary.Attributes["name"]) == nil {
+			return fmt.Errorf("vApp network %s does not exist", rs.Primary.Attributes["name"])
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckVcdVAppNetworkDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*VCDClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "vcd_vapp_network" {
+			continue
+		}
+
+		vapp, err := conn.findVAppByName(rs.Primary.Attributes["vapp_name"])
+		if err != nil {
+			continue
+		}
+
+		networkConfig, err := vapp.GetNetworkConfig()
+		if err != nil {
+			return err
+		}
+
+		if findVAppNetworkConfig(networkConfig, rs.Primary.Attributes["name"]) != nil {
+			return fmt.Errorf("vApp network %s still exists", rs.Primary.Attributes["name"])
+		}
+	}
+
+	return nil
+}
+
+const testAccCheckVcdVAppNetwork_basic = `
+resource "vcd_network" "foonet" {
+	name = "foonet"
+	edge_gateway = "%s"
+	gateway = "10.10.102.1"
+	static_ip_pool {
+		start_address = "10.10.102.2"
+		end_address = "10.10.102.254"
+	}
+}
+
+resource "vcd_vapp" "foobar" {
+  name = "foobar"
+}
+
+resource "vcd_vapp_network" "isolated" {
+  vapp_name = "${vcd_vapp.foobar.name}"
+  name      = "isolated"
+  gateway   = "192.168.2.1"
+  static_ip_pool {
+    start_address = "192.168.2.2"
+    end_address   = "192.168.2.100"
+  }
+}
+
+resource "vcd_vapp_network" "routed" {
+  vapp_name   = "${vcd_vapp.foobar.name}"
+  name        = "routed"
+  gateway     = "192.168.3.1"
+  org_network = "${vcd_network.foonet.name}"
+  static_ip_pool {
+    start_address = "192.168.3.2"
+    end_address   = "192.168.3.100"
+  }
+}
+
+resource "vcd_vapp_vm" "moo" {
+  vapp_name     = "${vcd_vapp.foobar.name}"
+  name          = "moo"
+  catalog_name  = "Skyscape Catalogue"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  memory        = 1024
+  cpus          = 1
+
+  network_name  = "${vcd_vapp_network.isolated.name}"
+  ip            = "192.168.2.10"
+}
+`
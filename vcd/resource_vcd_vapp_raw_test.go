@@ -5,13 +5,12 @@ import (
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
-	govcd "github.com/ukcloud/govcloudair"
 	"os"
 	"testing"
 )
 
 func TestAccVcdVAppRaw_Basic(t *testing.T) {
-	var vapp govcd.VApp
+	var vapp vcdVApp
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -30,7 +29,7 @@ func TestAccVcdVAppRaw_Basic(t *testing.T) {
 	})
 }
 
-func testAccCheckVcdVAppRawExists(n string, vapp *govcd.VApp) resource.TestCheckFunc {
+func testAccCheckVcdVAppRawExists(n string, vapp *vcdVApp) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
 		if !ok {
@@ -43,7 +42,7 @@ func testAccCheckVcdVAppRawExists(n string, vapp *govcd.VApp) resource.TestCheck
 
 		conn := testAccProvider.Meta().(*VCDClient)
 
-		resp, err := conn.OrgVdc.FindVAppByName(rs.Primary.ID)
+		resp, err := findVApp(conn, rs.Primary.ID)
 		if err != nil {
 			return err
 		}
@@ -62,7 +61,7 @@ func testAccCheckVcdVAppRawDestroy(s *terraform.State) error {
 			continue
 		}
 
-		_, err := conn.OrgVdc.FindVAppByName(rs.Primary.ID)
+		_, err := findVApp(conn, rs.Primary.ID)
 
 		if err == nil {
 			return fmt.Errorf("VPCs still exist")
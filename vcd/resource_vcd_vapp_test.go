@@ -7,11 +7,10 @@ import (
 
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
-	govcd "github.com/ukcloud/govcloudair"
 )
 
 func TestAccVcdVApp_PowerOff(t *testing.T) {
-	var vapp govcd.VApp
+	var vapp vcdVApp
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -25,10 +24,16 @@ func TestAccVcdVApp_PowerOff(t *testing.T) {
 					testAccCheckVcdVAppAttributes(&vapp),
 					resource.TestCheckResourceAttr(
 						"vcd_vapp.foobar", "name", "foobar"),
+					resource.TestCheckResourceAttrPair(
+						"vcd_vapp.foobar", "id", "vcd_vapp.foobar", "href"),
 					resource.TestCheckResourceAttr(
 						"vcd_vapp.foobar", "ip", "10.10.102.160"),
 					resource.TestCheckResourceAttr(
 						"vcd_vapp.foobar", "power_on", "true"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp.foobar", "metadata.%", "1"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp.foobar", "metadata.cost_center", "1234"),
 				),
 			},
 
@@ -45,7 +50,7 @@ func TestAccVcdVApp_PowerOff(t *testing.T) {
 			},
 
 			resource.TestStep{
-				Config: fmt.Sprintf(testAccCheckVcdVApp_powerOff, os.Getenv("VCD_EDGE_GATEWAY")),
+				Config: fmt.Sprintf(testAccCheckVcdVApp_powerOff, os.Getenv("VCD_EDGE_GATEWAY"), false),
 				Check: resource.ComposeTestCheckFunc(
 					testAccCheckVcdVAppExists("vcd_vapp.foobar", &vapp),
 					testAccCheckVcdVAppAttributes_off(&vapp),
@@ -61,7 +66,49 @@ func TestAccVcdVApp_PowerOff(t *testing.T) {
 	})
 }
 
-func testAccCheckVcdVAppExists(n string, vapp *govcd.VApp) resource.TestCheckFunc {
+func TestAccVcdVApp_PowerToggle(t *testing.T) {
+	var vapp vcdVApp
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdVAppDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVApp_powerOff, os.Getenv("VCD_EDGE_GATEWAY"), false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppExists("vcd_vapp.foobar", &vapp),
+					testAccCheckVcdVAppAttributes_off(&vapp),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp.foobar", "power_on", "false"),
+				),
+			},
+
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVApp_powerOff, os.Getenv("VCD_EDGE_GATEWAY"), true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppExists("vcd_vapp.foobar", &vapp),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp.foobar", "power_on", "true"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp.foobar", "status", "POWERED_ON"),
+				),
+			},
+
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVApp_powerOff, os.Getenv("VCD_EDGE_GATEWAY"), false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppExists("vcd_vapp.foobar", &vapp),
+					testAccCheckVcdVAppAttributes_off(&vapp),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp.foobar", "status", "POWERED_OFF"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdVAppExists(n string, vapp *vcdVApp) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
 		if !ok {
@@ -74,7 +121,7 @@ func testAccCheckVcdVAppExists(n string, vapp *govcd.VApp) resource.TestCheckFun
 
 		conn := testAccProvider.Meta().(*VCDClient)
 
-		resp, err := conn.OrgVdc.FindVAppByName(rs.Primary.ID)
+		resp, err := findVApp(conn, rs.Primary.ID)
 		if err != nil {
 			return err
 		}
@@ -93,7 +140,7 @@ func testAccCheckVcdVAppDestroy(s *terraform.State) error {
 			continue
 		}
 
-		_, err := conn.OrgVdc.FindVAppByName(rs.Primary.ID)
+		_, err := findVApp(conn, rs.Primary.ID)
 
 		if err == nil {
 			return fmt.Errorf("VPCs still exist")
@@ -105,7 +152,7 @@ func testAccCheckVcdVAppDestroy(s *terraform.State) error {
 	return nil
 }
 
-func testAccCheckVcdVAppAttributes(vapp *govcd.VApp) resource.TestCheckFunc {
+func testAccCheckVcdVAppAttributes(vapp *vcdVApp) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 
 		if vapp.VApp.Name != "foobar" {
@@ -126,7 +173,7 @@ func testAccCheckVcdVAppAttributes(vapp *govcd.VApp) resource.TestCheckFunc {
 	}
 }
 
-func testAccCheckVcdVAppAttributes_off(vapp *govcd.VApp) resource.TestCheckFunc {
+func testAccCheckVcdVAppAttributes_off(vapp *vcdVApp) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 
 		if vapp.VApp.Name != "foobar" {
@@ -176,6 +223,10 @@ resource "vcd_vapp" "foobar" {
   memory        = 1024
   cpus          = 1
   ip            = "10.10.102.160"
+
+  metadata {
+    cost_center = "1234"
+  }
 }
 
 resource "vcd_vapp" "foobar_allocated" {
@@ -213,6 +264,6 @@ resource "vcd_vapp" "foobar" {
   memory        = 1024
   cpus          = 1
   ip            = "10.10.103.160"
-  power_on      = false
+  power_on      = %t
 }
 `
@@ -4,7 +4,12 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/ukcloud/govcloudair"
+	types "github.com/ukcloud/govcloudair/types/v56"
 	"log"
+	"sort"
+	"strconv"
+	"time"
 )
 
 func resourceVcdVAppVm() *schema.Resource {
@@ -14,6 +19,8 @@ func resourceVcdVAppVm() *schema.Resource {
 		Read:   resourceVcdVAppVmRead,
 		Delete: resourceVcdVAppVmDelete,
 
+		Timeouts: resourceTimeouts(),
+
 		Schema: map[string]*schema.Schema{
 			"vapp_name": &schema.Schema{
 				Type:     schema.TypeString,
@@ -46,6 +53,18 @@ func resourceVcdVAppVm() *schema.Resource {
 			"cpus": &schema.Schema{
 				Type:     schema.TypeInt,
 				Optional: true,
+				Computed: true,
+			},
+			"cpu_cores": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validateVmCPUCores,
+			},
+			"nested_hypervisor_enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
 			},
 			"ip": &schema.Schema{
 				Type:     schema.TypeString,
@@ -53,9 +72,53 @@ func resourceVcdVAppVm() *schema.Resource {
 				Computed: true,
 			},
 			"initscript": &schema.Schema{
-				Type:     schema.TypeString,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"customization"},
+			},
+			"metadata": &schema.Schema{
+				Type:     schema.TypeMap,
 				Optional: true,
-				ForceNew: true,
+			},
+			"customization": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"enabled": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+						"change_sid": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						"admin_password": &schema.Schema{
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+						"admin_password_auto": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						"computer_name": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Computed: true,
+						},
+						"initscript": &schema.Schema{
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
 			},
 
 			"href": &schema.Schema{
@@ -68,6 +131,31 @@ func resourceVcdVAppVm() *schema.Resource {
 				Optional: true,
 				Default:  true,
 			},
+			"shutdown_guest": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"shutdown_timeout": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      300,
+				ValidateFunc: validateShutdownTimeout,
+			},
+			"dhcp_wait_attempts": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validateDhcpWait,
+				Description:  "Number of times to read the VM again while the guest has not reported the address of a DHCP NIC",
+			},
+			"dhcp_wait_interval": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      5,
+				ValidateFunc: validateDhcpWait,
+				Description:  "Seconds to wait before the first of dhcp_wait_attempts, doubled before each next one",
+			},
 			"network_href": &schema.Schema{
 				Type:     schema.TypeString,
 				Optional: true,
@@ -78,6 +166,92 @@ func resourceVcdVAppVm() *schema.Resource {
 				Optional: true,
 				ForceNew: true,
 			},
+
+			"network": &schema.Schema{
+				Type:          schema.TypeList,
+				Optional:      true,
+				ConflictsWith: []string{"network_name", "ip"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"ip_allocation_mode": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "POOL",
+							ValidateFunc: validateVmIPAllocationMode,
+						},
+						"ip": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Computed: true,
+						},
+						"is_primary": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Computed: true,
+						},
+						"adapter_type": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Computed: true,
+						},
+						"connected": &schema.Schema{
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     true,
+							Description: "Whether the NIC is connected to its network",
+						},
+					},
+				},
+			},
+
+			"storage_profile": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+
+			"allow_disk_shrink": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Replace disks whose size_in_mb is lowered with new, empty ones, as vCD cannot shrink disks",
+			},
+
+			"disk": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"bus_type": &schema.Schema{
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "scsi",
+							ValidateFunc: validateVmDiskBusType,
+						},
+						"bus_number": &schema.Schema{
+							Type:     schema.TypeInt,
+							Required: true,
+						},
+						"unit_number": &schema.Schema{
+							Type:     schema.TypeInt,
+							Required: true,
+						},
+						"size_in_mb": &schema.Schema{
+							Type:     schema.TypeInt,
+							Required: true,
+						},
+						"storage_profile": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Computed: true,
+						},
+					},
+				},
+			},
 		},
 	}
 }
@@ -85,7 +259,28 @@ func resourceVcdVAppVm() *schema.Resource {
 func resourceVcdVAppVmCreate(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
 
-	catalog, err := vcdClient.Org.FindCatalog(d.Get("catalog_name").(string))
+	vappName := d.Get("vapp_name").(string)
+	vAppMutexKV.Lock(vappName)
+	defer vAppMutexKV.Unlock(vappName)
+
+	if err := checkVmDisks(d.Get("disk").([]interface{})); err != nil {
+		return err
+	}
+
+	networks := d.Get("network").([]interface{})
+	if err := checkVmNetworks(networks); err != nil {
+		return err
+	}
+
+	// With network blocks the VM is added to the vApp on its primary network,
+	// and connected to the others once it exists
+	networkName := d.Get("network_name").(string)
+	if len(networks) > 0 {
+		connections, primary := expandVmNetworks(networks)
+		networkName = connections[primary].Network
+	}
+
+	catalog, err := vcdClient.findCatalog(d.Get("catalog_name").(string))
 	if err != nil {
 		return fmt.Errorf("Error finding catalog: %#v", err)
 	}
@@ -100,122 +295,182 @@ func resourceVcdVAppVmCreate(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error finding VAppTemplate: %#v", err)
 	}
 
-	vapp, err := vcdClient.OrgVdc.FindVAppByName(d.Get("vapp_name").(string))
-	if err != nil {
-		return fmt.Errorf("Error finding Vapp: %#v", err)
+	var storageProfile *types.Reference
+	if name, ok := d.GetOk("storage_profile"); ok {
+		ref, err := vcdClient.OrgVdc.FindStorageProfileReference(name.(string))
+		if err != nil {
+			return fmt.Errorf("Error finding storage profile %s: %#v", name.(string), err)
+		}
+		storageProfile = &ref
 	}
 
-	netname := "blank"
-	net, err := vcdClient.OrgVdc.FindVDCNetwork(d.Get("network_name").(string))
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutCreate)
 
-	if err == nil {
-		netname = net.OrgVDCNetwork.Name
+	vapp, err := vcdClient.findVAppByName(d.Get("vapp_name").(string))
+	if err != nil {
+		return fmt.Errorf("Error finding Vapp: %#v", err)
 	}
 
 	vAppNetworkConfig, err := vapp.GetNetworkConfig()
+	if err != nil {
+		return fmt.Errorf("Error getting vApp networks: %#v", err)
+	}
 
-	vAppNetworkName := "blank"
-	if vAppNetworkConfig.NetworkConfig != nil {
-		vAppNetworkName = vAppNetworkConfig.NetworkConfig.NetworkName
-		if netname == "blank" {
-			net, err = vcdClient.OrgVdc.FindVDCNetwork(vAppNetworkName)
-			if err != nil {
-				return fmt.Errorf("Error finding vApp network: %#v", err)
-			}
+	netname := "blank"
+	var net govcd.OrgVDCNetwork
+
+	// vApp networks only exist within the vApp, so they are matched on the
+	// vApp rather than looked up in the VDC
+	if config := findVAppNetworkConfig(vAppNetworkConfig, networkName); config != nil && config.Configuration.FenceMode != "bridged" {
+		netname = config.NetworkName
+		net = govcd.OrgVDCNetwork{OrgVDCNetwork: &types.OrgVDCNetwork{Name: netname}}
+	} else {
+		net, err = vcdClient.OrgVdc.FindVDCNetwork(networkName)
 
+		if err == nil {
 			netname = net.OrgVDCNetwork.Name
 		}
 
-	} else {
-
-		if netname == "blank" {
-			return fmt.Errorf("'network_name' must be valid when adding VM to raw vapp")
+		var bridged *types.VAppNetworkConfiguration
+		for _, config := range vAppNetworkConfig.NetworkConfig {
+			if config.Configuration != nil && config.Configuration.FenceMode == "bridged" && (bridged == nil || config.NetworkName == netname) {
+				bridged = config
+			}
 		}
 
-		err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-			task, err := vapp.AddRAWNetworkConfig(netname, net.OrgVDCNetwork.HREF)
-			if err != nil {
-				return resource.RetryableError(fmt.Errorf("Error assigning network to vApp: %#v", err))
+		vAppNetworkName := "blank"
+		if bridged != nil {
+			vAppNetworkName = bridged.NetworkName
+			if netname == "blank" {
+				net, err = vcdClient.OrgVdc.FindVDCNetwork(vAppNetworkName)
+				if err != nil {
+					return fmt.Errorf("Error finding vApp network: %#v", err)
+				}
+
+				netname = net.OrgVDCNetwork.Name
 			}
-			return resource.RetryableError(task.WaitTaskCompletion())
-		})
 
-		if err != nil {
-			return fmt.Errorf("Error2 assigning network to vApp:: %#v", err)
 		} else {
-			vAppNetworkName = netname
-		}
 
-	}
+			if netname == "blank" {
+				return fmt.Errorf("'network_name' must be valid when adding VM to raw vapp")
+			}
+
+			err = retryCall(timeout, func() *resource.RetryError {
+				task, err := vapp.AddRAWNetworkConfig(netname, net.OrgVDCNetwork.HREF)
+				if err != nil {
+					return resource.RetryableError(fmt.Errorf("Error assigning network to vApp: %#v", err))
+				}
+				return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+			})
 
-	if vAppNetworkName != netname {
-		return fmt.Errorf("The VDC network '%s' must be assigned to the vApp. Currently the vApp network date is %s", netname, vAppNetworkName)
+			if err != nil {
+				return fmt.Errorf("Error2 assigning network to vApp:: %#v", err)
+			} else {
+				vAppNetworkName = netname
+			}
+
+		}
+
+		if vAppNetworkName != netname {
+			return fmt.Errorf("The VDC network '%s' must be assigned to the vApp. Currently the vApp network date is %s", netname, vAppNetworkName)
+		}
 	}
 
 	log.Printf("[TRACE] Network name found: %s", netname)
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+	err = retryCall(timeout, func() *resource.RetryError {
 		log.Printf("[TRACE] Creating VM: %s", d.Get("name").(string))
-		task, err := vapp.AddVM(net, vapptemplate, d.Get("name").(string))
+		task, err := vapp.AddVMWithStorageProfile(net, vapptemplate, d.Get("name").(string), storageProfile)
 
 		if err != nil {
 			return resource.RetryableError(fmt.Errorf("Error adding VM: %#v", err))
 		}
 
-		return resource.RetryableError(task.WaitTaskCompletion())
+		return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 	})
 
 	if err != nil {
 		return fmt.Errorf("Error completing tasks: %#v", err)
 	}
 
-	vm, err := vcdClient.OrgVdc.FindVMByName(vapp, d.Get("name").(string))
+	vm, err := vcdClient.findVMByName(vapp, d.Get("name").(string))
 
 	if err != nil {
 		d.SetId("")
 		return fmt.Errorf("Error getting VM1 : %#v", err)
 	}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := vm.ChangeNetworkConfig(netname, d.Get("ip").(string))
-		if err != nil {
-			return resource.RetryableError(fmt.Errorf("Error with Networking change: %#v", err))
-		}
-		return resource.RetryableError(task.WaitTaskCompletion())
-	})
+	if len(networks) > 0 {
+		err = changeVmNetworks(vapp, vm, networks, timeout)
+	} else {
+		err = retryCall(timeout, func() *resource.RetryError {
+			task, err := vm.ChangeNetworkConfig(netname, d.Get("ip").(string))
+			if err != nil {
+				return resource.RetryableError(fmt.Errorf("Error with Networking change: %#v", err))
+			}
+			return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+		})
+	}
 	if err != nil {
 		return fmt.Errorf("Error changing network: %#v", err)
 	}
 
-	initscript := d.Get("initscript").(string)
+	if _, ok := d.GetOk("customization"); ok {
+		// The VM has not been powered on yet, so vCD applies the settings on
+		// its first boot
+		err = retryCall(timeout, func() *resource.RetryError {
+			task, err := vm.SetGuestCustomization(vmGuestCustomization(d))
+			if err != nil {
+				return resource.RetryableError(fmt.Errorf("Error setting guest customization: %#v", err))
+			}
+			return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+		})
+		if err != nil {
+			return fmt.Errorf("Error completing tasks: %#v", err)
+		}
+	} else {
+		initscript := d.Get("initscript").(string)
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-		task, err := vm.RunCustomizationScript(d.Get("name").(string), initscript)
+		err = retryCall(timeout, func() *resource.RetryError {
+			task, err := vm.RunCustomizationScript(d.Get("name").(string), initscript)
+			if err != nil {
+				return resource.RetryableError(fmt.Errorf("Error with setting init script: %#v", err))
+			}
+			return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+		})
 		if err != nil {
-			return resource.RetryableError(fmt.Errorf("Error with setting init script: %#v", err))
+			return fmt.Errorf("Error completing tasks: %#v", err)
 		}
-		return resource.RetryableError(task.WaitTaskCompletion())
-	})
-	if err != nil {
-		return fmt.Errorf("Error completing tasks: %#v", err)
 	}
 
-	d.SetId(d.Get("name").(string))
+	d.SetId(vm.VM.HREF)
 
-	return resourceVcdVAppVmUpdate(d, meta)
+	return updateVAppVm(d, meta)
 }
 
 func resourceVcdVAppVmUpdate(d *schema.ResourceData, meta interface{}) error {
+	vappName := d.Get("vapp_name").(string)
+	vAppMutexKV.Lock(vappName)
+	defer vAppMutexKV.Unlock(vappName)
+
+	return updateVAppVm(d, meta)
+}
+
+// updateVAppVm applies the configuration of the VM, for both Create and
+// Update. The caller holds the lock of the vApp, as changing the networks of
+// the VM also changes those of the vApp.
+func updateVAppVm(d *schema.ResourceData, meta interface{}) error {
 
 	vcdClient := meta.(*VCDClient)
 
-	vapp, err := vcdClient.OrgVdc.FindVAppByName(d.Get("vapp_name").(string))
+	vapp, err := vcdClient.findVAppByName(d.Get("vapp_name").(string))
 
 	if err != nil {
 		return fmt.Errorf("error finding vapp: %s", err)
 	}
 
-	vm, err := vcdClient.OrgVdc.FindVMByName(vapp, d.Get("name").(string))
+	vm, err := findVM(vcdClient, vapp, d.Id(), d.Get("name").(string))
 
 	if err != nil {
 		d.SetId("")
@@ -227,52 +482,216 @@ func resourceVcdVAppVmUpdate(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error getting VM status: %#v", err)
 	}
 
-	if d.HasChange("memory") || d.HasChange("cpus") || d.HasChange("power_on") {
-		if status != "POWERED_OFF" {
-			task, err := vm.PowerOff()
+	// Everything that can reject the configuration is checked before the
+	// first change is made to the VM
+	var ref types.Reference
+	changeStorageProfile := d.HasChange("storage_profile") && !d.IsNewResource()
+	if changeStorageProfile {
+		ref, err = vcdClient.OrgVdc.FindStorageProfileReference(d.Get("storage_profile").(string))
+		if err != nil {
+			return fmt.Errorf("Error finding storage profile %s: %#v", d.Get("storage_profile").(string), err)
+		}
+	}
+
+	var disks, unshrunkDisks []vmDisk
+	if d.HasChange("disk") {
+		if err := checkVmDisks(d.Get("disk").([]interface{})); err != nil {
+			return err
+		}
+		disks, unshrunkDisks, err = vmDisks(d, vcdClient.OrgVdc, vm)
+		if err != nil {
+			return err
+		}
+	}
+
+	changeNetworks := d.HasChange("network") && !d.IsNewResource()
+	changeNetworkLinks := false
+	if changeNetworks {
+		if err := checkVmNetworks(d.Get("network").([]interface{})); err != nil {
+			return err
+		}
+		// Connecting and disconnecting NICs does not need a power cycle
+		changeNetworkLinks = onlyVmNetworkLinksChanged(d)
+		changeNetworks = !changeNetworkLinks
+	}
+
+	var cpus, cores int
+	changeCPUs := d.HasChange("cpus") || d.HasChange("cpu_cores")
+	hotAddCPUs := false
+	if changeCPUs {
+		currentCPUs, _ := vm.CPUs()
+		cpus, cores = d.Get("cpus").(int), d.Get("cpu_cores").(int)
+		if cpus == 0 {
+			cpus = currentCPUs
+		}
+		if err := checkVmCPUs(cpus, cores); err != nil {
+			return err
+		}
+
+		// A running VM can get more CPUs when CPU hot add is enabled on it,
+		// anything else, including a change of cores per socket, needs the
+		// VM powered off
+		hotAddCPUs = status != "POWERED_OFF" && !d.HasChange("cpu_cores") && cpus > currentCPUs &&
+			vm.VM.VMCapabilities != nil && vm.VM.VMCapabilities.CPUHotAddEnabled
+	}
+
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutUpdate)
+
+	// New VMs are added powered off, and the metadata is set before they
+	// are powered on below, so that it is there when they are deployed
+	if d.HasChange("metadata") {
+		err = updateMetadata(vm.c, d, &vm, timeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	if changeStorageProfile {
+		err = retryCall(timeout, func() *resource.RetryError {
+			task, err := vm.ChangeStorageProfile(ref)
 			if err != nil {
-				return fmt.Errorf("Error Powering Off: %#v", err)
+				return resource.RetryableError(fmt.Errorf("Error changing storage profile: %#v", err))
 			}
-			err = task.WaitTaskCompletion()
+			return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+		})
+		if err != nil {
+			return fmt.Errorf("Error completing tasks: %#v", err)
+		}
+	}
+
+	// Customization only runs on first boot, so changing it on an existing VM
+	// needs a redeploy that forces it. That is only worth doing when the VM
+	// is running and stays running, otherwise the settings are just stored.
+	recustomize := false
+	if d.HasChange("customization") && !d.IsNewResource() {
+		section := vmGuestCustomization(d)
+
+		err = retryCall(timeout, func() *resource.RetryError {
+			task, err := vm.SetGuestCustomization(section)
 			if err != nil {
-				return fmt.Errorf("Error completing tasks: %#v", err)
+				return resource.RetryableError(fmt.Errorf("Error setting guest customization: %#v", err))
+			}
+			return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+		})
+		if err != nil {
+			return fmt.Errorf("Error completing tasks: %#v", err)
+		}
+
+		recustomize = section.Enabled && status != "POWERED_OFF" && d.Get("power_on").(bool)
+	}
+
+	if hotAddCPUs {
+		if err := changeVmCPUs(vm, cpus, cores, timeout); err != nil {
+			return err
+		}
+	}
+
+	if changeNetworkLinks {
+		if err := changeVmNetworkLinks(vm, d.Get("network").([]interface{}), timeout); err != nil {
+			return fmt.Errorf("Error changing network: %#v", err)
+		}
+	}
+
+	// vCD only offers to change the nested hypervisor when the VM state
+	// allows it, so the VM is only powered off when the running VM lacks
+	// the action
+	nested := d.Get("nested_hypervisor_enabled").(bool)
+	changeNested := d.HasChange("nested_hypervisor_enabled") && nested != vm.VM.NestedHypervisorEnabled
+	nestedNeedsPowerOff := changeNested && status != "POWERED_OFF" && !vm.CanChangeNestedHypervisor(nested)
+
+	if changeNested && !nestedNeedsPowerOff {
+		if err := changeVmNestedHypervisor(vm, nested, timeout); err != nil {
+			return err
+		}
+	}
+
+	if d.HasChange("memory") || (changeCPUs && !hotAddCPUs) || d.HasChange("disk") || changeNetworks || d.HasChange("power_on") || recustomize || nestedNeedsPowerOff {
+		if status != "POWERED_OFF" {
+			// Undeploying powers the VM off by itself, unless the guest is
+			// to be shut down first
+			if !recustomize || d.Get("shutdown_guest").(bool) {
+				if err := powerOffVm(d, &vm, timeout); err != nil {
+					return err
+				}
+			}
+			if recustomize {
+				task, err := vm.Undeploy()
+				if err != nil {
+					return fmt.Errorf("Error Undeploying: %#v", err)
+				}
+				err = waitTask(&vcdClient.Client, task, timeout)
+				if err != nil {
+					return fmt.Errorf("Error completing tasks: %#v", err)
+				}
 			}
 		}
 
 		if d.HasChange("memory") {
-			err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+			err = retryCall(timeout, func() *resource.RetryError {
 				task, err := vm.ChangeMemorySize(d.Get("memory").(int))
 				if err != nil {
 					return resource.RetryableError(fmt.Errorf("Error changing memory size: %#v", err))
 				}
 
-				return resource.RetryableError(task.WaitTaskCompletion())
+				return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
 			})
 			if err != nil {
 				return err
 			}
 		}
 
-		if d.HasChange("cpus") {
-			err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
-				task, err := vm.ChangeCPUcount(d.Get("cpus").(int))
+		if changeCPUs && !hotAddCPUs {
+			if err := changeVmCPUs(vm, cpus, cores, timeout); err != nil {
+				return err
+			}
+		}
+
+		if d.HasChange("disk") {
+			// Shrunk disks are removed first, so that they are added
+			// again as new disks
+			steps := [][]vmDisk{disks}
+			if unshrunkDisks != nil {
+				steps = [][]vmDisk{unshrunkDisks, disks}
+			}
+			for _, step := range steps {
+				err = retryCall(timeout, func() *resource.RetryError {
+					task, err := vm.ChangeDisks(step)
+					if err != nil {
+						return resource.RetryableError(fmt.Errorf("Error changing disks: %#v", err))
+					}
+
+					return resource.RetryableError(waitTask(&vcdClient.Client, task, timeout))
+				})
 				if err != nil {
-					return resource.RetryableError(fmt.Errorf("Error changing cpu count: %#v", err))
+					return fmt.Errorf("Error completing task: %#v", err)
 				}
+			}
+		}
 
-				return resource.RetryableError(task.WaitTaskCompletion())
-			})
+		if changeNetworks {
+			err = changeVmNetworks(vapp, vm, d.Get("network").([]interface{}), timeout)
 			if err != nil {
-				return fmt.Errorf("Error completing task: %#v", err)
+				return fmt.Errorf("Error changing network: %#v", err)
+			}
+		}
+
+		if nestedNeedsPowerOff {
+			if err := changeVmNestedHypervisor(vm, nested, timeout); err != nil {
+				return err
 			}
 		}
 
 		if d.Get("power_on").(bool) {
-			task, err := vm.PowerOn()
+			var task govcd.Task
+			if recustomize {
+				task, err = vm.PowerOnAndForceCustomization()
+			} else {
+				task, err = vm.PowerOn()
+			}
 			if err != nil {
 				return fmt.Errorf("Error Powering Up: %#v", err)
 			}
-			err = task.WaitTaskCompletion()
+			err = waitTask(&vcdClient.Client, task, timeout)
 			if err != nil {
 				return fmt.Errorf("Error completing tasks: %#v", err)
 			}
@@ -286,36 +705,491 @@ func resourceVcdVAppVmUpdate(d *schema.ResourceData, meta interface{}) error {
 func resourceVcdVAppVmRead(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
 
-	vapp, err := vcdClient.OrgVdc.FindVAppByName(d.Get("vapp_name").(string))
+	vapp, err := vcdClient.findVAppByName(d.Get("vapp_name").(string))
 
 	if err != nil {
 		return fmt.Errorf("error finding vapp: %s", err)
 	}
 
-	vm, err := vcdClient.OrgVdc.FindVMByName(vapp, d.Get("name").(string))
+	vm, err := findVM(vcdClient, vapp, d.Id(), d.Get("name").(string))
 
 	if err != nil {
 		d.SetId("")
 		return fmt.Errorf("Error getting VM3 : %#v", err)
 	}
 
+	if err := waitVmDhcpAddresses(d, &vm); err != nil {
+		return err
+	}
+
+	// Earlier versions used the name as the ID
+	d.SetId(vm.VM.HREF)
 	d.Set("name", vm.VM.Name)
-	d.Set("ip", vm.VM.NetworkConnectionSection.NetworkConnection.IPAddress)
+	if primary := vm.PrimaryNetworkConnection(); primary != nil {
+		d.Set("ip", primary.IPAddress)
+	}
 	d.Set("href", vm.VM.HREF)
+	if vm.VM.StorageProfile != nil {
+		d.Set("storage_profile", vm.VM.StorageProfile.Name)
+	}
+	if cpus, cores := vm.CPUs(); cpus > 0 {
+		d.Set("cpus", cpus)
+		if cores > 0 {
+			d.Set("cpu_cores", cores)
+		}
+	}
+	d.Set("nested_hypervisor_enabled", vm.VM.NestedHypervisorEnabled)
+
+	err = readMetadata(d, &vm)
+	if err != nil {
+		return err
+	}
+
+	if len(d.Get("network").([]interface{})) > 0 {
+		err = d.Set("network", flattenVmNetworks(vm.NetworkConnections()))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = d.Set("disk", flattenVmDisks(d.Get("disk").([]interface{}), vm.GetDisks(), vcdClient.OrgVdc))
+	if err != nil {
+		return err
+	}
+
+	if _, ok := d.GetOk("customization"); ok && vm.GuestCustomization() != nil {
+		err = d.Set("customization", flattenGuestCustomization(vm.GuestCustomization(), d.Get("customization.0.admin_password").(string)))
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// findVM looks a VM of the vApp up by its href, falling back to its name for
+// the resources created by earlier versions, which used it as the ID
+func findVM(vcdClient *VCDClient, vapp vcdVApp, id, name string) (vcdVM, error) {
+	if uuid := uuidFromHref(id); uuid != "" && vapp.VApp.Children != nil {
+		for _, child := range vapp.VApp.Children.VM {
+			if uuidFromHref(child.HREF) == uuid {
+				name = child.Name
+				break
+			}
+		}
+	}
+
+	return vcdClient.findVMByName(vapp, name)
+}
+
+// vmGuestCustomization builds the guest customization section for the VM,
+// disabling customization when the block has been removed.
+func vmGuestCustomization(d *schema.ResourceData) *types.GuestCustomizationSection {
+	section := &types.GuestCustomizationSection{}
+	if c, ok := d.GetOk("customization"); ok {
+		section = expandGuestCustomization(c.([]interface{}))
+	}
+
+	if section.ComputerName == "" {
+		section.ComputerName = d.Get("name").(string)
+	}
+
+	return section
+}
+
+// changeVmNetworks connects the VM to the networks of its network blocks,
+// which must all be networks of the vApp.
+func changeVmNetworks(vapp vcdVApp, vm vcdVM, networks []interface{}, timeout int) error {
+	vAppNetworkConfig, err := vapp.GetNetworkConfig()
+	if err != nil {
+		return fmt.Errorf("Error getting vApp networks: %#v", err)
+	}
+
+	connections, primary := expandVmNetworks(networks)
+	for _, connection := range connections {
+		if findVAppNetworkConfig(vAppNetworkConfig, connection.Network) == nil {
+			return fmt.Errorf("Network %s is not a network of vApp %s", connection.Network, vapp.VApp.Name)
+		}
+	}
+
+	return retryCall(timeout, func() *resource.RetryError {
+		task, err := vm.ChangeNetworkConnections(connections, primary)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error changing network connections: %#v", err))
+		}
+		return resource.RetryableError(waitTask(vm.c, task, timeout))
+	})
+}
+
+// changeVmNetworkLinks connects and disconnects the NICs of the VM as its
+// network blocks say, keeping everything else about them. vCD does this on a
+// running VM.
+func changeVmNetworkLinks(vm vcdVM, networks []interface{}, timeout int) error {
+	section := vm.NetworkConnections()
+	if section == nil {
+		return fmt.Errorf("VM %s has no NICs", vm.VM.Name)
+	}
+
+	connections := make([]*networkConnectionType, 0, len(section.NetworkConnection))
+	for _, connection := range section.NetworkConnection {
+		if i := connection.NetworkConnectionIndex; i < len(networks) {
+			connection.IsConnected = networks[i].(map[string]interface{})["connected"].(bool)
+		}
+		connections = append(connections, connection)
+	}
+
+	return retryCall(timeout, func() *resource.RetryError {
+		task, err := vm.ChangeNetworkConnections(connections, section.PrimaryNetworkConnectionIndex)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error changing network connections: %#v", err))
+		}
+		return resource.RetryableError(waitTask(vm.c, task, timeout))
+	})
+}
+
+// onlyVmNetworkLinksChanged reports whether the network blocks only changed
+// in whether their NICs are connected.
+func onlyVmNetworkLinksChanged(d *schema.ResourceData) bool {
+	o, n := d.GetChange("network")
+	oldNetworks, newNetworks := o.([]interface{}), n.([]interface{})
+	if len(oldNetworks) != len(newNetworks) {
+		return false
+	}
+
+	for i := range newNetworks {
+		oldData, newData := oldNetworks[i].(map[string]interface{}), newNetworks[i].(map[string]interface{})
+		for k, v := range newData {
+			if k != "connected" && oldData[k] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// expandVmNetworks returns the NICs of the network blocks, numbered in the
+// order of the blocks, and the index of the primary NIC.
+func expandVmNetworks(networks []interface{}) ([]*networkConnectionType, int) {
+	connections := make([]*networkConnectionType, 0, len(networks))
+	primary := 0
+
+	for i, n := range networks {
+		data := n.(map[string]interface{})
+
+		connection := &networkConnectionType{
+			Network:                 data["name"].(string),
+			NeedsCustomization:      true,
+			NetworkConnectionIndex:  i,
+			IsConnected:             data["connected"].(bool),
+			IPAddressAllocationMode: data["ip_allocation_mode"].(string),
+			NetworkAdapterType:      data["adapter_type"].(string),
+		}
+		// The ip of other modes is the one vCD assigned, so it is not sent
+		if connection.IPAddressAllocationMode == "MANUAL" {
+			connection.IPAddress = data["ip"].(string)
+		}
+		if data["is_primary"].(bool) {
+			primary = i
+		}
+
+		connections = append(connections, connection)
+	}
+
+	return connections, primary
+}
+
+func flattenVmNetworks(section *networkConnectionSectionType) []map[string]interface{} {
+	if section == nil {
+		return nil
+	}
+
+	connections := make([]*networkConnectionType, len(section.NetworkConnection))
+	copy(connections, section.NetworkConnection)
+	sort.Slice(connections, func(i, j int) bool {
+		return connections[i].NetworkConnectionIndex < connections[j].NetworkConnectionIndex
+	})
+
+	result := make([]map[string]interface{}, 0, len(connections))
+	for _, connection := range connections {
+		result = append(result, map[string]interface{}{
+			"name":               connection.Network,
+			"ip_allocation_mode": connection.IPAddressAllocationMode,
+			"ip":                 connection.IPAddress,
+			"is_primary":         connection.NetworkConnectionIndex == section.PrimaryNetworkConnectionIndex,
+			"adapter_type":       connection.NetworkAdapterType,
+			"connected":          connection.IsConnected,
+		})
+	}
+	return result
+}
+
+// checkVmNetworks requires a single primary NIC when the VM has more than
+// one, and an ip for each NIC with the MANUAL allocation mode.
+func checkVmNetworks(networks []interface{}) error {
+	primaries := 0
+	for i, n := range networks {
+		data := n.(map[string]interface{})
+		if data["is_primary"].(bool) {
+			primaries++
+		}
+		if data["ip_allocation_mode"].(string) == "MANUAL" && data["ip"].(string) == "" {
+			return fmt.Errorf("Network %d (%s) uses the MANUAL ip_allocation_mode, which needs an ip", i, data["name"].(string))
+		}
+	}
+
+	if primaries > 1 || (primaries == 0 && len(networks) > 1) {
+		return fmt.Errorf("Exactly one network must set is_primary, %d do", primaries)
+	}
+	return nil
+}
+
+func validateVmIPAllocationMode(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	switch value {
+	case "POOL", "DHCP", "MANUAL", "NONE":
+	default:
+		errors = append(errors, fmt.Errorf("%q must be one of POOL, DHCP, MANUAL or NONE, got %q", k, value))
+	}
+	return
+}
+
+// vmDisks returns the disks the VM should have after an update. Disks the
+// configuration does not manage, such as most template disks, are kept as
+// they are. Managed disks are keyed by bus and unit, and may only grow
+// unless allow_disk_shrink is set. Shrunk disks are then replaced, and the
+// disks to set first, with the shrunk ones removed, are returned as well.
+func vmDisks(d *schema.ResourceData, vdc govcd.Vdc, vm vcdVM) ([]vmDisk, []vmDisk, error) {
+	oldDisks, newDisks := d.GetChange("disk")
+
+	moves, err := vmDiskMoves(oldDisks.([]interface{}), newDisks.([]interface{}), vm.GetDisks())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	removed := make(map[string]bool)
+	for _, o := range oldDisks.([]interface{}) {
+		removed[vmDiskKey(o.(map[string]interface{}))] = true
+	}
+
+	configured := make(map[string]vmDisk)
+	var order []string
+	for _, n := range newDisks.([]interface{}) {
+		data := n.(map[string]interface{})
+		disk := vmDisk{
+			BusType:    vmDiskBusTypes[data["bus_type"].(string)],
+			BusNumber:  data["bus_number"].(int),
+			UnitNumber: data["unit_number"].(int),
+			SizeMB:     data["size_in_mb"].(int),
+		}
+		if name := data["storage_profile"].(string); name != "" {
+			ref, err := vdc.FindStorageProfileReference(name)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Error finding storage profile %s: %#v", name, err)
+			}
+			disk.StorageProfileHREF = ref.HREF
+		}
+
+		key := vmDiskKey(data)
+		configured[key] = disk
+		order = append(order, key)
+		delete(removed, key)
+	}
+
+	var disks, unshrunk []vmDisk
+	shrunk := false
+	for _, current := range vm.GetDisks() {
+		key := fmt.Sprintf("%s %d:%d", vmDiskBusName(current.BusType), current.BusNumber, current.UnitNumber)
+		if to, ok := moves[key]; ok {
+			from := current
+			log.Printf("[DEBUG] Moving disk %s to %s", key, to)
+			key = to
+			disk := configured[key]
+			disk.MoveFrom = &from
+			configured[key] = disk
+		}
+		disk, ok := configured[key]
+		if !ok {
+			if !removed[key] {
+				disks = append(disks, current)
+				unshrunk = append(unshrunk, current)
+			}
+			continue
+		}
+
+		disks = append(disks, disk)
+		delete(configured, key)
+		if disk.SizeMB >= current.SizeMB {
+			unshrunk = append(unshrunk, disk)
+			continue
+		}
+
+		if !d.Get("allow_disk_shrink").(bool) {
+			return nil, nil, fmt.Errorf("Disk %s cannot shrink from %d MB to %d MB: vCD cannot shrink disks in place. "+
+				"Set allow_disk_shrink to replace it with a new, empty disk, losing its data", key, current.SizeMB, disk.SizeMB)
+		}
+		log.Printf("[DEBUG] Replacing disk %s to shrink it from %d MB to %d MB", key, current.SizeMB, disk.SizeMB)
+		shrunk = true
+	}
+
+	for _, key := range order {
+		if disk, ok := configured[key]; ok {
+			disks = append(disks, disk)
+			unshrunk = append(unshrunk, disk)
+		}
+	}
+
+	if !shrunk {
+		return disks, nil, nil
+	}
+	return disks, unshrunk, nil
+}
+
+// vmDiskMoves pairs the disk blocks whose bus or unit changed with the disks
+// they managed, keyed by the old placement, so that the disks are moved
+// instead of replaced by new, empty ones. A block counts as moved when the
+// block at the same position managed a disk which no block manages anymore,
+// and no disk is at its new placement yet. vCD only moves disks between buses
+// of the same type, so changing the bus type is refused.
+func vmDiskMoves(oldDisks, newDisks []interface{}, current []vmDisk) (map[string]string, error) {
+	oldKeys := make(map[string]bool)
+	for _, o := range oldDisks {
+		oldKeys[vmDiskKey(o.(map[string]interface{}))] = true
+	}
+	newKeys := make(map[string]bool)
+	for _, n := range newDisks {
+		newKeys[vmDiskKey(n.(map[string]interface{}))] = true
+	}
+	currentKeys := make(map[string]bool)
+	for _, disk := range current {
+		currentKeys[fmt.Sprintf("%s %d:%d", vmDiskBusName(disk.BusType), disk.BusNumber, disk.UnitNumber)] = true
+	}
+
+	moves := make(map[string]string)
+	for i := 0; i < len(oldDisks) && i < len(newDisks); i++ {
+		o, n := oldDisks[i].(map[string]interface{}), newDisks[i].(map[string]interface{})
+		from, to := vmDiskKey(o), vmDiskKey(n)
+		if from == to || newKeys[from] || oldKeys[to] || !currentKeys[from] || currentKeys[to] {
+			continue
+		}
+		if o["bus_type"].(string) != n["bus_type"].(string) {
+			return nil, fmt.Errorf("Disk %s cannot move to %s: vCD only moves disks between buses of the same type. "+
+				"Remove the block and add the new disk in a later apply, or taint the VM to recreate it", from, to)
+		}
+		moves[from] = to
+	}
+	return moves, nil
+}
+
+// flattenVmDisks refreshes the configured disks from the disks of the VM,
+// dropping the ones which no longer exist so they are added again.
+func flattenVmDisks(configured []interface{}, current []vmDisk, vdc govcd.Vdc) []map[string]interface{} {
+	byKey := make(map[string]vmDisk, len(current))
+	for _, disk := range current {
+		byKey[fmt.Sprintf("%s %d:%d", vmDiskBusName(disk.BusType), disk.BusNumber, disk.UnitNumber)] = disk
+	}
+
+	result := make([]map[string]interface{}, 0, len(configured))
+	for _, c := range configured {
+		data := c.(map[string]interface{})
+		disk, ok := byKey[vmDiskKey(data)]
+		if !ok {
+			continue
+		}
+
+		result = append(result, map[string]interface{}{
+			"bus_type":        data["bus_type"],
+			"bus_number":      disk.BusNumber,
+			"unit_number":     disk.UnitNumber,
+			"size_in_mb":      disk.SizeMB,
+			"storage_profile": storageProfileName(vdc, disk.StorageProfileHREF),
+		})
+	}
+	return result
+}
+
+// checkVmDisks rejects disks outside of the buses and units vCD can address,
+// and disks sharing a bus and unit. The whole list is needed for the latter,
+// so it cannot be checked by a ValidateFunc.
+func checkVmDisks(disks []interface{}) error {
+	seen := make(map[string]bool)
+	for _, d := range disks {
+		data := d.(map[string]interface{})
+		bus := data["bus_number"].(int)
+		unit := data["unit_number"].(int)
+		key := vmDiskKey(data)
+
+		switch data["bus_type"].(string) {
+		case "scsi":
+			if bus < 0 || bus > 3 || unit < 0 || unit > 15 || unit == 7 {
+				return fmt.Errorf("Disk %s is invalid: scsi buses are numbered 0 to 3 and units 0 to 15, where unit 7 is reserved for the controller", key)
+			}
+		case "ide":
+			if bus < 0 || bus > 1 || unit < 0 || unit > 1 {
+				return fmt.Errorf("Disk %s is invalid: ide buses and units are numbered 0 to 1", key)
+			}
+		}
+
+		if seen[key] {
+			return fmt.Errorf("Disk %s is defined more than once", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+var vmDiskBusTypes = map[string]int{
+	"ide":  diskBusIDE,
+	"scsi": diskBusSCSI,
+}
+
+func vmDiskBusName(busType int) string {
+	for name, t := range vmDiskBusTypes {
+		if t == busType {
+			return name
+		}
+	}
+	return strconv.Itoa(busType)
+}
+
+func vmDiskKey(data map[string]interface{}) string {
+	return fmt.Sprintf("%s %d:%d", data["bus_type"].(string), data["bus_number"].(int), data["unit_number"].(int))
+}
+
+// storageProfileName returns the name of the VDC storage profile with the
+// given href, or an empty string when there is none.
+func storageProfileName(vdc govcd.Vdc, href string) string {
+	for _, sps := range vdc.Vdc.VdcStorageProfiles {
+		for _, sp := range sps.VdcStorageProfile {
+			if sp.HREF == href {
+				return sp.Name
+			}
+		}
+	}
+	return ""
+}
+
+func validateVmDiskBusType(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if _, ok := vmDiskBusTypes[value]; !ok {
+		errors = append(errors, fmt.Errorf("%q must be one of scsi or ide, got %q", k, value))
+	}
+	return
+}
+
 func resourceVcdVAppVmDelete(d *schema.ResourceData, meta interface{}) error {
 	vcdClient := meta.(*VCDClient)
 
-	vapp, err := vcdClient.OrgVdc.FindVAppByName(d.Get("vapp_name").(string))
+	vappName := d.Get("vapp_name").(string)
+	vAppMutexKV.Lock(vappName)
+	defer vAppMutexKV.Unlock(vappName)
+
+	vapp, err := vcdClient.findVAppByName(d.Get("vapp_name").(string))
 
 	if err != nil {
 		return fmt.Errorf("error finding vapp: %s", err)
 	}
 
-	vm, err := vcdClient.OrgVdc.FindVMByName(vapp, d.Get("name").(string))
+	vm, err := findVM(vcdClient, vapp, d.Id(), d.Get("name").(string))
 
 	if err != nil {
 		return fmt.Errorf("Error getting VM4 : %#v", err)
@@ -326,22 +1200,38 @@ func resourceVcdVAppVmDelete(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("Error getting vApp status: %#v", err)
 	}
 
+	timeout := vcdClient.retryTimeout(d, schema.TimeoutDelete)
+
 	log.Printf("[TRACE] Vapp Status:: %s", status)
 	if status != "POWERED_OFF" {
+		// Undeploying the vApp powers the VM off, so give its guest the
+		// chance to shut down first
+		if d.Get("shutdown_guest").(bool) {
+			vmStatus, err := vm.GetStatus()
+			if err != nil {
+				return fmt.Errorf("Error getting VM status: %#v", err)
+			}
+			if vmStatus != "POWERED_OFF" {
+				if err := powerOffVm(d, &vm, timeout); err != nil {
+					return err
+				}
+			}
+		}
+
 		log.Printf("[TRACE] Undeploying vApp: %s", vapp.VApp.Name)
 		task, err := vapp.Undeploy()
 		if err != nil {
 			return fmt.Errorf("Error Undeploying vApp: %#v", err)
 		}
-		err = task.WaitTaskCompletion()
+		err = waitTask(&vcdClient.Client, task, timeout)
 		if err != nil {
 			return fmt.Errorf("Error completing tasks: %#v", err)
 		}
 	}
 
-	err = retryCall(vcdClient.MaxRetryTimeout, func() *resource.RetryError {
+	err = retryCall(timeout, func() *resource.RetryError {
 		log.Printf("[TRACE] Removing VM: %s", vm.VM.Name)
-		err := vapp.RemoveVM(vm)
+		err := vapp.RemoveVM(vm.govcdVM)
 		if err != nil {
 			return resource.RetryableError(fmt.Errorf("Error deleting: %#v", err))
 		}
@@ -355,7 +1245,7 @@ func resourceVcdVAppVmDelete(d *schema.ResourceData, meta interface{}) error {
 		if err != nil {
 			return fmt.Errorf("Error Deploying vApp: %#v", err)
 		}
-		err = task.WaitTaskCompletion()
+		err = waitTask(&vcdClient.Client, task, timeout)
 		if err != nil {
 			return fmt.Errorf("Error completing tasks: %#v", err)
 		}
@@ -365,7 +1255,7 @@ func resourceVcdVAppVmDelete(d *schema.ResourceData, meta interface{}) error {
 		if err != nil {
 			return fmt.Errorf("Error Powering on vApp: %#v", err)
 		}
-		err = task.WaitTaskCompletion()
+		err = waitTask(&vcdClient.Client, task, timeout)
 		if err != nil {
 			return fmt.Errorf("Error completing tasks: %#v", err)
 		}
@@ -373,3 +1263,199 @@ func resourceVcdVAppVmDelete(d *schema.ResourceData, meta interface{}) error {
 
 	return err
 }
+
+func changeVmCPUs(vm vcdVM, cpus, cores, timeout int) error {
+	err := retryCall(timeout, func() *resource.RetryError {
+		task, err := vm.ChangeCPUCountWithCores(cpus, cores)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error changing cpu count: %#v", err))
+		}
+
+		return resource.RetryableError(waitTask(vm.c, task, timeout))
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing task: %#v", err)
+	}
+	return nil
+}
+
+// changeVmNestedHypervisor enables or disables the nested hypervisor of the
+// VM, failing when vCD does not offer to, as the host lacks
+// hardware-assisted virtualization or vCD is older than 5.1.
+func changeVmNestedHypervisor(vm vcdVM, enabled bool, timeout int) error {
+	// The links of the VM change with its power state
+	if err := vm.Refresh(); err != nil {
+		return fmt.Errorf("Error refreshing VM: %#v", err)
+	}
+	if !vm.CanChangeNestedHypervisor(enabled) {
+		return fmt.Errorf("Unable to set nested_hypervisor_enabled to %t on VM %s: vCD does not offer it, "+
+			"the VM host must support hardware-assisted virtualization and vCD must be 5.1 or later", enabled, vm.VM.Name)
+	}
+
+	err := retryCall(timeout, func() *resource.RetryError {
+		task, err := vm.ChangeNestedHypervisor(enabled)
+		if err != nil {
+			return resource.RetryableError(fmt.Errorf("Error changing nested hypervisor: %#v", err))
+		}
+
+		return resource.RetryableError(waitTask(vm.c, task, timeout))
+	})
+	if err != nil {
+		return fmt.Errorf("Error completing task: %#v", err)
+	}
+	return nil
+}
+
+// powerOffVm shuts the guest operating system of the VM down when
+// shutdown_guest is set and VMware Tools are installed, and powers the VM off
+// when it is not, or when the guest is not down within shutdown_timeout.
+// vCD is given timeout seconds to power the VM off.
+func powerOffVm(d *schema.ResourceData, vm *vcdVM, timeout int) error {
+	if d.Get("shutdown_guest").(bool) && vm.HasVMwareTools() {
+		off := shutdownGuest(func(timeout int) error {
+			task, err := vm.Shutdown()
+			if err != nil {
+				return err
+			}
+			return waitTask(vm.c, task, timeout)
+		}, func() (bool, error) {
+			status, err := vm.GetStatus()
+			return status == "POWERED_OFF", err
+		}, d.Get("shutdown_timeout").(int))
+		if off {
+			return nil
+		}
+	} else if d.Get("shutdown_guest").(bool) {
+		log.Printf("[DEBUG] VM %s has no VMware Tools, powering it off", vm.VM.Name)
+	}
+
+	task, err := vm.PowerOff()
+	if err != nil {
+		return fmt.Errorf("Error Powering Off: %#v", err)
+	}
+	err = waitTask(vm.c, task, timeout)
+	if err != nil {
+		return fmt.Errorf("Error completing tasks: %#v", err)
+	}
+
+	return nil
+}
+
+// shutdownGuest asks the guest operating system to shut down and waits for
+// poweredOff to report it down, taking up to timeout seconds for both. It
+// reports false when the shutdown failed or took too long, in which case the
+// caller powers off.
+func shutdownGuest(shutdown func(timeout int) error, poweredOff func() (bool, error), timeout int) bool {
+	start := time.Now()
+	if err := shutdown(timeout); err != nil {
+		log.Printf("[DEBUG] Guest shutdown failed: %s", err)
+		return false
+	}
+
+	// Poll at least once for the time left after the shutdown task
+	remaining := timeout - int(time.Since(start).Seconds())
+	if remaining < 1 {
+		remaining = 1
+	}
+
+	err := retryCall(remaining, func() *resource.RetryError {
+		off, err := poweredOff()
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+		if !off {
+			return resource.RetryableError(fmt.Errorf("guest is still running"))
+		}
+		return nil
+	})
+	if err != nil {
+		// The guest may have finished shutting down in the meantime
+		if off, pollErr := poweredOff(); pollErr == nil && off {
+			return true
+		}
+		log.Printf("[DEBUG] Guest not shut down within %d seconds: %s", timeout, err)
+		return false
+	}
+
+	return true
+}
+
+// waitVmDhcpAddresses reads a powered on VM again, up to dhcp_wait_attempts
+// times, until the guest reported the address of each connected NIC using
+// DHCP. It waits dhcp_wait_interval seconds before the first attempt and
+// twice as long before each next one. Addresses the guest did not report by
+// then are left empty.
+func waitVmDhcpAddresses(d *schema.ResourceData, vm *vcdVM) error {
+	attempts := d.Get("dhcp_wait_attempts").(int)
+	if attempts == 0 || !vmLacksDhcpAddress(vm) {
+		return nil
+	}
+
+	status, err := vm.GetStatus()
+	if err != nil {
+		return fmt.Errorf("Error getting VM status: %#v", err)
+	}
+	if status != "POWERED_ON" {
+		return nil
+	}
+
+	interval := time.Duration(d.Get("dhcp_wait_interval").(int)) * time.Second
+	for i := 0; i < attempts && vmLacksDhcpAddress(vm); i++ {
+		log.Printf("[DEBUG] Waiting %s for the guest of VM %s to report its DHCP addresses", interval, vm.VM.Name)
+		time.Sleep(interval)
+		interval *= 2
+
+		if err := vm.Refresh(); err != nil {
+			return fmt.Errorf("Error refreshing VM: %#v", err)
+		}
+	}
+
+	return nil
+}
+
+// vmLacksDhcpAddress reports whether a connected NIC of the VM uses DHCP and
+// has no address yet.
+func vmLacksDhcpAddress(vm *vcdVM) bool {
+	section := vm.NetworkConnections()
+	if section == nil {
+		return false
+	}
+
+	for _, connection := range section.NetworkConnection {
+		if connection.IsConnected && connection.IPAddressAllocationMode == "DHCP" && connection.IPAddress == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func validateDhcpWait(v interface{}, k string) (ws []string, errors []error) {
+	if value := v.(int); value < 0 {
+		errors = append(errors, fmt.Errorf("%q must not be negative, got %d", k, value))
+	}
+	return
+}
+
+func validateShutdownTimeout(v interface{}, k string) (ws []string, errors []error) {
+	if value := v.(int); value < 1 {
+		errors = append(errors, fmt.Errorf("%q must be at least 1 second, got %d", k, value))
+	}
+	return
+}
+
+// checkVmCPUs verifies that the CPUs can be spread evenly over sockets of
+// cores CPUs. Terraform cannot compare fields when planning, so this is done
+// before the VM is changed.
+func checkVmCPUs(cpus, cores int) error {
+	if cores > 0 && cpus%cores != 0 {
+		return fmt.Errorf("cpus (%d) must be a multiple of cpu_cores (%d)", cpus, cores)
+	}
+	return nil
+}
+
+func validateVmCPUCores(v interface{}, k string) (ws []string, errors []error) {
+	if value := v.(int); value < 1 {
+		errors = append(errors, fmt.Errorf("%q must be at least 1, got %d", k, value))
+	}
+	return
+}
@@ -3,6 +3,7 @@ package vcd
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
@@ -12,7 +13,7 @@ import (
 
 func TestAccVcdVAppVm_Basic(t *testing.T) {
 	var vapp govcd.VApp
-	var vm govcd.VM
+	var vm vcdVM
 
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
@@ -25,17 +26,196 @@ func TestAccVcdVAppVm_Basic(t *testing.T) {
 					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
 					resource.TestCheckResourceAttr(
 						"vcd_vapp_vm.moo", "name", "moo"),
+					resource.TestCheckResourceAttrPair(
+						"vcd_vapp_vm.moo", "id", "vcd_vapp_vm.moo", "href"),
 					resource.TestCheckResourceAttr(
 						"vcd_vapp_vm.moo", "ip", "10.10.102.161"),
 					resource.TestCheckResourceAttr(
 						"vcd_vapp_vm.moo", "power_on", "true"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "metadata.cost_center", "1234"),
+				),
+			},
+			resource.TestStep{
+				// The href IDs must be found again on refresh
+				Config:   fmt.Sprintf(testAccCheckVcdVAppVm_basic, os.Getenv("VCD_EDGE_GATEWAY")),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestAccVcdVAppVm_Customization(t *testing.T) {
+	var vapp govcd.VApp
+	var vm vcdVM
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdVAppVmDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_customization, os.Getenv("VCD_EDGE_GATEWAY"), "moo"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "customization.0.computer_name", "moo"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "customization.0.initscript.#", "2"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_customization, os.Getenv("VCD_EDGE_GATEWAY"), "moo2"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "customization.0.computer_name", "moo2"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "power_on", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVcdVAppVm_StorageProfile(t *testing.T) {
+	var vapp govcd.VApp
+	var vm vcdVM
+
+	if v := os.Getenv("VCD_STORAGE_PROFILE"); v == "" {
+		t.Skip("Environment variable VCD_STORAGE_PROFILE must be set to run VM storage profile tests")
+		return
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdVAppVmDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_storageProfile, os.Getenv("VCD_EDGE_GATEWAY"), os.Getenv("VCD_STORAGE_PROFILE")),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					testAccCheckVcdVAppVmStorageProfile(&vm, os.Getenv("VCD_STORAGE_PROFILE")),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "storage_profile", os.Getenv("VCD_STORAGE_PROFILE")),
+				),
+			},
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckVcdVAppVm_storageProfile, os.Getenv("VCD_EDGE_GATEWAY"), "no-such-profile"),
+				ExpectError: regexp.MustCompile("Error finding storage profile no-such-profile"),
+			},
+		},
+	})
+}
+
+func TestAccVcdVAppVm_Disks(t *testing.T) {
+	var vapp govcd.VApp
+	var vm vcdVM
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdVAppVmDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_disks, os.Getenv("VCD_EDGE_GATEWAY"), 1024),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					testAccCheckVcdVAppVmDisk(&vm, 1, 0, 1024),
+					testAccCheckVcdVAppVmDisk(&vm, 1, 1, 2048),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "disk.#", "2"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "disk.0.size_in_mb", "1024"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_disks, os.Getenv("VCD_EDGE_GATEWAY"), 4096),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					testAccCheckVcdVAppVmDisk(&vm, 1, 0, 4096),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "disk.0.size_in_mb", "4096"),
+				),
+			},
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckVcdVAppVm_disks, os.Getenv("VCD_EDGE_GATEWAY"), 2048),
+				ExpectError: regexp.MustCompile("cannot shrink from 4096 MB to 2048 MB"),
+			},
+		},
+	})
+}
+
+func TestAccVcdVAppVm_Networks(t *testing.T) {
+	var vapp govcd.VApp
+	var vm vcdVM
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdVAppVmDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_networks, os.Getenv("VCD_EDGE_GATEWAY"), true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "network.#", "2"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "network.0.name", "isolated"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "network.0.is_primary", "false"),
+					resource.TestCheckResourceAttrSet(
+						"vcd_vapp_vm.moo", "network.0.ip"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "network.1.name", "routed"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "network.1.ip", "192.168.3.10"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "network.1.is_primary", "true"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "ip", "192.168.3.10"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_networks, os.Getenv("VCD_EDGE_GATEWAY"), false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "network.0.connected", "false"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "network.1.connected", "true"),
 				),
 			},
 		},
 	})
 }
 
-func testAccCheckVcdVAppVmExists(n string, vapp *govcd.VApp, vm *govcd.VM) resource.TestCheckFunc {
+func testAccCheckVcdVAppVmDisk(vm *vcdVM, bus, unit, size int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		for _, disk := range vm.GetDisks() {
+			if disk.BusType == diskBusSCSI && disk.BusNumber == bus && disk.UnitNumber == unit {
+				if disk.SizeMB != size {
+					return fmt.Errorf("Disk %d:%d has %d MB, expected %d MB", bus, unit, disk.SizeMB, size)
+				}
+				return nil
+			}
+		}
+		return fmt.Errorf("Disk %d:%d not found", bus, unit)
+	}
+}
+
+func testAccCheckVcdVAppVmStorageProfile(vm *vcdVM, name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if vm.VM.StorageProfile == nil || vm.VM.StorageProfile.Name != name {
+			return fmt.Errorf("VM storage profile is %#v, expected %s", vm.VM.StorageProfile, name)
+		}
+		return nil
+	}
+}
+
+func testAccCheckVcdVAppVmExists(n string, vapp *govcd.VApp, vm *vcdVM) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
 		if !ok {
@@ -48,9 +228,9 @@ func testAccCheckVcdVAppVmExists(n string, vapp *govcd.VApp, vm *govcd.VM) resou
 
 		conn := testAccProvider.Meta().(*VCDClient)
 
-		vapp, err := conn.OrgVdc.FindVAppByName("foobar")
+		vapp, err := conn.findVAppByName("foobar")
 
-		resp, err := conn.OrgVdc.FindVMByName(vapp, "moo")
+		resp, err := conn.findVMByName(vapp, "moo")
 
 		if err != nil {
 			return err
@@ -70,7 +250,7 @@ func testAccCheckVcdVAppVmDestroy(s *terraform.State) error {
 			continue
 		}
 
-		_, err := conn.OrgVdc.FindVAppByName("foobar")
+		_, err := conn.findVAppByName("foobar")
 
 		if err == nil {
 			return fmt.Errorf("VPCs still exist")
@@ -82,6 +262,137 @@ func testAccCheckVcdVAppVmDestroy(s *terraform.State) error {
 	return nil
 }
 
+func TestAccVcdVAppVm_CPUCores(t *testing.T) {
+	var vapp govcd.VApp
+	var vm vcdVM
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdVAppVmDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_cpuCores, os.Getenv("VCD_EDGE_GATEWAY"), 4, 2),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					testAccCheckVcdVAppVmCPUs(&vm, 4, 2),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "cpus", "4"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "cpu_cores", "2"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_cpuCores, os.Getenv("VCD_EDGE_GATEWAY"), 4, 4),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					testAccCheckVcdVAppVmCPUs(&vm, 4, 4),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "cpu_cores", "4"),
+				),
+			},
+			resource.TestStep{
+				Config:      fmt.Sprintf(testAccCheckVcdVAppVm_cpuCores, os.Getenv("VCD_EDGE_GATEWAY"), 3, 2),
+				ExpectError: regexp.MustCompile(`cpus \(3\) must be a multiple of cpu_cores \(2\)`),
+			},
+		},
+	})
+}
+
+func TestAccVcdVAppVm_NestedHypervisor(t *testing.T) {
+	var vapp govcd.VApp
+	var vm vcdVM
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdVAppVmDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_nestedHypervisor, os.Getenv("VCD_EDGE_GATEWAY"), true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					testAccCheckVcdVAppVmNestedHypervisor(&vm, true),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "nested_hypervisor_enabled", "true"),
+				),
+			},
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_nestedHypervisor, os.Getenv("VCD_EDGE_GATEWAY"), false),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					testAccCheckVcdVAppVmNestedHypervisor(&vm, false),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "nested_hypervisor_enabled", "false"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "power_on", "true"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVcdVAppVm_ShutdownGuest(t *testing.T) {
+	var vapp govcd.VApp
+	var vm vcdVM
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVcdVAppVmDestroy,
+		Steps: []resource.TestStep{
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_shutdownGuest, os.Getenv("VCD_EDGE_GATEWAY"), 1024),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "shutdown_guest", "true"),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "shutdown_timeout", "120"),
+				),
+			},
+			// Changing the memory needs the VM to be shut down and powered on again
+			resource.TestStep{
+				Config: fmt.Sprintf(testAccCheckVcdVAppVm_shutdownGuest, os.Getenv("VCD_EDGE_GATEWAY"), 2048),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVcdVAppVmExists("vcd_vapp_vm.moo", &vapp, &vm),
+					testAccCheckVcdVAppVmMemory(&vm, 2048),
+					resource.TestCheckResourceAttr(
+						"vcd_vapp_vm.moo", "power_on", "true"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVcdVAppVmMemory(vm *vcdVM, memory int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if got := vm.Memory(); got != memory {
+			return fmt.Errorf("VM memory is %d MB, expected %d MB", got, memory)
+		}
+		return nil
+	}
+}
+
+func testAccCheckVcdVAppVmNestedHypervisor(vm *vcdVM, enabled bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if vm.VM.NestedHypervisorEnabled != enabled {
+			return fmt.Errorf("VM nested hypervisor enabled is %t, expected %t", vm.VM.NestedHypervisorEnabled, enabled)
+		}
+		return nil
+	}
+}
+
+func testAccCheckVcdVAppVmCPUs(vm *vcdVM, cpus, cores int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		count, coresPerSocket := vm.CPUs()
+		if count != cpus || coresPerSocket != cores {
+			return fmt.Errorf("VM has %d CPUs with %d cores per socket, expected %d with %d", count, coresPerSocket, cpus, cores)
+		}
+		return nil
+	}
+}
+
 const testAccCheckVcdVAppVm_basic = `
 resource "vcd_network" "foonet" {
 	name = "foonet"
@@ -111,5 +422,287 @@ resource "vcd_vapp_vm" "moo" {
   memory        = 1024
   cpus          = 1
   ip            = "10.10.102.161"
+
+  metadata {
+    cost_center = "1234"
+  }
+}
+`
+
+const testAccCheckVcdVAppVm_customization = `
+resource "vcd_network" "foonet" {
+	name = "foonet"
+	edge_gateway = "%s"
+	gateway = "10.10.102.1"
+	static_ip_pool {
+		start_address = "10.10.102.2"
+		end_address = "10.10.102.254"
+	}
+}
+
+resource "vcd_vapp" "foobar" {
+  name          = "foobar"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  catalog_name  = "Skyscape Catalogue"
+  network_name  = "${vcd_network.foonet.name}"
+  memory        = 1024
+  cpus          = 1
+  ip            = "10.10.102.160"
+}
+
+resource "vcd_vapp_vm" "moo" {
+  vapp_name     = "${vcd_vapp.foobar.name}"
+  name          = "moo"
+  catalog_name  = "Skyscape Catalogue"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  memory        = 1024
+  cpus          = 1
+  ip            = "10.10.102.161"
+
+  customization {
+    computer_name       = "%s"
+    admin_password_auto = true
+    initscript          = [
+      "touch /tmp/customized",
+      "echo done >> /tmp/customized",
+    ]
+  }
+}
+`
+
+const testAccCheckVcdVAppVm_storageProfile = `
+resource "vcd_network" "foonet" {
+	name = "foonet"
+	edge_gateway = "%s"
+	gateway = "10.10.102.1"
+	static_ip_pool {
+		start_address = "10.10.102.2"
+		end_address = "10.10.102.254"
+	}
+}
+
+resource "vcd_vapp" "foobar" {
+  name          = "foobar"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  catalog_name  = "Skyscape Catalogue"
+  network_name  = "${vcd_network.foonet.name}"
+  memory        = 1024
+  cpus          = 1
+  ip            = "10.10.102.160"
+}
+
+resource "vcd_vapp_vm" "moo" {
+  vapp_name       = "${vcd_vapp.foobar.name}"
+  name            = "moo"
+  catalog_name    = "Skyscape Catalogue"
+  template_name   = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  memory          = 1024
+  cpus            = 1
+  ip              = "10.10.102.161"
+  storage_profile = "%s"
+}
+`
+
+const testAccCheckVcdVAppVm_disks = `
+resource "vcd_network" "foonet" {
+	name = "foonet"
+	edge_gateway = "%s"
+	gateway = "10.10.102.1"
+	static_ip_pool {
+		start_address = "10.10.102.2"
+		end_address = "10.10.102.254"
+	}
+}
+
+resource "vcd_vapp" "foobar" {
+  name          = "foobar"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  catalog_name  = "Skyscape Catalogue"
+  network_name  = "${vcd_network.foonet.name}"
+  memory        = 1024
+  cpus          = 1
+  ip            = "10.10.102.160"
+}
+
+resource "vcd_vapp_vm" "moo" {
+  vapp_name     = "${vcd_vapp.foobar.name}"
+  name          = "moo"
+  catalog_name  = "Skyscape Catalogue"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  memory        = 1024
+  cpus          = 1
+  ip            = "10.10.102.161"
+
+  disk {
+    bus_number  = 1
+    unit_number = 0
+    size_in_mb  = %d
+  }
+
+  disk {
+    bus_number  = 1
+    unit_number = 1
+    size_in_mb  = 2048
+  }
+}
+`
+
+const testAccCheckVcdVAppVm_networks = `
+resource "vcd_network" "foonet" {
+	name = "foonet"
+	edge_gateway = "%s"
+	gateway = "10.10.102.1"
+	static_ip_pool {
+		start_address = "10.10.102.2"
+		end_address = "10.10.102.254"
+	}
+}
+
+resource "vcd_vapp" "foobar" {
+  name = "foobar"
+}
+
+resource "vcd_vapp_network" "isolated" {
+  vapp_name = "${vcd_vapp.foobar.name}"
+  name      = "isolated"
+  gateway   = "192.168.2.1"
+  static_ip_pool {
+    start_address = "192.168.2.2"
+    end_address   = "192.168.2.100"
+  }
+}
+
+resource "vcd_vapp_network" "routed" {
+  vapp_name   = "${vcd_vapp.foobar.name}"
+  name        = "routed"
+  gateway     = "192.168.3.1"
+  org_network = "${vcd_network.foonet.name}"
+  static_ip_pool {
+    start_address = "192.168.3.2"
+    end_address   = "192.168.3.100"
+  }
+}
+
+resource "vcd_vapp_vm" "moo" {
+  vapp_name     = "${vcd_vapp.foobar.name}"
+  name          = "moo"
+  catalog_name  = "Skyscape Catalogue"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  memory        = 1024
+  cpus          = 1
+
+  network {
+    name               = "${vcd_vapp_network.isolated.name}"
+    ip_allocation_mode = "POOL"
+    is_primary         = false
+    connected          = %t
+  }
+
+  network {
+    name               = "${vcd_vapp_network.routed.name}"
+    ip_allocation_mode = "MANUAL"
+    ip                 = "192.168.3.10"
+    is_primary         = true
+  }
+}
+`
+
+const testAccCheckVcdVAppVm_cpuCores = `
+resource "vcd_network" "foonet" {
+	name = "foonet"
+	edge_gateway = "%s"
+	gateway = "10.10.102.1"
+	static_ip_pool {
+		start_address = "10.10.102.2"
+		end_address = "10.10.102.254"
+	}
+}
+
+resource "vcd_vapp" "foobar" {
+  name          = "foobar"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  catalog_name  = "Skyscape Catalogue"
+  network_name  = "${vcd_network.foonet.name}"
+  memory        = 1024
+  cpus          = 1
+  ip            = "10.10.102.160"
+}
+
+resource "vcd_vapp_vm" "moo" {
+  vapp_name     = "${vcd_vapp.foobar.name}"
+  name          = "moo"
+  catalog_name  = "Skyscape Catalogue"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  memory        = 1024
+  cpus          = %d
+  cpu_cores     = %d
+  ip            = "10.10.102.161"
+}
+`
+
+const testAccCheckVcdVAppVm_nestedHypervisor = `
+resource "vcd_network" "foonet" {
+	name = "foonet"
+	edge_gateway = "%s"
+	gateway = "10.10.102.1"
+	static_ip_pool {
+		start_address = "10.10.102.2"
+		end_address = "10.10.102.254"
+	}
+}
+
+resource "vcd_vapp" "foobar" {
+  name          = "foobar"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  catalog_name  = "Skyscape Catalogue"
+  network_name  = "${vcd_network.foonet.name}"
+  memory        = 1024
+  cpus          = 1
+  ip            = "10.10.102.160"
+}
+
+resource "vcd_vapp_vm" "moo" {
+  vapp_name                 = "${vcd_vapp.foobar.name}"
+  name                      = "moo"
+  catalog_name              = "Skyscape Catalogue"
+  template_name             = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  memory                    = 1024
+  cpus                      = 1
+  ip                        = "10.10.102.161"
+  nested_hypervisor_enabled = %t
+}
+`
+
+const testAccCheckVcdVAppVm_shutdownGuest = `
+resource "vcd_network" "foonet" {
+	name = "foonet"
+	edge_gateway = "%s"
+	gateway = "10.10.102.1"
+	static_ip_pool {
+		start_address = "10.10.102.2"
+		end_address = "10.10.102.254"
+	}
+}
+
+resource "vcd_vapp" "foobar" {
+  name          = "foobar"
+  template_name = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  catalog_name  = "Skyscape Catalogue"
+  network_name  = "${vcd_network.foonet.name}"
+  memory        = 1024
+  cpus          = 1
+  ip            = "10.10.102.160"
+}
+
+resource "vcd_vapp_vm" "moo" {
+  vapp_name        = "${vcd_vapp.foobar.name}"
+  name             = "moo"
+  catalog_name     = "Skyscape Catalogue"
+  template_name    = "Skyscape_CentOS_6_4_x64_50GB_Small_v1.0.1"
+  memory           = %d
+  cpus             = 1
+  ip               = "10.10.102.161"
+  shutdown_guest   = true
+  shutdown_timeout = 120
 }
 `
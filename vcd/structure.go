@@ -1,8 +1,13 @@
 package vcd
 
 import (
+	"bytes"
 	"fmt"
+	"net"
+	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform/helper/resource"
@@ -10,6 +15,19 @@ import (
 	types "github.com/ukcloud/govcloudair/types/v56"
 )
 
+var hrefUUIDRegexp = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// uuidFromHref returns the UUID ending the href of a vCD object, such as
+// https://vcd/api/vApp/vapp-<uuid>, or "" when it is not such an href. The
+// UUID identifies the object whatever the host and prefix of the href.
+func uuidFromHref(href string) string {
+	u, err := url.ParseRequestURI(href)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return hrefUUIDRegexp.FindString(strings.ToLower(strings.TrimSuffix(u.Path, "/")))
+}
+
 func expandIPRange(configured []interface{}) types.IPRanges {
 	ipRange := make([]*types.IPRange, 0, len(configured))
 
@@ -31,6 +49,98 @@ func expandIPRange(configured []interface{}) types.IPRanges {
 	return ipRanges
 }
 
+func flattenIPRange(ipRanges *types.IPRanges) []map[string]interface{} {
+	pools := make([]map[string]interface{}, 0, len(ipRanges.IPRange))
+
+	for _, ip := range ipRanges.IPRange {
+		pools = append(pools, map[string]interface{}{
+			"start_address": ip.StartAddress,
+			"end_address":   ip.EndAddress,
+		})
+	}
+
+	return pools
+}
+
+// checkIPRanges verifies that each range starts before it ends and that the
+// ranges do not overlap. Terraform cannot compare the elements of a set when
+// planning, so this is done before they are sent to vCD.
+func checkIPRanges(ipRanges types.IPRanges) error {
+	for i, r := range ipRanges.IPRange {
+		start := net.ParseIP(r.StartAddress).To4()
+		end := net.ParseIP(r.EndAddress).To4()
+		if start == nil || end == nil {
+			return fmt.Errorf("IP range %s-%s is not a range of IPv4 addresses", r.StartAddress, r.EndAddress)
+		}
+		if bytes.Compare(start, end) > 0 {
+			return fmt.Errorf("IP range %s-%s starts after it ends", r.StartAddress, r.EndAddress)
+		}
+
+		for _, o := range ipRanges.IPRange[:i] {
+			if bytes.Compare(start, net.ParseIP(o.EndAddress).To4()) <= 0 &&
+				bytes.Compare(end, net.ParseIP(o.StartAddress).To4()) >= 0 {
+				return fmt.Errorf("IP ranges %s-%s and %s-%s overlap", o.StartAddress, o.EndAddress, r.StartAddress, r.EndAddress)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateIPv4Address(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if net.ParseIP(value).To4() == nil {
+		errors = append(errors, fmt.Errorf("%q must be an IPv4 address, got %q", k, value))
+	}
+	return
+}
+
+func expandGuestCustomization(configured []interface{}) *types.GuestCustomizationSection {
+	data := configured[0].(map[string]interface{})
+
+	script := make([]string, 0, len(data["initscript"].([]interface{})))
+	for _, line := range data["initscript"].([]interface{}) {
+		script = append(script, line.(string))
+	}
+
+	password := data["admin_password"].(string)
+	auto := data["admin_password_auto"].(bool)
+
+	return &types.GuestCustomizationSection{
+		Enabled:              data["enabled"].(bool),
+		ChangeSid:            data["change_sid"].(bool),
+		AdminPasswordEnabled: password != "" || auto,
+		AdminPasswordAuto:    auto,
+		AdminPassword:        password,
+		ComputerName:         data["computer_name"].(string),
+		CustomizationScript:  strings.Join(script, "\n"),
+	}
+}
+
+// flattenGuestCustomization keeps the configured admin password as vCD returns
+// the generated one when admin_password_auto is set.
+func flattenGuestCustomization(section *types.GuestCustomizationSection, password string) []map[string]interface{} {
+	script := make([]string, 0)
+	if section.CustomizationScript != "" {
+		script = strings.Split(strings.Replace(section.CustomizationScript, "\r\n", "\n", -1), "\n")
+	}
+
+	if !section.AdminPasswordAuto && section.AdminPassword != "" {
+		password = section.AdminPassword
+	}
+
+	return []map[string]interface{}{
+		map[string]interface{}{
+			"enabled":             section.Enabled,
+			"change_sid":          section.ChangeSid,
+			"admin_password":      password,
+			"admin_password_auto": section.AdminPasswordAuto,
+			"computer_name":       section.ComputerName,
+			"initscript":          script,
+		},
+	}
+}
+
 func expandFirewallRules(d *schema.ResourceData, gateway *types.EdgeGateway) ([]*types.FirewallRule, error) {
 	//firewallRules := make([]*types.FirewallRule, 0, len(configured))
 	firewallRules := gateway.Configuration.EdgeGatewayServiceConfiguration.FirewallService.FirewallRule
@@ -73,7 +183,27 @@ func expandFirewallRules(d *schema.ResourceData, gateway *types.EdgeGateway) ([]
 			SourceIP:             d.Get(prefix + ".source_ip").(string),
 			EnableLogging:        false,
 		}
-		firewallRules = append(firewallRules, rule)
+
+		aboveRuleID := d.Get(prefix + ".above_rule_id").(string)
+		if aboveRuleID == "" {
+			firewallRules = append(firewallRules, rule)
+			continue
+		}
+
+		position := -1
+		for j, r := range firewallRules {
+			if r.ID == aboveRuleID {
+				position = j
+				break
+			}
+		}
+		if position == -1 {
+			return nil, fmt.Errorf("Rule %s to insert rule %d above was not found on edge gateway %s", aboveRuleID, i, gateway.Name)
+		}
+
+		firewallRules = append(firewallRules, nil)
+		copy(firewallRules[position+1:], firewallRules[position:])
+		firewallRules[position] = rule
 	}
 
 	return firewallRules, nil
@@ -108,6 +238,32 @@ func getPortString(port int) string {
 	return portstring
 }
 
+// parseNatPortRange returns the first and last port of a NAT port, which is
+// either a single port, a range such as 8000-8100 or any. Both ports are -1
+// for any.
+func parseNatPortRange(portrange string) (int, int, error) {
+	if strings.ToLower(portrange) == "any" {
+		return -1, -1, nil
+	}
+
+	bounds := strings.SplitN(portrange, "-", 2)
+	ports := make([]int, len(bounds))
+	for i, b := range bounds {
+		port, err := strconv.Atoi(b)
+		if err != nil || port < 1 || port > 65535 {
+			return 0, 0, fmt.Errorf("must be a port between 1 and 65535, a range of such ports or any, got %q", portrange)
+		}
+		ports[i] = port
+	}
+
+	first, last := ports[0], ports[len(ports)-1]
+	if first > last {
+		return 0, 0, fmt.Errorf("range %q is reversed", portrange)
+	}
+
+	return first, last, nil
+}
+
 func retryCall(seconds int, f resource.RetryFunc) error {
 	return resource.Retry(time.Duration(seconds)*time.Second, f)
 }
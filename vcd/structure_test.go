@@ -0,0 +1,328 @@
+package vcd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	govcd "github.com/ukcloud/govcloudair"
+	types "github.com/ukcloud/govcloudair/types/v56"
+)
+
+func TestParseNatPortRange(t *testing.T) {
+	cases := []struct {
+		portrange   string
+		first, last int
+		valid       bool
+	}{
+		{"80", 80, 80, true},
+		{"8000-8100", 8000, 8100, true},
+		{"8000-8000", 8000, 8000, true},
+		{"any", -1, -1, true},
+		{"Any", -1, -1, true},
+		{"8100-8000", 0, 0, false},
+		{"0", 0, 0, false},
+		{"65536", 0, 0, false},
+		{"80-", 0, 0, false},
+		{"-80", 0, 0, false},
+		{"http", 0, 0, false},
+		{"", 0, 0, false},
+	}
+
+	for _, c := range cases {
+		first, last, err := parseNatPortRange(c.portrange)
+		if c.valid != (err == nil) {
+			t.Errorf("%q: expected valid %t, got error %v", c.portrange, c.valid, err)
+			continue
+		}
+		if first != c.first || last != c.last {
+			t.Errorf("%q: expected %d-%d, got %d-%d", c.portrange, c.first, c.last, first, last)
+		}
+
+		_, errors := validateNatPortRange(c.portrange, "port")
+		if c.valid != (len(errors) == 0) {
+			t.Errorf("%q: validation disagrees with parsing: %v", c.portrange, errors)
+		}
+	}
+}
+
+func TestCheckDNATRule(t *testing.T) {
+	cases := []struct {
+		port, translatedPort, protocol, icmpSubType string
+		valid                                       bool
+	}{
+		{"80", "8080", "tcp", "", true},
+		{"8000-8100", "9000-9100", "udp", "", true},
+		{"8000-8100", "9000-9050", "udp", "", false},
+		{"8000-8100", "9000", "tcp", "", false},
+		{"any", "any", "icmp", "echo-request", true},
+		{"any", "any", "tcp", "echo-request", false},
+	}
+
+	for _, c := range cases {
+		err := checkDNATRule(c.port, c.translatedPort, c.protocol, c.icmpSubType)
+		if c.valid != (err == nil) {
+			t.Errorf("%s > %s %s %s: expected valid %t, got error %v",
+				c.port, c.translatedPort, c.protocol, c.icmpSubType, c.valid, err)
+		}
+	}
+}
+
+func TestPortString(t *testing.T) {
+	for _, port := range []string{"any", "22", "65535"} {
+		if got := getPortString(getNumericPort(port)); got != port {
+			t.Errorf("%q: round trip gave %q", port, got)
+		}
+	}
+}
+
+func TestUUIDFromHref(t *testing.T) {
+	cases := map[string]string{
+		"https://vcd.example.com/api/vApp/vapp-5C1F0D0B-4A82-4C9B-9C5E-0F5D2B83A1E4":     "5c1f0d0b-4a82-4c9b-9c5e-0f5d2b83a1e4",
+		"https://vcd.example.com/api/vApp/vm-5c1f0d0b-4a82-4c9b-9c5e-0f5d2b83a1e4/":      "5c1f0d0b-4a82-4c9b-9c5e-0f5d2b83a1e4",
+		"https://vcd.example.com/api/admin/network/5c1f0d0b-4a82-4c9b-9c5e-0f5d2b83a1e4": "5c1f0d0b-4a82-4c9b-9c5e-0f5d2b83a1e4",
+		"https://vcd.example.com/api/vApp/vapp-1234":                                     "",
+		"foobar":                               "",
+		"5c1f0d0b-4a82-4c9b-9c5e-0f5d2b83a1e4": "",
+		"urn:vcloud:vapp:5c1f0d0b-4a82-4c9b-9c5e-0f5d2b83a1e4": "",
+	}
+
+	for href, expected := range cases {
+		if got := uuidFromHref(href); got != expected {
+			t.Errorf("%q: expected %q, got %q", href, expected, got)
+		}
+	}
+}
+
+func TestInsertedMediaHREF(t *testing.T) {
+	cases := map[string]string{
+		"https://vcd.example.com/api/vApp/vm-1:https://vcd.example.com/api/media/2":         "https://vcd.example.com/api/media/2",
+		"https://vcd.example.com:443/api/vApp/vm-1:https://vcd.example.com:443/api/media/2": "https://vcd.example.com:443/api/media/2",
+		"vm-1": "",
+	}
+	for id, expected := range cases {
+		if actual := insertedMediaHREF(id); actual != expected {
+			t.Errorf("%s: expected %q, got %q", id, expected, actual)
+		}
+	}
+}
+
+func TestIPRange(t *testing.T) {
+	configured := []interface{}{
+		map[string]interface{}{"start_address": "10.10.0.10", "end_address": "10.10.0.20"},
+		map[string]interface{}{"start_address": "10.10.0.100", "end_address": "10.10.0.100"},
+	}
+
+	ipRanges := expandIPRange(configured)
+	if len(ipRanges.IPRange) != 2 || ipRanges.IPRange[1].StartAddress != "10.10.0.100" {
+		t.Fatalf("Unexpected IP ranges: %#v", ipRanges.IPRange)
+	}
+
+	flattened := flattenIPRange(&ipRanges)
+	for i, pool := range flattened {
+		if !reflect.DeepEqual(map[string]interface{}(pool), configured[i]) {
+			t.Errorf("Range %d: expected %v, got %v", i, configured[i], pool)
+		}
+	}
+}
+
+func TestCheckIPRanges(t *testing.T) {
+	cases := []struct {
+		ranges [][2]string
+		valid  bool
+	}{
+		{[][2]string{{"10.10.0.10", "10.10.0.20"}, {"10.10.0.100", "10.10.0.100"}, {"10.10.0.30", "10.10.0.40"}}, true},
+		{[][2]string{{"10.10.0.10", "10.10.0.20"}, {"10.10.0.21", "10.10.0.30"}}, true},
+		{[][2]string{{"10.10.0.20", "10.10.0.10"}}, false},
+		{[][2]string{{"10.10.0.10", "10.10.0.20"}, {"10.10.0.20", "10.10.0.30"}}, false},
+		{[][2]string{{"10.10.0.10", "10.10.0.50"}, {"10.10.0.20", "10.10.0.30"}}, false},
+		{[][2]string{{"10.10.0.10", "fe80::1"}}, false},
+	}
+
+	for _, c := range cases {
+		configured := []interface{}{}
+		for _, r := range c.ranges {
+			configured = append(configured, map[string]interface{}{"start_address": r[0], "end_address": r[1]})
+		}
+
+		err := checkIPRanges(expandIPRange(configured))
+		if c.valid && err != nil {
+			t.Errorf("%v: unexpected error %s", c.ranges, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("%v: expected an error", c.ranges)
+		}
+	}
+}
+
+func TestShutdownGuest(t *testing.T) {
+	cases := []struct {
+		name        string
+		shutdownErr error
+		offAfter    int
+		expected    bool
+	}{
+		{"shut down", nil, 2, true},
+		{"shutdown failed", fmt.Errorf("VMware Tools not running"), 0, false},
+		{"timed out", nil, -1, false},
+	}
+
+	for _, c := range cases {
+		shutdowns, polls := 0, 0
+		off := shutdownGuest(func(timeout int) error {
+			shutdowns++
+			if timeout != 1 {
+				t.Errorf("%s: expected the shutdown task to be given 1 second, got %d", c.name, timeout)
+			}
+			return c.shutdownErr
+		}, func() (bool, error) {
+			polls++
+			return c.offAfter >= 0 && polls >= c.offAfter, nil
+		}, 1)
+
+		if shutdowns != 1 {
+			t.Errorf("%s: expected the guest shutdown to be requested once, got %d", c.name, shutdowns)
+		}
+		if c.shutdownErr != nil && polls != 0 {
+			t.Errorf("%s: expected no power state polls after a failed shutdown, got %d", c.name, polls)
+		}
+		if off != c.expected {
+			t.Errorf("%s: expected %t, got %t", c.name, c.expected, off)
+		}
+	}
+}
+
+func TestFlattenOrgVdcStorageProfiles(t *testing.T) {
+	declared := []interface{}{
+		map[string]interface{}{"name": "gold", "limit": 0, "default": false, "enabled": true},
+		map[string]interface{}{"name": "silver", "limit": 1024, "default": false, "enabled": true},
+	}
+	profiles := []*adminVdcStorageProfileType{
+		{Name: "bronze", Limit: 0, Enabled: false, ProviderVdcStorageProfile: &types.Reference{Name: "bronze"}},
+		{Name: "silver", Limit: 1024, Enabled: true, ProviderVdcStorageProfile: &types.Reference{Name: "silver"}},
+		{Name: "gold", Limit: 0, Enabled: true, Default: true, ProviderVdcStorageProfile: &types.Reference{Name: "gold"}},
+	}
+
+	// The declared profiles come first, and the default vCD gave the first
+	// one is not reported as none was declared
+	expected := []map[string]interface{}{
+		{"name": "gold", "limit": 0, "default": false, "enabled": true},
+		{"name": "silver", "limit": 1024, "default": false, "enabled": true},
+		{"name": "bronze", "limit": 0, "default": false, "enabled": false},
+	}
+	if actual := flattenOrgVdcStorageProfiles(declared, profiles); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	// A declared default is reported as is
+	declared[0].(map[string]interface{})["default"] = true
+	expected[0]["default"] = true
+	if actual := flattenOrgVdcStorageProfiles(declared, profiles); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestVmDisksShrink(t *testing.T) {
+	vm := vcdVM{sections: &vmType{VirtualHardwareSection: &virtualHardwareSectionType{Item: []*virtualHardwareItemType{
+		{ResourceType: diskBusSCSI, InstanceID: 2, Address: "0"},
+		{ResourceType: 17, InstanceID: 2000, Parent: 2, AddressOnParent: 0, HostResource: []*virtualHardwareHostResources{{Capacity: 2048}}},
+		{ResourceType: 17, InstanceID: 2001, Parent: 2, AddressOnParent: 1, HostResource: []*virtualHardwareHostResources{{Capacity: 4096}}},
+	}}}}
+	raw := map[string]interface{}{
+		"disk": []interface{}{
+			map[string]interface{}{"bus_type": "scsi", "bus_number": 0, "unit_number": 0, "size_in_mb": 1024},
+		},
+	}
+
+	// Shrinking is refused unless allowed
+	d := schema.TestResourceDataRaw(t, resourceVcdVAppVm().Schema, raw)
+	if _, _, err := vmDisks(d, govcd.Vdc{}, vm); err == nil || !strings.Contains(err.Error(), "allow_disk_shrink") {
+		t.Fatalf("expected the shrink to be refused, got %v", err)
+	}
+
+	// When allowed, the disk is removed before it is added again, and the
+	// other disks are kept
+	raw["allow_disk_shrink"] = true
+	d = schema.TestResourceDataRaw(t, resourceVcdVAppVm().Schema, raw)
+	disks, unshrunk, err := vmDisks(d, govcd.Vdc{}, vm)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := []vmDisk{
+		{BusType: diskBusSCSI, BusNumber: 0, UnitNumber: 0, SizeMB: 1024},
+		{BusType: diskBusSCSI, BusNumber: 0, UnitNumber: 1, SizeMB: 4096},
+	}
+	if !reflect.DeepEqual(disks, expected) {
+		t.Errorf("expected disks %v, got %v", expected, disks)
+	}
+	if !reflect.DeepEqual(unshrunk, expected[1:]) {
+		t.Errorf("expected disks %v before the shrunk one is added, got %v", expected[1:], unshrunk)
+	}
+
+	// Growing needs no replacement
+	raw["disk"].([]interface{})[0].(map[string]interface{})["size_in_mb"] = 2048
+	d = schema.TestResourceDataRaw(t, resourceVcdVAppVm().Schema, raw)
+	if _, unshrunk, err := vmDisks(d, govcd.Vdc{}, vm); err != nil || unshrunk != nil {
+		t.Errorf("expected no replacement, got %v, %v", unshrunk, err)
+	}
+}
+
+func TestVmDiskMoves(t *testing.T) {
+	disk := func(busType string, bus, unit int) interface{} {
+		return map[string]interface{}{"bus_type": busType, "bus_number": bus, "unit_number": unit, "size_in_mb": 1024}
+	}
+	current := []vmDisk{
+		{BusType: diskBusSCSI, BusNumber: 0, UnitNumber: 1, SizeMB: 1024},
+		{BusType: diskBusSCSI, BusNumber: 1, UnitNumber: 0, SizeMB: 1024},
+	}
+
+	cases := []struct {
+		name     string
+		old, new []interface{}
+		expected map[string]string
+		err      string
+	}{
+		{"unchanged", []interface{}{disk("scsi", 0, 1)}, []interface{}{disk("scsi", 0, 1)}, map[string]string{}, ""},
+		{"moved", []interface{}{disk("scsi", 0, 1)}, []interface{}{disk("scsi", 2, 3)}, map[string]string{"scsi 0:1": "scsi 2:3"}, ""},
+		// A disk already at the new placement is managed instead
+		{"taken", []interface{}{disk("scsi", 0, 1)}, []interface{}{disk("scsi", 1, 0)}, map[string]string{}, ""},
+		// Blocks swapping places keep their disks
+		{"reordered", []interface{}{disk("scsi", 0, 1), disk("scsi", 1, 0)}, []interface{}{disk("scsi", 1, 0), disk("scsi", 0, 1)}, map[string]string{}, ""},
+		{"added", nil, []interface{}{disk("scsi", 2, 3)}, map[string]string{}, ""},
+		{"bus type", []interface{}{disk("scsi", 0, 1)}, []interface{}{disk("ide", 0, 0)}, nil, "same type"},
+	}
+
+	for _, c := range cases {
+		moves, err := vmDiskMoves(c.old, c.new, current)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%s: expected error %q, got %v", c.name, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: err: %s", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(moves, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, moves)
+		}
+	}
+}
+
+func TestGetProtocol(t *testing.T) {
+	cases := map[string]types.FirewallRuleProtocols{
+		"tcp":  {TCP: true},
+		"udp":  {UDP: true},
+		"icmp": {ICMP: true},
+		"any":  {Any: true},
+	}
+
+	for expected, protocols := range cases {
+		if got := getProtocol(protocols); got != expected {
+			t.Errorf("%#v: expected %s, got %s", protocols, expected, got)
+		}
+	}
+}